@@ -0,0 +1,52 @@
+package game
+
+import (
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+type ResetGame struct{}
+
+func (game *Game) send(message GameMessage, timeout time.Duration) bool {
+	select {
+	case game.channel <- message:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// INFO Ends the game right away as if it had run out of bricks or time, the next round starts after the usual delay
+func (game *Game) ForceGameOver(reason string, timeout time.Duration) bool {
+	return game.send(EndGame{Reason: reason}, timeout)
+}
+
+func (game *Game) RequestReset(timeout time.Duration) bool {
+	return game.send(ResetGame{}, timeout)
+}
+
+// INFO Starts the round over with the same players, a fresh grid, zeroed scores and only their permanent balls
+func (game *Game) Reset() {
+	if game.GameOver != nil {
+		game.RestartGame()
+		return
+	}
+	game.ResetGrid()
+	game.restartClock()
+	game.SuddenDeath = false
+	for _, player := range game.Players {
+		if player != nil {
+			player.Score = utils.InitialScore
+		}
+	}
+	balls := append([]*Ball{}, game.Balls...)
+	for _, ball := range balls {
+		if !ball.permanent {
+			game.RemoveBall(ball.Id)
+		}
+	}
+	for len(game.Lasers) > 0 {
+		game.RemoveLaser(game.Lasers[0].Id)
+	}
+}
@@ -0,0 +1,63 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestGame_Reset(t *testing.T) {
+	game := StartGame()
+	game.Players[0] = &Player{Index: 0, Connected: true, Score: 42}
+	permanent := NewBall(NewBallChannel(), 100, 100, utils.BallSize, utils.CanvasSize, 0, 1, utils.NewRandom(1))
+	powerUp := NewBall(NewBallChannel(), 100, 100, utils.BallSize, utils.CanvasSize, 0, 2, utils.NewRandom(1))
+	permanent.permanent = true
+	game.Balls = []*Ball{permanent, powerUp}
+	game.Lasers = []*Laser{{Id: 3, done: make(chan struct{})}}
+	game.Wave = 3
+	for i := range game.Canvas.Grid {
+		for j := range game.Canvas.Grid[i] {
+			game.Canvas.Grid[i][j].Data.Type = utils.Cells.Empty
+		}
+	}
+
+	game.Reset()
+
+	if game.Players[0].Score != utils.InitialScore {
+		t.Errorf("Expected the score to be reset to %d, got %d", utils.InitialScore, game.Players[0].Score)
+	}
+	if len(game.Balls) != 1 || game.Balls[0] != permanent {
+		t.Errorf("Expected only the permanent ball to remain, got %d balls", len(game.Balls))
+	}
+	if len(game.Lasers) != 0 {
+		t.Errorf("Expected lasers to be removed")
+	}
+	if game.Wave != 1 || !game.Canvas.Grid.HasBricks() {
+		t.Errorf("Expected a fresh grid on the first wave")
+	}
+}
+
+func TestGame_ForceGameOver(t *testing.T) {
+	game := StartGame()
+	go game.ReadGameChannel()
+
+	if !game.ForceGameOver("ended by an admin", time.Second) {
+		t.Fatalf("Expected the game to accept the forced game over")
+	}
+	snapshot, ok := game.RequestSnapshot(time.Second)
+	if !ok {
+		t.Fatalf("Expected a snapshot from the game")
+	}
+	if snapshot.GameOver == nil || snapshot.GameOver.Reason != "ended by an admin" {
+		t.Errorf("Expected the game to be over with the admin reason, got %+v", snapshot.GameOver)
+	}
+}
+
+func TestGame_ForceGameOver_NotResponding(t *testing.T) {
+	game := StartGame()
+
+	if game.ForceGameOver("ended by an admin", 10*time.Millisecond) {
+		t.Errorf("Expected the forced game over to time out without a game routine")
+	}
+}
@@ -0,0 +1,66 @@
+package game
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+type CheckIdlePlayers struct{}
+
+// INFO There are no bots to take over, so idle players are only checked about once a second while an AFK timeout is configured
+func (game *Game) WatchIdlePlayers(ctx context.Context) {
+	if game.config.AFKTimeout <= 0 {
+		return
+	}
+	interval := time.Second
+	if game.config.AFKTimeout < interval {
+		interval = game.config.AFKTimeout
+	}
+	ticker := game.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case game.channel <- CheckIdlePlayers{}:
+		}
+	}
+}
+
+// INFO Flags connected players without input for the AFK timeout and parks their paddle, their next input clears the flag
+func (game *Game) CheckIdlePlayers(now time.Time) {
+	for index, player := range game.Players {
+		if player == nil || !player.Connected {
+			continue
+		}
+		idle := now.Sub(player.LastInputAt()) >= game.config.AFKTimeout
+		if idle == player.Afk {
+			continue
+		}
+		player.Afk = idle
+		if !idle {
+			utils.LogInfo("Player is back", "player", index)
+			continue
+		}
+		utils.LogInfo("Player is away", "player", index)
+		if paddle := game.Paddles[index]; paddle != nil {
+			paddle.Direction = ""
+		}
+	}
+}
+
+func (player *Player) touchInput(now time.Time) {
+	atomic.StoreInt64(&player.lastInput, now.UnixNano())
+}
+
+func (player *Player) LastInputAt() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&player.lastInput))
+}
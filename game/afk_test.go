@@ -0,0 +1,59 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGame_CheckIdlePlayers(t *testing.T) {
+	game := StartGame()
+	game.config.AFKTimeout = time.Minute
+	now := time.Now()
+	idle := &Player{Index: 0, Connected: true}
+	idle.touchInput(now.Add(-2 * time.Minute))
+	active := &Player{Index: 1, Connected: true}
+	active.touchInput(now.Add(-time.Second))
+	away := &Player{Index: 2}
+	away.touchInput(now.Add(-2 * time.Minute))
+	game.Players = [4]*Player{idle, active, away}
+	paddle := &Paddle{Index: 0, Direction: "left"}
+	game.Paddles = [4]*Paddle{paddle}
+
+	game.CheckIdlePlayers(now)
+	if !idle.Afk {
+		t.Errorf("Expected the idle player to be flagged afk")
+	}
+	if paddle.Direction != "" {
+		t.Errorf("Expected the afk player's paddle to stop, got %q", paddle.Direction)
+	}
+	if active.Afk {
+		t.Errorf("Expected the active player not to be flagged")
+	}
+	if away.Afk {
+		t.Errorf("Expected disconnected players to be left to the grace period")
+	}
+
+	idle.touchInput(now.Add(time.Second))
+	game.CheckIdlePlayers(now.Add(2 * time.Second))
+	if idle.Afk {
+		t.Errorf("Expected new input to clear the afk flag")
+	}
+}
+
+func TestGame_WatchIdlePlayers(t *testing.T) {
+	game := StartGame()
+	game.config.AFKTimeout = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go game.WatchIdlePlayers(ctx)
+
+	select {
+	case message := <-game.channel:
+		if message != (CheckIdlePlayers{}) {
+			t.Errorf("Expected an idle check, got %+v", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected idle players to be checked")
+	}
+}
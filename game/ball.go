@@ -3,6 +3,7 @@ package game
 import (
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/lguibr/pongo/utils"
@@ -14,6 +15,19 @@ type BallPositionMessage struct {
 	Ball *Ball
 }
 
+type BallSpin struct {
+	Factor float64
+	Decay  float64
+}
+
+const minBallSpin = 0.001
+
+// INFO Owner of balls spawned for a player that already owns too many, they score for nobody
+const NoOwner = -1
+
+// INFO Color of balls without a connected owner
+var NeutralBallColor = [3]int{128, 128, 128}
+
 type Ball struct {
 	X          int              `json:"x"`
 	Y          int              `json:"y"`
@@ -25,10 +39,47 @@ type Ball struct {
 	Id         int              `json:"id"`
 	OwnerIndex int              `json:"ownerIndex"`
 	Phasing    bool             `json:"phasing"`
+	Ghost      bool             `json:"ghost"`
+	Layer      int              `json:"layer"`
 	Mass       int              `json:"mass"`
+	Stuck      bool             `json:"stuck"`
+	Spin       float64          `json:"spin"`
+	Color      [3]int           `json:"color"`
 	Channel    chan BallMessage `json:"-"`
 	canvasSize int
-	open       bool
+	permanent  bool
+	cues       []EventCue
+	//INFO Walls reached since the last paddle hit gave the ball its owner
+	wallHitsSinceOwnerChange int
+	cornerWedges             bool
+	stuckTo                  *Paddle
+	stuckAt                  [2]int
+	launchWith               [2]int
+	gravity                  [2]float64
+	carry                    [2]float64
+	maxSpeed                 int
+	speedCap                 int
+	substeps                 int
+	substepDone              chan struct{}
+	//INFO Where the ball last made progress from and the ticks it has stayed around there since
+	wedgedAnchor [2]int
+	wedgedTicks  int
+	spin         BallSpin
+	slowed       bool
+	previousX    int
+	previousY    int
+	moved        bool
+	//INFO Ticks left before the ball can hit a paddle again, and how many a hit blocks
+	paddleHitCooldown      int
+	paddleHitCooldownTicks int
+	//INFO Slower balls only damage a brick every so many hits, the charge carries the fraction over between hits
+	minDamageSpeed int
+	damageCharge   float64
+	edgeAngleBoost float64
+	maxRadius      int
+	//INFO Closed once the ball leaves the game, stopping its engine and reader
+	done  chan struct{}
+	state sync.Locker
 }
 
 func (b *Ball) GetX() int      { return b.X }
@@ -40,7 +91,7 @@ func NewBallChannel() chan BallMessage {
 
 }
 
-func NewBall(channel chan BallMessage, x, y, radius, canvasSize, ownerIndex, index int) *Ball {
+func NewBall(channel chan BallMessage, x, y, radius, canvasSize, ownerIndex, index int, random *rand.Rand) *Ball {
 	if x == 0 && y == 0 {
 		cardinalPosition := [2]int{canvasSize/2 - utils.CellSize*1.5, 0}
 
@@ -69,8 +120,8 @@ func NewBall(channel chan BallMessage, x, y, radius, canvasSize, ownerIndex, ind
 	maxVelocity := utils.MaxVelocity
 	minVelocity := utils.MinVelocity
 
-	cardinalVX := minVelocity + rand.Intn(maxVelocity-minVelocity)
-	cardinalVY := utils.RandomNumberN(maxVelocity)
+	cardinalVX := minVelocity + random.Intn(maxVelocity-minVelocity)
+	cardinalVY := utils.RandomNumberN(random, maxVelocity)
 
 	vx, vy := utils.RotateVector(ownerIndex, -cardinalVX, cardinalVY, 1, 1)
 	return &Ball{
@@ -83,28 +134,193 @@ func NewBall(channel chan BallMessage, x, y, radius, canvasSize, ownerIndex, ind
 		OwnerIndex: ownerIndex,
 		canvasSize: canvasSize,
 		Channel:    channel,
-		open:       true,
+		done:       make(chan struct{}),
 		Mass:       mass,
 	}
 }
 
+// INFO Points the ball at x, y keeping its speed, deviating by a random angle of up to jitter radians either way
+func (ball *Ball) AimAt(x, y int, jitter float64, random *rand.Rand) {
+	speed := math.Hypot(float64(ball.Vx), float64(ball.Vy))
+	angle := math.Atan2(float64(y-ball.Y), float64(x-ball.X))
+	if jitter > 0 {
+		angle += (random.Float64()*2 - 1) * jitter
+	}
+	ball.Vx = int(math.Round(speed * math.Cos(angle)))
+	ball.Vy = int(math.Round(speed * math.Sin(angle)))
+}
+
 func (ball *Ball) Engine() {
 	for {
-		if !ball.open {
+		steps := utils.MaxInt(ball.substeps, 1)
+		for step := 0; step < steps; step++ {
+			withState(ball.state, func() {
+				//INFO Checked holding the lock, so a ball removed meanwhile never moves again
+				if !ball.removed() {
+					ball.MoveSubstep(step, steps)
+				}
+			})
+			select {
+			case ball.Channel <- BallPositionMessage{ball}:
+			case <-ball.done:
+				return
+			}
+			//INFO The next substep only moves once the collisions of this one were handled
+			if steps > 1 {
+				select {
+				case <-ball.substepDone:
+				case <-time.After(utils.Period):
+				}
+			}
+		}
+		select {
+		case <-ball.done:
 			return
+		case <-time.After(utils.Period):
+		}
+	}
+}
+
+func (ball *Ball) stop() {
+	if ball.done != nil {
+		close(ball.done)
+	}
+}
+
+func (ball *Ball) removed() bool {
+	select {
+	case <-ball.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// INFO Messages reach the ball reader without waiting on its buffer, the reader itself sends some while it holds the state lock
+func (ball *Ball) send(message BallMessage) {
+	select {
+	case ball.Channel <- message:
+		return
+	default:
+	}
+	go func() {
+		select {
+		case ball.Channel <- message:
+		case <-ball.done:
 		}
-		ball.Move()
-		ball.Channel <- BallPositionMessage{ball}
-		time.Sleep(utils.Period)
+	}()
+}
+
+// INFO Counts the ticks the ball keeps within half a cell of where it last made progress, true once it reached the limit
+func (ball *Ball) Wedged(limit int) bool {
+	if limit <= 0 || ball.Stuck {
+		ball.wedgedTicks = 0
+		return false
+	}
+	if utils.Abs(ball.X-ball.wedgedAnchor[0]) > utils.CellSize/2 || utils.Abs(ball.Y-ball.wedgedAnchor[1]) > utils.CellSize/2 {
+		ball.wedgedAnchor = [2]int{ball.X, ball.Y}
+		ball.wedgedTicks = 0
+		return false
+	}
+	ball.wedgedTicks++
+	return ball.wedgedTicks >= limit
+}
+
+// INFO Sends the ball off in a random direction at a random speed, phasing briefly so it can leave whatever trapped it
+func (ball *Ball) Nudge(random *rand.Rand) {
+	angle := random.Float64() * 2 * math.Pi
+	speed := float64(utils.MinVelocity + random.Intn(utils.MaxVelocity-utils.MinVelocity+1))
+	ball.Vx = int(math.Round(speed * math.Cos(angle)))
+	ball.Vy = int(math.Round(speed * math.Sin(angle)))
+	ball.wedgedAnchor = [2]int{ball.X, ball.Y}
+	ball.wedgedTicks = 0
+}
+
+func (ball *Ball) substepHandled() {
+	select {
+	case ball.substepDone <- struct{}{}:
+	default:
 	}
 }
 
 func (ball *Ball) Move() {
-	ball.X += ball.Vx + ball.Ax/2
-	ball.Y += ball.Vy + ball.Ay/2
+	ball.MoveSubstep(0, 1)
+}
+
+// INFO Moves the ball by its share of one tick's travel, velocity changes are applied once after the last substep
+func (ball *Ball) MoveSubstep(step, steps int) {
+	if step == 0 && ball.paddleHitCooldown > 0 {
+		ball.paddleHitCooldown--
+	}
+	ball.previousX, ball.previousY, ball.moved = ball.X, ball.Y, true
+	if ball.Stuck && ball.stuckTo != nil {
+		ball.X = ball.stuckTo.X + ball.stuckAt[0]
+		ball.Y = ball.stuckTo.Y + ball.stuckAt[1]
+		return
+	}
+
+	//INFO Power-ups and paddles change the velocity directly, the hard cap catches them before the ball moves
+	ball.ClampSpeed(ball.speedCap)
+	ball.X += substepShare(ball.Vx+ball.Ax/2, step, steps)
+	ball.Y += substepShare(ball.Vy+ball.Ay/2, step, steps)
+	if step < steps-1 {
+		return
+	}
 
 	ball.Vx += ball.Ax
 	ball.Vy += ball.Ay
+	ball.applyGravity()
+	ball.applySpin()
+}
+
+// INFO Integer part of a distance travelled during one substep, the shares of every substep add up to the whole distance
+func substepShare(distance, step, steps int) int {
+	return distance*(step+1)/steps - distance*step/steps
+}
+
+// INFO Velocities are integers, so fractional accelerations accumulate until they add up to a whole step
+func (ball *Ball) accelerate(ax, ay float64) {
+	ball.carry[0] += ax
+	ball.carry[1] += ay
+	stepX, stepY := math.Trunc(ball.carry[0]), math.Trunc(ball.carry[1])
+	ball.carry[0] -= stepX
+	ball.carry[1] -= stepY
+	ball.Vx += int(stepX)
+	ball.Vy += int(stepY)
+	ball.ClampSpeed(ball.maxSpeed)
+}
+
+func (ball *Ball) applyGravity() {
+	if ball.gravity == [2]float64{} {
+		return
+	}
+	ball.accelerate(ball.gravity[0], ball.gravity[1])
+}
+
+// INFO Spin pushes the ball sideways to its velocity so it curves, and wears off a little every tick
+func (ball *Ball) applySpin() {
+	if ball.Spin == 0 {
+		return
+	}
+	ball.accelerate(-float64(ball.Vy)*ball.Spin, float64(ball.Vx)*ball.Spin)
+	ball.Spin *= ball.spin.Decay
+	if math.Abs(ball.Spin) < minBallSpin {
+		ball.Spin = 0
+	}
+}
+
+// INFO Rescales the velocity so its magnitude never exceeds maxSpeed, keeping its direction
+func (ball *Ball) ClampSpeed(maxSpeed int) {
+	if maxSpeed <= 0 {
+		return
+	}
+	speed := math.Hypot(float64(ball.Vx), float64(ball.Vy))
+	if speed <= float64(maxSpeed) {
+		return
+	}
+	ratio := float64(maxSpeed) / speed
+	ball.Vx = int(float64(ball.Vx) * ratio)
+	ball.Vy = int(float64(ball.Vy) * ratio)
 }
 
 func (ball *Ball) getCenterIndex() (x, y int) {
@@ -143,14 +359,64 @@ func (ball *Ball) IncreaseVelocity(ratio float64) {
 	ball.Vy = int(math.Floor(float64(ball.Vy) * ratio))
 }
 
+// INFO A moving component keeps at least a step, so a slowed ball never stops on an axis the inverse ratio couldn't restore
+func (ball *Ball) ScaleVelocity(ratio float64) {
+	ball.Vx = scaleVelocityComponent(ball.Vx, ratio)
+	ball.Vy = scaleVelocityComponent(ball.Vy, ratio)
+}
+
+func scaleVelocityComponent(velocity int, ratio float64) int {
+	scaled := int(math.Round(float64(velocity) * ratio))
+	switch {
+	case scaled != 0 || velocity == 0:
+		return scaled
+	case velocity > 0:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// INFO Mass keeps growing but the radius stops at maxRadius, so a ball never outgrows the cells it collides with
 func (ball *Ball) IncreaseMass(additional int) {
 	ball.Mass += additional
 	ball.Radius += additional * 2
+	if ball.maxRadius > 0 {
+		ball.Radius = utils.MinInt(ball.Radius, ball.maxRadius)
+	}
+}
+
+// INFO A ghost ball goes through paddles, unlike phasing it still bounces off bricks, so it mostly ends up scoring behind one
+func (ball *Ball) SetBallGhost(clock Clock, duration time.Duration) {
+	ball.Ghost = true
+	clock.AfterFunc(duration, func() {
+		withState(ball.state, func() { ball.Ghost = false })
+	})
+}
+
+func (ball *Ball) StickTo(paddle *Paddle) {
+	ball.Stuck = true
+	ball.stuckTo = paddle
+	ball.stuckAt = [2]int{ball.X - paddle.X, ball.Y - paddle.Y}
+	ball.launchWith = [2]int{ball.Vx, ball.Vy}
+	ball.Vx = 0
+	ball.Vy = 0
 }
-func (ball *Ball) SetBallPhasing(expiresIn int) {
+
+func (ball *Ball) Launch() {
+	if !ball.Stuck {
+		return
+	}
+	ball.Stuck = false
+	ball.stuckTo = nil
+	ball.Vx = ball.launchWith[0]
+	ball.Vy = ball.launchWith[1]
+}
+
+func (ball *Ball) SetBallPhasing(clock Clock, expiresIn int) {
 	ball.Phasing = true
-	go time.AfterFunc(time.Duration(expiresIn)*time.Second, func() {
-		ball.Phasing = false
+	clock.AfterFunc(time.Duration(expiresIn)*time.Second, func() {
+		withState(ball.state, func() { ball.Phasing = false })
 	})
 
 }
@@ -1,7 +1,10 @@
 package game
 
 import (
+	"math"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/lguibr/pongo/utils"
 )
@@ -27,7 +30,7 @@ func TestNewBall(t *testing.T) {
 	ballChannel := NewBallChannel()
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			ball := NewBall(ballChannel, tc.x, tc.y, tc.radius, canvasSize, tc.index, tc.index)
+			ball := NewBall(ballChannel, tc.x, tc.y, tc.radius, canvasSize, tc.index, tc.index, utils.NewRandom(1))
 			if ball.X != tc.expectedX {
 				t.Errorf("Expected X to be %d, but got %d", tc.expectedX, ball.X)
 			}
@@ -366,3 +369,209 @@ func TestBall_GetCenterIndex(t *testing.T) {
 		}
 	}
 }
+
+func TestBall_Move_Gravity(t *testing.T) {
+	ball := &Ball{X: 100, Y: 100, Vx: 4, Vy: 0, gravity: [2]float64{0, 0.5}}
+	positions := [][2]int{}
+	for i := 0; i < 4; i++ {
+		ball.Move()
+		positions = append(positions, [2]int{ball.X, ball.Y})
+	}
+
+	expected := [][2]int{{104, 100}, {108, 100}, {112, 101}, {116, 102}}
+	if !reflect.DeepEqual(positions, expected) {
+		t.Errorf("Expected the ball to arc along %v, got %v", expected, positions)
+	}
+	if ball.Vx != 4 || ball.Vy != 2 {
+		t.Errorf("Expected gravity to only change the vertical velocity, got (%d, %d)", ball.Vx, ball.Vy)
+	}
+}
+
+func TestBall_ClampSpeed(t *testing.T) {
+	testCases := []struct {
+		vx, vy, maxSpeed     int
+		expectedX, expectedY int
+	}{
+		{3, 4, 10, 3, 4},
+		{6, 8, 5, 3, 4},
+		{-6, 8, 5, -3, 4},
+		{12, 0, 0, 12, 0},
+	}
+	for _, tc := range testCases {
+		ball := &Ball{Vx: tc.vx, Vy: tc.vy}
+		ball.ClampSpeed(tc.maxSpeed)
+		if ball.Vx != tc.expectedX || ball.Vy != tc.expectedY {
+			t.Errorf("ClampSpeed(%d) on (%d, %d) = (%d, %d), want (%d, %d)", tc.maxSpeed, tc.vx, tc.vy, ball.Vx, ball.Vy, tc.expectedX, tc.expectedY)
+		}
+	}
+}
+
+func TestBall_Move_SpeedCap(t *testing.T) {
+	ball := &Ball{X: 300, Y: 300, Vx: 6, Vy: 8, Radius: 10, canvasSize: utils.CanvasSize, maxSpeed: 10, speedCap: 12}
+
+	for i := 0; i < 10; i++ {
+		ball.IncreaseVelocity(1.1)
+		ball.Move()
+		if speed := math.Hypot(float64(ball.Vx), float64(ball.Vy)); speed > 12 {
+			t.Fatalf("Expected the speed to stay under the cap of 12 after %d power-ups, got %f", i+1, speed)
+		}
+	}
+	if ball.Vx <= 0 || ball.Vy <= 0 {
+		t.Errorf("Expected the cap to keep the ball's direction, got (%d, %d)", ball.Vx, ball.Vy)
+	}
+}
+
+func TestBall_MoveSubstep(t *testing.T) {
+	whole := &Ball{X: 100, Y: 100, Vx: 7, Vy: -5, gravity: [2]float64{0, 1}, paddleHitCooldown: 2}
+	split := *whole
+	whole.Move()
+
+	positions := [][2]int{}
+	for step := 0; step < 4; step++ {
+		split.MoveSubstep(step, 4)
+		positions = append(positions, [2]int{split.X, split.Y})
+	}
+	expected := [][2]int{{101, 99}, {103, 98}, {105, 97}, {107, 95}}
+	if !reflect.DeepEqual(positions, expected) {
+		t.Errorf("Expected the substeps to pass through %v, got %v", expected, positions)
+	}
+	if split.X != whole.X || split.Y != whole.Y || split.Vx != whole.Vx || split.Vy != whole.Vy {
+		t.Errorf("Expected four substeps to end like a whole move at (%d, %d) with (%d, %d), got (%d, %d) with (%d, %d)", whole.X, whole.Y, whole.Vx, whole.Vy, split.X, split.Y, split.Vx, split.Vy)
+	}
+	if split.paddleHitCooldown != 1 {
+		t.Errorf("Expected the paddle cooldown to tick once per tick, got %d", split.paddleHitCooldown)
+	}
+}
+
+func TestBall_Wedged(t *testing.T) {
+	ball := &Ball{X: 100, Y: 100}
+	ball.Wedged(3)
+	for i := 0; i < 2; i++ {
+		//INFO Bouncing back and forth between two bricks doesn't count as progress
+		ball.X += (i%2*2 - 1) * 4
+		if ball.Wedged(3) {
+			t.Fatalf("Expected the ball not to be wedged after %d ticks", i+1)
+		}
+	}
+	if !ball.Wedged(3) {
+		t.Errorf("Expected the ball to be wedged after 3 ticks around the same spot")
+	}
+
+	ball.X += utils.CellSize
+	if ball.Wedged(3) || ball.wedgedTicks != 0 {
+		t.Errorf("Expected progress to reset the count, got %d ticks", ball.wedgedTicks)
+	}
+	if ball.Wedged(0) {
+		t.Errorf("Expected a zero limit to never report a wedged ball")
+	}
+}
+
+func TestBall_Nudge(t *testing.T) {
+	random := utils.NewRandom(1)
+	for i := 0; i < 20; i++ {
+		ball := &Ball{X: 100, Y: 100, wedgedTicks: 10}
+		ball.Nudge(random)
+		speed := math.Hypot(float64(ball.Vx), float64(ball.Vy))
+		if speed < utils.MinVelocity-1 || speed > utils.MaxVelocity+1 {
+			t.Errorf("Expected a nudge between the min and max velocity, got %f", speed)
+		}
+		if ball.wedgedTicks != 0 {
+			t.Errorf("Expected a nudge to reset the wedged count, got %d", ball.wedgedTicks)
+		}
+	}
+}
+
+func TestBall_AimAt(t *testing.T) {
+	random := utils.NewRandom(1)
+	ball := &Ball{X: 100, Y: 500, Vx: -6, Vy: 8}
+	ball.AimAt(500, 500, 0, random)
+	if ball.Vx != 10 || ball.Vy != 0 {
+		t.Errorf("Expected no jitter to aim straight at the target, got (%d, %d)", ball.Vx, ball.Vy)
+	}
+
+	for _, jitter := range []float64{0.1, math.Pi / 4} {
+		for i := 0; i < 100; i++ {
+			ball := &Ball{X: 100, Y: 500, Vx: -60, Vy: 80}
+			ball.AimAt(500, 500, jitter, random)
+			deviation := math.Abs(math.Atan2(float64(ball.Vy), float64(ball.Vx)))
+			if deviation > jitter+0.02 {
+				t.Errorf("Expected a deviation of at most %f, got %f", jitter, deviation)
+			}
+			speed := math.Hypot(float64(ball.Vx), float64(ball.Vy))
+			if math.Abs(speed-100) > 1 {
+				t.Errorf("Expected aiming to keep the speed, got %f", speed)
+			}
+		}
+	}
+}
+
+func TestBall_Move_Spin(t *testing.T) {
+	ball := &Ball{X: 100, Y: 100, Vx: 5, Vy: 0, Spin: 0.2, spin: BallSpin{Decay: 0.9}}
+
+	ball.Move()
+	ball.Move()
+	ball.Move()
+
+	if ball.Vy == 0 {
+		t.Errorf("Expected the spin to curve the ball, got velocity (%d, %d)", ball.Vx, ball.Vy)
+	}
+	if ball.Spin >= 0.2 {
+		t.Errorf("Expected the spin to decay, got %f", ball.Spin)
+	}
+
+	for i := 0; i < 60; i++ {
+		ball.Move()
+	}
+	if ball.Spin != 0 {
+		t.Errorf("Expected the spin to wear off, got %f", ball.Spin)
+	}
+}
+
+func TestBall_SetBallPhasing(t *testing.T) {
+	clock := NewManualClock(time.Unix(100, 0))
+	ball := &Ball{}
+	ball.SetBallPhasing(clock, 2)
+	if !ball.Phasing {
+		t.Fatalf("Expected the ball to start phasing")
+	}
+	clock.Advance(time.Second)
+	if !ball.Phasing {
+		t.Errorf("Expected the ball to keep phasing before it expires")
+	}
+	clock.Advance(time.Second)
+	if ball.Phasing {
+		t.Errorf("Expected phasing to wear off once it expires")
+	}
+}
+
+func TestBall_IncreaseMass(t *testing.T) {
+	ball := &Ball{Mass: 1, Radius: utils.BallSize, maxRadius: utils.CellSize / 2}
+	for i := 0; i < 20; i++ {
+		ball.IncreaseMass(1)
+	}
+	if ball.Mass != 21 {
+		t.Errorf("Expected the mass to keep growing, got %d", ball.Mass)
+	}
+	if ball.Radius != utils.CellSize/2 {
+		t.Errorf("Expected the radius to stop at %d, got %d", utils.CellSize/2, ball.Radius)
+	}
+
+	uncapped := &Ball{Mass: 1, Radius: utils.BallSize}
+	uncapped.IncreaseMass(20)
+	if uncapped.Radius != utils.BallSize+40 {
+		t.Errorf("Expected no cap to let the radius grow, got %d", uncapped.Radius)
+	}
+}
+
+func TestBall_SetBallGhost(t *testing.T) {
+	clock := NewManualClock(time.Unix(100, 0))
+	ball := &Ball{}
+	ball.SetBallGhost(clock, 3*time.Second)
+	if !ball.Ghost {
+		t.Fatalf("Expected the ball to turn into a ghost")
+	}
+	clock.Advance(3 * time.Second)
+	if ball.Ghost {
+		t.Errorf("Expected the ghost to wear off once it expires")
+	}
+}
@@ -12,6 +12,7 @@ type Canvas struct {
 	GridSize   int  `json:"gridSize"`
 	CanvasSize int  `json:"canvasSize"`
 	CellSize   int  `json:"cellSize"`
+	MaxLife    int  `json:"maxLife"` //INFO Highest brick life of the fresh grid, clients normalize brick life against it for their color ramp
 }
 
 func (c *Canvas) GetGrid() [][]Cell  { return c.Grid }
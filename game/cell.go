@@ -6,6 +6,7 @@ type BrickData struct {
 	Type  utils.CellType `json:"type"`
 	Life  int            `json:"life"`
 	Level int            `json:"level"`
+	Layer int            `json:"layer,omitempty"` //INFO Balls of another non-zero layer pass through, left out while zero to keep grids small
 }
 type Cell struct {
 	X    int        `json:"x"`
@@ -26,7 +27,7 @@ func NewCell(x, y, life int, typeOfCell utils.CellType) Cell {
 }
 
 func NewBrickData(typeOfCell utils.CellType, life int) *BrickData {
-	if typeOfCell == utils.Cells.Brick && life == 0 {
+	if typeOfCell.IsBrick() && life == 0 {
 		life = 1
 	}
 	if typeOfCell == utils.Cells.Empty {
@@ -45,6 +46,9 @@ func (cell *Cell) Compare(comparedCell Cell) bool {
 	if cell.Data.Level != comparedCell.Data.Level {
 		return false
 	}
+	if cell.Data.Layer != comparedCell.Data.Layer {
+		return false
+	}
 	return true
 }
 
@@ -58,5 +62,8 @@ func (data *BrickData) Compare(comparedData *BrickData) bool {
 	if data.Level != comparedData.Level {
 		return false
 	}
+	if data.Layer != comparedData.Layer {
+		return false
+	}
 	return true
 }
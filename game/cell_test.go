@@ -16,6 +16,7 @@ func TestNewBrickData(t *testing.T) {
 	testCases := []NewBrickDataTestCase{
 		{typeOfCell: utils.Cells.Brick, life: 0, expected: &BrickData{Type: utils.Cells.Brick, Life: 1, Level: 1}},
 		{typeOfCell: utils.Cells.Brick, life: 2, expected: &BrickData{Type: utils.Cells.Brick, Life: 2, Level: 2}},
+		{typeOfCell: utils.Cells.Explosive, life: 0, expected: &BrickData{Type: utils.Cells.Explosive, Life: 1, Level: 1}},
 		{typeOfCell: utils.Cells.Empty, life: 0, expected: &BrickData{Type: utils.Cells.Empty, Life: 0, Level: 0}},
 		{typeOfCell: utils.Cells.Empty, life: 2, expected: &BrickData{Type: utils.Cells.Empty, Life: 0, Level: 0}},
 	}
@@ -0,0 +1,150 @@
+package game
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// INFO Source of the game's timers and tickers, tests swap in a ManualClock to fire them without sleeping
+type Clock interface {
+	Now() time.Time
+	AfterFunc(duration time.Duration, callback func()) Timer
+	NewTicker(interval time.Duration) Ticker
+}
+
+type Timer interface {
+	Stop() bool
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+type realClock struct{}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) AfterFunc(duration time.Duration, callback func()) Timer {
+	return time.AfterFunc(duration, callback)
+}
+
+func (realClock) NewTicker(interval time.Duration) Ticker {
+	return realTicker{time.NewTicker(interval)}
+}
+
+func (ticker realTicker) C() <-chan time.Time { return ticker.ticker.C }
+func (ticker realTicker) Stop()               { ticker.ticker.Stop() }
+
+// INFO Only moves when advanced, due timers run in order on the advancing goroutine and tickers drop ticks nobody took like real ones
+type ManualClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	timers  []*manualTimer
+	tickers []*manualTicker
+}
+
+type manualTimer struct {
+	clock    *ManualClock
+	at       time.Time
+	callback func()
+}
+
+type manualTicker struct {
+	clock    *ManualClock
+	interval time.Duration
+	next     time.Time
+	channel  chan time.Time
+}
+
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+func (clock *ManualClock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	return clock.now
+}
+
+func (clock *ManualClock) AfterFunc(duration time.Duration, callback func()) Timer {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	timer := &manualTimer{clock: clock, at: clock.now.Add(duration), callback: callback}
+	clock.timers = append(clock.timers, timer)
+	return timer
+}
+
+func (clock *ManualClock) NewTicker(interval time.Duration) Ticker {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	ticker := &manualTicker{clock: clock, interval: interval, next: clock.now.Add(interval), channel: make(chan time.Time, 1)}
+	clock.tickers = append(clock.tickers, ticker)
+	return ticker
+}
+
+func (clock *ManualClock) Advance(duration time.Duration) {
+	clock.mutex.Lock()
+	clock.now = clock.now.Add(duration)
+	now := clock.now
+	due := []*manualTimer{}
+	pending := []*manualTimer{}
+	for _, timer := range clock.timers {
+		if timer.at.After(now) {
+			pending = append(pending, timer)
+		} else {
+			due = append(due, timer)
+		}
+	}
+	clock.timers = pending
+	for _, ticker := range clock.tickers {
+		if ticker.next.After(now) {
+			continue
+		}
+		for !ticker.next.After(now) {
+			ticker.next = ticker.next.Add(ticker.interval)
+		}
+		select {
+		case ticker.channel <- now:
+		default:
+		}
+	}
+	clock.mutex.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+	for _, timer := range due {
+		timer.callback()
+	}
+}
+
+func (timer *manualTimer) Stop() bool {
+	clock := timer.clock
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	for index, pending := range clock.timers {
+		if pending == timer {
+			clock.timers = append(clock.timers[:index], clock.timers[index+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (ticker *manualTicker) C() <-chan time.Time { return ticker.channel }
+
+func (ticker *manualTicker) Stop() {
+	clock := ticker.clock
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	for index, pending := range clock.tickers {
+		if pending == ticker {
+			clock.tickers = append(clock.tickers[:index], clock.tickers[index+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestManualClock_AfterFunc(t *testing.T) {
+	start := time.Unix(100, 0)
+	clock := NewManualClock(start)
+	fired := []string{}
+	clock.AfterFunc(2*time.Second, func() { fired = append(fired, "second") })
+	clock.AfterFunc(time.Second, func() { fired = append(fired, "first") })
+	stopped := clock.AfterFunc(time.Second, func() { fired = append(fired, "stopped") })
+	if !stopped.Stop() {
+		t.Errorf("Expected a pending timer to stop")
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if len(fired) != 0 {
+		t.Errorf("Expected no timer to fire early, got %v", fired)
+	}
+	clock.Advance(2 * time.Second)
+	if !reflect.DeepEqual(fired, []string{"first", "second"}) {
+		t.Errorf("Expected the due timers to fire in order, got %v", fired)
+	}
+	if !clock.Now().Equal(start.Add(2500 * time.Millisecond)) {
+		t.Errorf("Expected the clock to move by the advanced time, got %v", clock.Now())
+	}
+	if stopped.Stop() {
+		t.Errorf("Expected a stopped timer not to stop again")
+	}
+}
+
+// INFO Loops started in a goroutine may create their ticker after the test first advances, so the clock keeps moving until something arrives
+func advanceUntil[T any](t *testing.T, clock *ManualClock, step time.Duration, channel <-chan T) T {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		clock.Advance(step)
+		select {
+		case value := <-channel:
+			return value
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	t.Fatalf("Expected something after advancing the clock")
+	var zero T
+	return zero
+}
+
+func TestManualClock_Ticker(t *testing.T) {
+	clock := NewManualClock(time.Unix(100, 0))
+	ticker := clock.NewTicker(time.Second)
+
+	clock.Advance(999 * time.Millisecond)
+	select {
+	case <-ticker.C():
+		t.Fatalf("Expected no tick before the interval")
+	default:
+	}
+	//INFO Ticks nobody took are dropped like on a real ticker
+	clock.Advance(3 * time.Second)
+	select {
+	case <-ticker.C():
+	default:
+		t.Fatalf("Expected a tick once the interval passed")
+	}
+	select {
+	case <-ticker.C():
+		t.Fatalf("Expected missed ticks to be dropped")
+	default:
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Errorf("Expected a stopped ticker not to tick")
+	default:
+	}
+}
@@ -0,0 +1,134 @@
+package game
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"golang.org/x/net/websocket"
+)
+
+type Codec string
+
+const (
+	JSONCodec    Codec = "json"
+	MsgpackCodec Codec = "msgpack"
+)
+
+func CodecFromString(codec string) Codec {
+	if Codec(codec) == MsgpackCodec {
+		return MsgpackCodec
+	}
+	return JSONCodec
+}
+
+func (codec Codec) Marshal(v interface{}) ([]byte, error) {
+	if codec == MsgpackCodec {
+		return marshalMsgpack(v)
+	}
+	return json.Marshal(v)
+}
+
+func (codec Codec) Unmarshal(data []byte, v interface{}) error {
+	if codec == MsgpackCodec {
+		return unmarshalMsgpack(data, v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// INFO MessagePack frames are keyed by the json tags so both codecs name every field the same
+func marshalMsgpack(v interface{}) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	encoder := msgpack.NewEncoder(buffer)
+	encoder.SetCustomStructTag("json")
+	encoder.SetSortMapKeys(true)
+	encoder.UseCompactInts(true)
+	err := encoder.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func unmarshalMsgpack(data []byte, v interface{}) error {
+	reader := bytes.NewReader(data)
+	decoder := msgpack.NewDecoder(reader)
+	decoder.SetCustomStructTag("json")
+	err := decoder.Decode(v)
+	if err != nil {
+		return err
+	}
+	//INFO A frame holds exactly one message, anything after it means the frame is corrupt
+	if reader.Len() != 0 {
+		return fmt.Errorf("msgpack: %d trailing bytes", reader.Len())
+	}
+	return nil
+}
+
+func (codec Codec) PayloadType() byte {
+	if codec == MsgpackCodec {
+		return websocket.BinaryFrame
+	}
+	return websocket.TextFrame
+}
+
+// INFO Clients asking for compression get every game state frame gzipped in a binary frame, other messages stay uncompressed
+type Compression string
+
+const (
+	NoCompression   Compression = ""
+	GzipCompression Compression = "gzip"
+)
+
+func CompressionFromString(compression string) Compression {
+	if Compression(compression) == GzipCompression {
+		return GzipCompression
+	}
+	return NoCompression
+}
+
+func (compression Compression) Compress(data []byte) ([]byte, error) {
+	if compression != GzipCompression {
+		return data, nil
+	}
+	buffer := &bytes.Buffer{}
+	writer := gzip.NewWriter(buffer)
+	_, err := writer.Write(data)
+	if err != nil {
+		return nil, err
+	}
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (compression Compression) Decompress(data []byte) ([]byte, error) {
+	if compression != GzipCompression {
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// INFO Decodes a game state frame the way a client would, decompressing it first when compression was requested
+func DecodeGameState(codec Codec, compression Compression, data []byte) (*Game, error) {
+	data, err := compression.Decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	game := &Game{}
+	err = codec.Unmarshal(data, game)
+	if err != nil {
+		return nil, err
+	}
+	return game, nil
+}
@@ -0,0 +1,166 @@
+package game
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/lguibr/pongo/utils"
+	"golang.org/x/net/websocket"
+)
+
+func TestCodecFromString(t *testing.T) {
+	testCases := map[string]Codec{
+		"msgpack": MsgpackCodec,
+		"json":    JSONCodec,
+		"":        JSONCodec,
+		"invalid": JSONCodec,
+	}
+	for input, expected := range testCases {
+		result := CodecFromString(input)
+		if result != expected {
+			t.Errorf("CodecFromString(%s) = %s, want %s", input, result, expected)
+		}
+	}
+}
+
+func TestCodec_PayloadType(t *testing.T) {
+	if JSONCodec.PayloadType() != websocket.TextFrame {
+		t.Errorf("Expected JSON codec to use text frames")
+	}
+	if MsgpackCodec.PayloadType() != websocket.BinaryFrame {
+		t.Errorf("Expected msgpack codec to use binary frames")
+	}
+}
+
+func TestCodec_Msgpack_RoundTrip(t *testing.T) {
+	type payload struct {
+		X       int               `json:"x"`
+		Ratio   float64           `json:"ratio"`
+		Whole   float64           `json:"whole"`
+		Raw     []byte            `json:"raw"`
+		Empty   string            `json:"empty,omitempty"`
+		Skipped chan int          `json:"-"`
+		Tags    map[string]string `json:"tags"`
+		Missing *payload          `json:"missing"`
+	}
+	original := payload{X: -576, Ratio: 1.1, Whole: 3, Raw: []byte("raw"), Tags: map[string]string{"b": "2", "a": "1"}}
+	data, err := MsgpackCodec.Marshal(original)
+	if err != nil {
+		t.Fatalf("Error encoding the payload: %v", err)
+	}
+	decoded := payload{}
+	if err := MsgpackCodec.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Error decoding the payload: %v", err)
+	}
+	if !reflect.DeepEqual(original, decoded) {
+		t.Errorf("Expected the round trip to keep the payload, got %+v", decoded)
+	}
+
+	generic := map[string]interface{}{}
+	if err := MsgpackCodec.Unmarshal(data, &generic); err != nil {
+		t.Fatalf("Error decoding the payload generically: %v", err)
+	}
+	//INFO Fields are named by their json tags, whole floats stay floats and bytes stay binary instead of reading like JSON
+	if _, ok := generic["whole"].(float64); !ok {
+		t.Errorf("Expected a whole float to stay a float, got %T", generic["whole"])
+	}
+	if raw, ok := generic["raw"].([]byte); !ok || !bytes.Equal(raw, []byte("raw")) {
+		t.Errorf("Expected bytes to stay binary, got %T %v", generic["raw"], generic["raw"])
+	}
+	for _, key := range []string{"empty", "Skipped"} {
+		if _, ok := generic[key]; ok {
+			t.Errorf("Expected %q to be left out like in JSON", key)
+		}
+	}
+
+	again, _ := MsgpackCodec.Marshal(original)
+	if !bytes.Equal(data, again) {
+		t.Errorf("Expected map keys to be sorted so equal values encode alike")
+	}
+}
+
+func TestCodec_Msgpack_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", []byte{}},
+		{"truncated string", []byte{0xa5, 'a'}},
+		{"truncated array", []byte{0x92, 0x01}},
+		{"unsupported prefix", []byte{0xc1}},
+		{"trailing bytes", []byte{0x01, 0x02}},
+	}
+	for _, tc := range testCases {
+		var value interface{}
+		if err := MsgpackCodec.Unmarshal(tc.data, &value); err == nil {
+			t.Errorf("Expected an error for %s, got %v", tc.name, value)
+		}
+	}
+}
+
+func TestDecodeGameState(t *testing.T) {
+	game := StartGame()
+	game.Players[0] = NewPlayer(game.Canvas, 0, NewPlayerChannel(), utils.NewRandom(1))
+	game.Paddles[0] = NewPaddle(NewPaddleChannel(), game.Canvas.CanvasSize, 0)
+	game.Balls = []*Ball{NewBall(NewBallChannel(), 0, 0, 0, game.Canvas.CanvasSize, 0, 1, utils.NewRandom(1))}
+
+	fromJSON, err := DecodeGameState(JSONCodec, NoCompression, game.Encode(JSONCodec))
+	if err != nil {
+		t.Fatalf("Error decoding JSON game state: %v", err)
+	}
+	fromMsgpack, err := DecodeGameState(MsgpackCodec, NoCompression, game.Encode(MsgpackCodec))
+	if err != nil {
+		t.Fatalf("Error decoding msgpack game state: %v", err)
+	}
+
+	if !reflect.DeepEqual(fromJSON, fromMsgpack) {
+		t.Errorf("Expected msgpack and JSON frames to decode to identical state")
+	}
+	if fromMsgpack.Balls[0].X != game.Balls[0].X || !fromMsgpack.Canvas.Grid.Compare(game.Canvas.Grid) {
+		t.Errorf("Expected decoded state to match the game")
+	}
+}
+
+func TestCompressionFromString(t *testing.T) {
+	testCases := map[string]Compression{
+		"gzip":    GzipCompression,
+		"":        NoCompression,
+		"deflate": NoCompression,
+	}
+	for input, expected := range testCases {
+		result := CompressionFromString(input)
+		if result != expected {
+			t.Errorf("CompressionFromString(%s) = %s, want %s", input, result, expected)
+		}
+	}
+}
+
+func TestDecodeGameState_Gzip(t *testing.T) {
+	game := StartGame()
+	game.Players[0] = NewPlayer(game.Canvas, 0, NewPlayerChannel(), utils.NewRandom(1))
+	game.Balls = []*Ball{NewBall(NewBallChannel(), 0, 0, 0, game.Canvas.CanvasSize, 0, 1, utils.NewRandom(1))}
+
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		frame := game.Encode(codec)
+		compressed, err := GzipCompression.Compress(frame)
+		if err != nil {
+			t.Fatalf("Error compressing %s game state: %v", codec, err)
+		}
+		if len(compressed) >= len(frame) {
+			t.Errorf("Expected the %s frame to shrink, got %d bytes from %d", codec, len(compressed), len(frame))
+		}
+
+		fromCompressed, err := DecodeGameState(codec, GzipCompression, compressed)
+		if err != nil {
+			t.Fatalf("Error decoding compressed %s game state: %v", codec, err)
+		}
+		fromRaw, err := DecodeGameState(codec, NoCompression, frame)
+		if err != nil {
+			t.Fatalf("Error decoding %s game state: %v", codec, err)
+		}
+		if !reflect.DeepEqual(fromCompressed, fromRaw) {
+			t.Errorf("Expected compressed and raw %s frames to decode to identical state", codec)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"context"
 	"math"
 
 	"github.com/lguibr/pongo/utils"
@@ -16,6 +17,15 @@ type BreakBrickMessage struct {
 	Level       int
 }
 
+type CollideBalls struct{}
+
+// INFO Velocity change from hitting other balls, added by the ball routine to whatever velocity the ball has by then
+type BallImpulse struct {
+	Ball *Ball
+	Vx   int
+	Vy   int
+}
+
 func (ball *Ball) CollidesTopWall() bool {
 	return ball.Y-ball.Radius <= 0
 }
@@ -36,10 +46,17 @@ func (ball *Ball) CollidePaddle(paddle *Paddle) {
 	if paddle == nil {
 		return
 	}
+	//INFO A ball still overlapping the paddle it just hit must not bounce back and forth
+	if ball.paddleHitCooldown > 0 {
+		return
+	}
 
 	collisionDetected := ball.BallInterceptPaddles(paddle)
 	if collisionDetected {
+		ball.paddleHitCooldown = ball.paddleHitCooldownTicks
 		ball.OwnerIndex = paddle.Index
+		ball.wallHitsSinceOwnerChange = 0
+		ball.addCue("paddleHit", ball.X, ball.Y)
 		handlers := [4]func(){
 			ball.HandleCollideRight,
 			ball.HandleCollideTop,
@@ -49,37 +66,146 @@ func (ball *Ball) CollidePaddle(paddle *Paddle) {
 
 		handlerCollision := handlers[paddle.Index]
 		handlerCollision()
+		ball.curveOffPaddle(paddle)
+		//INFO A moving paddle puts spin on the ball
+		if velocity := paddle.AxisVelocity(); velocity != 0 {
+			ball.Spin = ball.spin.Factor * float64(velocity)
+		}
+		//INFO A dashing paddle hits the ball harder
+		if paddle.Dashing {
+			ball.IncreaseVelocity(paddle.dash.Factor)
+			ball.ClampSpeed(ball.maxSpeed)
+		}
+		//INFO A sticky paddle holds the reflected ball until its player moves again
+		if paddle.Sticky && !ball.Stuck {
+			paddle.Catch(ball)
+		}
 	}
 }
 
+// INFO Steers the reflected ball toward the side of the paddle it hit, growing with the square of the distance from the center
+// so center hits stay straight and edge hits leave sharply, never flatter than 60 degrees from the paddle's normal
+func (ball *Ball) curveOffPaddle(paddle *Paddle) {
+	if ball.edgeAngleBoost <= 0 || paddle.Length() <= 0 {
+		return
+	}
+	normal, tangent := float64(ball.Vx), float64(ball.Vy)
+	position, center := float64(ball.Y), float64(paddle.Y)+float64(paddle.Height)/2
+	if paddle.Index%2 != 0 {
+		normal, tangent = tangent, normal
+		position, center = float64(ball.X), float64(paddle.X)+float64(paddle.Width)/2
+	}
+	if normal == 0 {
+		return
+	}
+	offset := math.Max(-1, math.Min(1, (position-center)/(float64(paddle.Length())/2)))
+	speed := math.Hypot(normal, tangent)
+	tangent += ball.edgeAngleBoost * offset * math.Abs(offset) * speed
+	limit := math.Abs(normal) * math.Sqrt(3)
+	tangent = math.Max(-limit, math.Min(limit, tangent))
+	ratio := speed / math.Hypot(normal, tangent)
+	normal, tangent = normal*ratio, tangent*ratio
+	if paddle.Index%2 != 0 {
+		normal, tangent = tangent, normal
+	}
+	ball.Vx, ball.Vy = int(math.Round(normal)), int(math.Round(tangent))
+}
+
 func (ball *Ball) CollideCells(grid Grid, cellSize int) {
+	if ball.CollideCellsAlongPath(grid, cellSize) {
+		return
+	}
+	cells := ball.overlappedSolidCells(grid, cellSize)
+	if len(cells) == 0 {
+		return
+	}
+	row, col := ball.getCenterIndex()
+	ball.collideCell([2]int{row, col}, cells[0], grid)
+}
+
+// INFO Samples the path of a ball that moved further than its radius at most a radius apart, so it can't pass a brick or block
+// between two checks at any speed, the first solid cell it newly overlaps is hit from the last sample before it.
+// Shorter moves, like the default maxBallVelocity against the default ball size, are covered by the overlap check at their end
+func (ball *Ball) CollideCellsAlongPath(grid Grid, cellSize int) bool {
+	if !ball.moved || ball.Phasing || cellSize <= 1 {
+		return false
+	}
+	endX, endY := ball.X, ball.Y
+	deltaX, deltaY := endX-ball.previousX, endY-ball.previousY
+	distance := math.Max(math.Abs(float64(deltaX)), math.Abs(float64(deltaY)))
+	stepLength := utils.MaxInt(1, utils.MinInt(ball.Radius, cellSize/2))
+	steps := int(math.Ceil(distance / float64(stepLength)))
+	if steps <= 1 {
+		return false
+	}
+
+	//INFO Cells the ball already touched where it started were dealt with before it moved
+	ball.X, ball.Y = ball.previousX, ball.previousY
+	touched := map[[2]int]bool{}
+	for _, cell := range ball.overlappedSolidCells(grid, cellSize) {
+		touched[cell] = true
+	}
+	//INFO The end of the path is left to the regular overlap check
+	for step := 1; step < steps; step++ {
+		previousX, previousY := ball.X, ball.Y
+		ball.X, ball.Y = ball.previousX+deltaX*step/steps, ball.previousY+deltaY*step/steps
+		for _, cell := range ball.overlappedSolidCells(grid, cellSize) {
+			if touched[cell] {
+				continue
+			}
+			//INFO Bounce off the cell as seen from the last free sample, the ball's center may already be inside it
+			ball.X, ball.Y = previousX, previousY
+			row, col := ball.getCenterIndex()
+			ball.collideCell([2]int{row, col}, cell, grid)
+			return true
+		}
+	}
+	ball.X, ball.Y = endX, endY
+	return false
+}
+
+// INFO Bricks of the ball's layer, blocks and steel overlapped by the ball, in the order the cells around its center are checked
+func (ball *Ball) overlappedSolidCells(grid Grid, cellSize int) [][2]int {
 	gridSize := len(grid)
 	row, col := ball.getCenterIndex()
 	if row < 0 || row > gridSize-1 || col < 0 || col > gridSize-1 {
-		return
+		return nil
 	}
 
+	cells := [][2]int{}
 	for i := -1; i <= 1; i++ {
 		for j := -1; j <= 1; j++ {
 			surroundingRow, surroundingCol := row+i, col+j
 			if surroundingRow < 0 || surroundingRow > gridSize-1 || surroundingCol < 0 || surroundingCol > gridSize-1 {
 				continue
 			}
-
-			ballInterceptsCell := ball.InterceptsIndex(surroundingRow, surroundingCol, cellSize)
-			if ballInterceptsCell {
-				t := grid[surroundingRow][surroundingCol].Data.Type
-				if t == utils.Cells.Brick {
-					ball.handleCollideBrick([2]int{row, col}, [2]int{surroundingRow, surroundingCol}, grid)
-					return
-				}
-				if t == utils.Cells.Block {
-					ball.handleCollideBlock([2]int{row, col}, [2]int{surroundingRow, surroundingCol})
-					return
-				}
+			if !ball.InterceptsIndex(surroundingRow, surroundingCol, cellSize) {
+				continue
+			}
+			data := grid[surroundingRow][surroundingCol].Data
+			t := data.Type
+			if t.IsBrick() && !ball.HitsLayer(data.Layer) {
+				continue
+			}
+			if t.IsBrick() || t == utils.Cells.Block || t == utils.Cells.Steel {
+				cells = append(cells, [2]int{surroundingRow, surroundingCol})
 			}
 		}
 	}
+	return cells
+}
+
+func (ball *Ball) collideCell(center, cell [2]int, grid Grid) {
+	if grid[cell[0]][cell[1]].Data.Type.IsBrick() {
+		ball.handleCollideBrick(center, cell, grid)
+		return
+	}
+	ball.handleCollideBlock(center, cell)
+}
+
+// INFO A ball of layer zero hits every brick, any other layer only hits bricks of its own layer or of layer zero
+func (ball *Ball) HitsLayer(layer int) bool {
+	return ball.Layer == 0 || layer == 0 || ball.Layer == layer
 }
 
 type WallCollision struct {
@@ -98,30 +224,167 @@ func (ball *Ball) CollideWalls() {
 	for index, wallCollision := range wallsCollision {
 		if wallCollision.Collides() {
 			wallCollision.Handle()
-			ball.Channel <- WallCollisionMessage{Index: index, Ball: ball}
+			ball.addCue("wallHit", ball.X, ball.Y)
+			ball.send(WallCollisionMessage{Index: index, Ball: ball})
 			return
 		}
 	}
 }
 
+// INFO Whether the ball hit the wall inside its goal, the central ratio of the wall's length
+func (ball *Ball) InGoal(wall int, ratio float64) bool {
+	along := ball.Y
+	if wall%2 == 1 {
+		along = ball.X
+	}
+	margin := float64(ball.canvasSize) * (1 - ratio) / 2
+	return ratio > 0 && float64(along) >= margin && float64(along) <= float64(ball.canvasSize)-margin
+}
+
 func (ball *Ball) CollidePaddles(paddles [4]*Paddle) {
+	//INFO Ghost balls pass through paddles, the corner wedges belong to the walls so they still bounce there
 	for _, paddle := range paddles {
-		if paddle == nil {
+		if paddle == nil || ball.Ghost {
 			continue
 		}
 		ball.CollidePaddle(paddle)
 	}
+	if ball.cornerWedges {
+		ball.CollideCornerWedges(paddles)
+	}
+}
+
+// INFO The corner squares between two neighboring paddles are solid, a ball reaching one bounces back out of the corner
+func (ball *Ball) CollideCornerWedges(paddles [4]*Paddle) {
+	size := utils.PaddleWeight
+	far := ball.canvasSize - size
+	//INFO Corners in paddle order, each one sits between paddle index and the next one
+	corners := [4]struct {
+		wedge  Paddle
+		handle [2]func()
+	}{
+		{Paddle{X: far, Y: 0, Width: size, Height: size}, [2]func(){ball.HandleCollideRight, ball.HandleCollideTop}},
+		{Paddle{X: 0, Y: 0, Width: size, Height: size}, [2]func(){ball.HandleCollideTop, ball.HandleCollideLeft}},
+		{Paddle{X: 0, Y: far, Width: size, Height: size}, [2]func(){ball.HandleCollideLeft, ball.HandleCollideBottom}},
+		{Paddle{X: far, Y: far, Width: size, Height: size}, [2]func(){ball.HandleCollideBottom, ball.HandleCollideRight}},
+	}
+	for index, corner := range corners {
+		if paddles[index] == nil || paddles[(index+1)%4] == nil {
+			continue
+		}
+		if ball.BallInterceptPaddles(&corner.wedge) {
+			corner.handle[0]()
+			corner.handle[1]()
+			return
+		}
+	}
+}
+
+func (ball *Ball) CollideBalls(balls []*Ball) {
+	for _, other := range balls {
+		if other == nil || other == ball || other.Id == ball.Id {
+			continue
+		}
+		ball.CollideBall(other)
+	}
+}
+
+// INFO Asks the game once per tick for the ball updates depending on other balls and paddles, a ball routine only sees its own ball safely
+func (game *Game) TickBalls(ctx context.Context) {
+	ticker := game.clock.NewTicker(utils.Period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+		for _, message := range []GameMessage{CollideBalls{}, ApplyMagnets{}} {
+			select {
+			case <-ctx.Done():
+				return
+			case game.channel <- message:
+			}
+		}
+	}
+}
+
+// INFO Resolves every pair on copies of the balls, each ball gets its velocity change through its own channel
+func (game *Game) CollideBalls() {
+	probes := make([]*Ball, len(game.Balls))
+	for index, ball := range game.Balls {
+		probes[index] = &Ball{Id: ball.Id, X: ball.X, Y: ball.Y, Vx: ball.Vx, Vy: ball.Vy, Radius: ball.Radius, Mass: ball.Mass, Stuck: ball.Stuck}
+	}
+	for _, probe := range probes {
+		probe.CollideBalls(probes)
+	}
+	for index, ball := range game.Balls {
+		vx, vy := probes[index].Vx-ball.Vx, probes[index].Vy-ball.Vy
+		if vx != 0 || vy != 0 {
+			ball.send(BallImpulse{ball, vx, vy})
+		}
+	}
+}
+
+func (ball *Ball) CollideBall(other *Ball) bool {
+	if ball.Stuck || other.Stuck {
+		return false
+	}
+
+	normalX := float64(other.X - ball.X)
+	normalY := float64(other.Y - ball.Y)
+	distance := math.Hypot(normalX, normalY)
+	if distance == 0 || distance >= float64(ball.Radius+other.Radius) {
+		return false
+	}
+	normalX /= distance
+	normalY /= distance
+
+	//INFO Only resolve while the balls approach each other so overlapping balls don't flip back and forth
+	approachSpeed := float64(ball.Vx-other.Vx)*normalX + float64(ball.Vy-other.Vy)*normalY
+	if approachSpeed <= 0 {
+		return false
+	}
+
+	mass, otherMass := float64(ball.Mass), float64(other.Mass)
+	if mass <= 0 || otherMass <= 0 {
+		mass, otherMass = 1, 1
+	}
+	impulse := 2 * approachSpeed / (mass + otherMass)
+
+	ball.Vx = int(math.Round(float64(ball.Vx) - impulse*otherMass*normalX))
+	ball.Vy = int(math.Round(float64(ball.Vy) - impulse*otherMass*normalY))
+	other.Vx = int(math.Round(float64(other.Vx) + impulse*mass*normalX))
+	other.Vy = int(math.Round(float64(other.Vy) + impulse*mass*normalY))
+	return true
 }
 
 func (ball *Ball) handleCollideBrick(oldIndices, newIndices [2]int, grid Grid) {
 	ball.handleCollideBlock(oldIndices, newIndices)
+	if !ball.chargeDamage() {
+		return
+	}
 
-	grid[newIndices[0]][newIndices[1]].Data.Life -= ball.Mass
-	if grid[newIndices[0]][newIndices[1]].Data.Life <= 0 {
-		grid[newIndices[0]][newIndices[1]].Data.Type = utils.Cells.Empty
-		level := grid[newIndices[0]][newIndices[1]].Data.Level
-		ball.Channel <- BreakBrickMessage{Level: level, BallPayload: ball}
+	//INFO A chain reaction is reported as a single break so the ball channel buffer never overflows
+	level, destroyed := grid.DamageBrick(newIndices[0], newIndices[1], ball.Mass)
+	cue := brickCue(destroyed, newIndices[0], newIndices[1])
+	ball.addCue(cue.Kind, cue.X, cue.Y)
+	if destroyed {
+		ball.send(BreakBrickMessage{Level: level, BallPayload: ball})
+	}
+}
+
+// INFO A ball at or above minDamageSpeed always damages, a slower one damages on the share of hits matching its share of that speed
+func (ball *Ball) chargeDamage() bool {
+	if ball.minDamageSpeed <= 0 {
+		return true
+	}
+	ball.damageCharge += math.Hypot(float64(ball.Vx), float64(ball.Vy)) / float64(ball.minDamageSpeed)
+	if ball.damageCharge < 1 {
+		return false
 	}
+	ball.damageCharge = math.Mod(ball.damageCharge, 1)
+	return true
 }
 
 func (ball *Ball) handleCollideBlock(oldIndices, newIndices [2]int) {
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"math"
 	"testing"
 
 	"github.com/lguibr/pongo/utils"
@@ -131,6 +132,36 @@ func TestBall_HandleCollideBrick(t *testing.T) {
 	}
 }
 
+func TestBall_HandleCollideBrick_DamageFalloff(t *testing.T) {
+	testCases := []struct {
+		name         string
+		vx, vy       int
+		expectedLife int
+	}{
+		{"fast ball damages every hit", 8, 6, 0},
+		{"ball at the threshold damages every hit", 6, 8, 0},
+		{"half speed ball damages every other hit", 3, 4, 5},
+		{"fifth speed ball damages one hit in five", 0, 2, 8},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			grid := NewGrid(10)
+			grid[1][3] = Cell{X: 1, Y: 3, Data: NewBrickData(utils.Cells.Brick, 10)}
+			ball := &Ball{Channel: NewBallChannel(), Vx: tc.vx, Vy: tc.vy, Mass: 1, minDamageSpeed: 10, Phasing: true}
+			for i := 0; i < 10; i++ {
+				ball.handleCollideBrick([2]int{1, 2}, [2]int{1, 3}, grid)
+				select {
+				case <-ball.Channel:
+				default:
+				}
+			}
+			if grid[1][3].Data.Life != tc.expectedLife {
+				t.Errorf("Expected life %d after 10 hits, got %d", tc.expectedLife, grid[1][3].Data.Life)
+			}
+		})
+	}
+}
+
 func TestBall_CollidePaddles(t *testing.T) {
 	// Create test cases with different scenarios
 	testCases := []struct {
@@ -174,6 +205,16 @@ func TestBall_CollidePaddles(t *testing.T) {
 	}
 }
 
+func TestBall_CollidePaddles_Ghost(t *testing.T) {
+	paddles := [4]*Paddle{{X: 50, Y: 50, Width: 30, Height: 30}}
+	ball := &Ball{X: 75, Y: 75, Vx: 1, Vy: 1, Radius: 10, OwnerIndex: 2, Ghost: true}
+
+	ball.CollidePaddles(paddles)
+	if ball.Vx != 1 || ball.Vy != 1 || ball.OwnerIndex != 2 {
+		t.Errorf("Expected a ghost ball to pass through the paddle, got Vx = %d, Vy = %d and owner %d", ball.Vx, ball.Vy, ball.OwnerIndex)
+	}
+}
+
 func TestBall_CollideWalls(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -263,7 +304,7 @@ func TestBall_CollideWalls(t *testing.T) {
 	}
 }
 func TestBall_Move(t *testing.T) {
-	ball := NewBall(NewBallChannel(), 10, 20, 30, utils.CanvasSize, 1, 1)
+	ball := NewBall(NewBallChannel(), 10, 20, 30, utils.CanvasSize, 1, 1, utils.NewRandom(1))
 	ball.Ax = 1
 	ball.Ay = 2
 	testCases := []struct {
@@ -302,7 +343,7 @@ func TestBall_Move(t *testing.T) {
 }
 
 func TestBall_CollidePaddle(t *testing.T) {
-	ball := NewBall(NewBallChannel(), 10, 20, 30, utils.CanvasSize, 1, 1)
+	ball := NewBall(NewBallChannel(), 10, 20, 30, utils.CanvasSize, 1, 1, utils.NewRandom(1))
 	paddle := NewPaddle(make(chan PaddleMessage), utils.CanvasSize, 0)
 	testCases := []struct {
 		name                                        string
@@ -339,8 +380,34 @@ func TestBall_CollidePaddle(t *testing.T) {
 	}
 }
 
+func TestBall_CollideStickyPaddle(t *testing.T) {
+	ball := NewBall(NewBallChannel(), 20, 30, 10, utils.CanvasSize, 1, 1, utils.NewRandom(1))
+	ball.Vx, ball.Vy = 3, 2
+	paddle := &Paddle{X: 10, Y: 10, Width: 20, Height: 60, Index: 0, Sticky: true}
+
+	ball.CollidePaddle(paddle)
+
+	if !ball.Stuck || ball.Vx != 0 || ball.Vy != 0 {
+		t.Fatalf("Expected ball to be stuck with zero velocity, got stuck = %v, Vx = %d, Vy = %d", ball.Stuck, ball.Vx, ball.Vy)
+	}
+	if paddle.Sticky {
+		t.Errorf("Expected sticky paddle to be consumed by the catch")
+	}
+
+	paddle.Y += 15
+	ball.Move()
+	if ball.X != 20 || ball.Y != 45 {
+		t.Errorf("Expected stuck ball to follow the paddle to (20, 45), got (%d, %d)", ball.X, ball.Y)
+	}
+
+	paddle.LaunchStuckBalls()
+	if ball.Stuck || ball.Vx != -3 || ball.Vy != 2 {
+		t.Errorf("Expected ball to be launched with reflected velocity (-3, 2), got stuck = %v, Vx = %d, Vy = %d", ball.Stuck, ball.Vx, ball.Vy)
+	}
+}
+
 func TestCollideCells(t *testing.T) {
-	ball := NewBall(NewBallChannel(), 10, 10, 30, 12, 1, 1)
+	ball := NewBall(NewBallChannel(), 10, 10, 30, 12, 1, 1, utils.NewRandom(1))
 
 	// Set up test cases
 	testCases := []struct {
@@ -399,3 +466,350 @@ func TestCollideCells(t *testing.T) {
 		})
 	}
 }
+
+func TestBall_CollideBall(t *testing.T) {
+	testCases := []struct {
+		name                             string
+		ball, other                      Ball
+		collides                         bool
+		expectedVx, expectedVy           int
+		expectedOtherVx, expectedOtherVy int
+	}{
+		{
+			name:     "Head on with equal masses swaps velocities",
+			ball:     Ball{Id: 1, X: 100, Y: 100, Vx: 4, Vy: 0, Radius: 10, Mass: 1},
+			other:    Ball{Id: 2, X: 115, Y: 100, Vx: -2, Vy: 0, Radius: 10, Mass: 1},
+			collides: true, expectedVx: -2, expectedVy: 0, expectedOtherVx: 4, expectedOtherVy: 0,
+		},
+		{
+			name:     "Heavier ball keeps moving forward",
+			ball:     Ball{Id: 1, X: 100, Y: 100, Vx: 0, Vy: 6, Radius: 10, Mass: 3},
+			other:    Ball{Id: 2, X: 100, Y: 115, Vx: 0, Vy: 0, Radius: 10, Mass: 1},
+			collides: true, expectedVx: 0, expectedVy: 3, expectedOtherVx: 0, expectedOtherVy: 9,
+		},
+		{
+			name:     "Separating balls are left alone",
+			ball:     Ball{Id: 1, X: 100, Y: 100, Vx: -4, Vy: 0, Radius: 10, Mass: 1},
+			other:    Ball{Id: 2, X: 115, Y: 100, Vx: 2, Vy: 0, Radius: 10, Mass: 1},
+			collides: false, expectedVx: -4, expectedVy: 0, expectedOtherVx: 2, expectedOtherVy: 0,
+		},
+		{
+			name:     "Distant balls don't collide",
+			ball:     Ball{Id: 1, X: 100, Y: 100, Vx: 4, Vy: 0, Radius: 10, Mass: 1},
+			other:    Ball{Id: 2, X: 130, Y: 100, Vx: -2, Vy: 0, Radius: 10, Mass: 1},
+			collides: false, expectedVx: 4, expectedVy: 0, expectedOtherVx: -2, expectedOtherVy: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ball, other := tc.ball, tc.other
+			result := ball.CollideBall(&other)
+			if result != tc.collides {
+				t.Errorf("Expected collision to be %v, got %v", tc.collides, result)
+			}
+			if ball.Vx != tc.expectedVx || ball.Vy != tc.expectedVy {
+				t.Errorf("Expected ball velocity (%d, %d), got (%d, %d)", tc.expectedVx, tc.expectedVy, ball.Vx, ball.Vy)
+			}
+			if other.Vx != tc.expectedOtherVx || other.Vy != tc.expectedOtherVy {
+				t.Errorf("Expected other velocity (%d, %d), got (%d, %d)", tc.expectedOtherVx, tc.expectedOtherVy, other.Vx, other.Vy)
+			}
+		})
+	}
+}
+
+func TestBall_CollideBalls_ResolvesOnce(t *testing.T) {
+	ball := &Ball{Id: 1, X: 100, Y: 100, Vx: 4, Vy: 0, Radius: 10, Mass: 1}
+	other := &Ball{Id: 2, X: 115, Y: 100, Vx: -2, Vy: 0, Radius: 10, Mass: 1}
+	balls := []*Ball{ball, other}
+
+	ball.CollideBalls(balls)
+	other.CollideBalls(balls)
+
+	if ball.Vx != -2 || other.Vx != 4 {
+		t.Errorf("Expected overlapping balls to be resolved once, got Vx %d and %d", ball.Vx, other.Vx)
+	}
+}
+
+func TestGame_CollideBalls_SendsImpulses(t *testing.T) {
+	game := StartGame()
+	ball := &Ball{Id: 1, X: 100, Y: 100, Vx: 4, Vy: 0, Radius: 10, Mass: 1, Channel: NewBallChannel()}
+	other := &Ball{Id: 2, X: 115, Y: 100, Vx: -2, Vy: 0, Radius: 10, Mass: 1, Channel: NewBallChannel()}
+	apart := &Ball{Id: 3, X: 300, Y: 300, Vx: 1, Vy: 1, Radius: 10, Mass: 1, Channel: NewBallChannel()}
+	game.Balls = []*Ball{ball, other, apart}
+
+	game.CollideBalls()
+
+	if ball.Vx != 4 || other.Vx != -2 {
+		t.Errorf("Expected the game routine to leave the velocities to the ball routines, got Vx %d and %d", ball.Vx, other.Vx)
+	}
+	for _, tc := range []struct {
+		ball *Ball
+		vx   int
+	}{{ball, -6}, {other, 6}} {
+		select {
+		case message := <-tc.ball.Channel:
+			impulse, ok := message.(BallImpulse)
+			if !ok || impulse.Ball != tc.ball || impulse.Vx != tc.vx || impulse.Vy != 0 {
+				t.Errorf("Expected ball %d to get an impulse of %d, got %+v", tc.ball.Id, tc.vx, message)
+			}
+			game.handleBallMessage(message)
+		default:
+			t.Errorf("Expected ball %d to get an impulse", tc.ball.Id)
+		}
+	}
+	if ball.Vx != -2 || other.Vx != 4 {
+		t.Errorf("Expected the impulses to swap the velocities, got Vx %d and %d", ball.Vx, other.Vx)
+	}
+	if len(apart.Channel) != 0 {
+		t.Errorf("Expected no impulse for a ball hitting nothing")
+	}
+}
+
+func TestBall_CollidePaddle_Cooldown(t *testing.T) {
+	ball := NewBall(NewBallChannel(), 20, 30, 10, utils.CanvasSize, 1, 1, utils.NewRandom(1))
+	ball.paddleHitCooldownTicks = 2
+	ball.Vx, ball.Vy = 3, 0
+	paddle := &Paddle{X: 10, Y: 10, Width: 20, Height: 60, Index: 2}
+	otherPaddle := &Paddle{X: 10, Y: 10, Width: 20, Height: 60, Index: 0}
+
+	ball.CollidePaddle(paddle)
+	if ball.OwnerIndex != 2 || ball.Vx != 3 {
+		t.Fatalf("Expected the first hit to give the ball to paddle 2, got owner %d and Vx %d", ball.OwnerIndex, ball.Vx)
+	}
+
+	ball.CollidePaddle(otherPaddle)
+	if ball.OwnerIndex != 2 || ball.Vx != 3 {
+		t.Errorf("Expected a hit during the cooldown to be ignored, got owner %d and Vx %d", ball.OwnerIndex, ball.Vx)
+	}
+
+	ball.Vx = 0
+	ball.Move()
+	ball.Move()
+	ball.CollidePaddle(otherPaddle)
+	if ball.OwnerIndex != 0 {
+		t.Errorf("Expected the ball to hit paddles again once the cooldown is over, got owner %d", ball.OwnerIndex)
+	}
+}
+
+func TestBall_CurveOffPaddle(t *testing.T) {
+	paddle := &Paddle{X: 560, Y: 100, Width: 10, Height: 100, Index: 0}
+	angle := func(boost float64, y int) float64 {
+		ball := &Ball{X: 555, Y: y, Vx: -10, Vy: 0, edgeAngleBoost: boost}
+		ball.curveOffPaddle(paddle)
+		speed := math.Hypot(float64(ball.Vx), float64(ball.Vy))
+		if math.Abs(speed-10) > 1 {
+			t.Errorf("Expected the curve to keep the speed, got %f", speed)
+		}
+		return math.Atan2(float64(ball.Vy), -float64(ball.Vx))
+	}
+
+	if angle(1, 150) != 0 {
+		t.Errorf("Expected a center hit to leave straight")
+	}
+	if angle(0, 195) != 0 {
+		t.Errorf("Expected no boost to keep the plain reflection")
+	}
+	half, edge := angle(1, 125), angle(1, 100)
+	if half >= 0 || edge >= half {
+		t.Errorf("Expected hits above the center to curve up, sharper at the edge, got %f and %f", half, edge)
+	}
+	//INFO The boost grows with the square of the offset, so the edge turns the ball much more than twice the half way hit
+	if math.Abs(edge) <= 2*math.Abs(half) {
+		t.Errorf("Expected the edge to curve disproportionately, got %f and %f", half, edge)
+	}
+	if lower := angle(1, 175); math.Abs(lower+half) > 0.1 {
+		t.Errorf("Expected hits below the center to mirror hits above, got %f and %f", lower, half)
+	}
+	if steep := angle(100, 100); math.Abs(steep) > math.Pi/3+0.1 {
+		t.Errorf("Expected the curve to stop at 60 degrees, got %f", steep)
+	}
+}
+
+func TestBall_CollideCellsAlongPath(t *testing.T) {
+	cellSize := utils.CellSize
+	grid := NewGrid(utils.GridSize)
+	grid[5][3] = NewCell(5, 3, 2, utils.Cells.Brick)
+
+	//INFO The ball starts one cell before the brick and would land past it after a single move
+	ball := NewBall(NewBallChannel(), 4*cellSize+cellSize/2, 3*cellSize+cellSize/2, 1, utils.CanvasSize, 0, 1, utils.NewRandom(1))
+	ball.Vx, ball.Vy = 2*cellSize+cellSize/2, 0
+	ball.Move()
+	if ball.InterceptsIndex(5, 3, cellSize) {
+		t.Fatalf("Expected the ball to have jumped over the brick for this test")
+	}
+
+	ball.CollideCells(grid, cellSize)
+
+	if grid[5][3].Data.Life != 1 {
+		t.Errorf("Expected the swept brick to be damaged, got life %d", grid[5][3].Data.Life)
+	}
+	if ball.Vx >= 0 {
+		t.Errorf("Expected the ball to bounce back off the brick, got Vx %d", ball.Vx)
+	}
+	if ball.X >= 5*cellSize {
+		t.Errorf("Expected the ball to be placed before the brick, got x %d", ball.X)
+	}
+}
+
+func TestBall_CollideCellsAlongPath_DashSpeed(t *testing.T) {
+	cellSize := utils.CellSize
+	//INFO A dashing paddle doubles the speed of a ball already at the top speed
+	speed := 2 * utils.DefaultConfig().MaxBallVelocity
+	grid := NewGrid(utils.GridSize)
+	grid[5][5] = NewCell(5, 5, 2, utils.Cells.Brick)
+
+	//INFO The ball cuts diagonally past the brick's corner, overlapping it only halfway through the tick
+	corner := 5 * cellSize
+	ball := NewBall(NewBallChannel(), corner-6-speed/2, corner-6+speed/2, utils.BallSize, utils.CanvasSize, 0, 1, utils.NewRandom(1))
+	ball.Vx, ball.Vy = speed, -speed
+	if ball.InterceptsIndex(5, 5, cellSize) {
+		t.Fatalf("Expected the ball to start clear of the brick for this test")
+	}
+	ball.Move()
+	if ball.InterceptsIndex(5, 5, cellSize) {
+		t.Fatalf("Expected the ball to end clear of the brick for this test")
+	}
+
+	ball.CollideCells(grid, cellSize)
+
+	if grid[5][5].Data.Life != 1 {
+		t.Errorf("Expected the grazed brick to be damaged, got life %d", grid[5][5].Data.Life)
+	}
+	if ball.InterceptsIndex(5, 5, cellSize) {
+		t.Errorf("Expected the ball to be placed clear of the brick, got (%d, %d)", ball.X, ball.Y)
+	}
+}
+
+func TestBall_CollideCellsAlongPath_StartsTouching(t *testing.T) {
+	cellSize := utils.CellSize
+	grid := NewGrid(utils.GridSize)
+	grid[5][3] = NewCell(5, 3, 2, utils.Cells.Brick)
+
+	//INFO A ball sliding along a brick it already touched where it started is left to the regular overlap check
+	ball := NewBall(NewBallChannel(), 5*cellSize-utils.BallSize/2, 3*cellSize+utils.BallSize, utils.BallSize, utils.CanvasSize, 0, 1, utils.NewRandom(1))
+	ball.Vx, ball.Vy = 0, cellSize/2
+	ball.Move()
+
+	if ball.CollideCellsAlongPath(grid, cellSize) {
+		t.Errorf("Expected the brick the ball started on not to be swept again")
+	}
+	if grid[5][3].Data.Life != 2 {
+		t.Errorf("Expected the sweep to leave the brick alone, got life %d", grid[5][3].Data.Life)
+	}
+}
+
+func TestBall_CollideCells_Layers(t *testing.T) {
+	cellSize := utils.CellSize
+	testCases := []struct {
+		name       string
+		ballLayer  int
+		brickLayer int
+		expected   int
+	}{
+		{"same layer", 1, 1, 1},
+		{"other layer", 1, 2, 2},
+		{"layerless ball", 0, 2, 1},
+		{"layerless brick", 1, 0, 1},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			for _, swept := range []bool{false, true} {
+				grid := NewGrid(utils.GridSize)
+				grid[5][3] = NewCell(5, 3, 2, utils.Cells.Brick)
+				grid[5][3].Data.Layer = testCase.brickLayer
+				ball := NewBall(NewBallChannel(), 5*cellSize+cellSize/2, 3*cellSize+cellSize/2, 1, utils.CanvasSize, 0, 1, utils.NewRandom(1))
+				if swept {
+					ball.X = 4*cellSize + cellSize/2
+					ball.Vx, ball.Vy = 2*cellSize+cellSize/2, 0
+					ball.Move()
+				}
+				ball.Layer = testCase.ballLayer
+
+				ball.CollideCells(grid, cellSize)
+
+				if grid[5][3].Data.Life != testCase.expected {
+					t.Errorf("Expected brick life %d when swept is %v, got %d", testCase.expected, swept, grid[5][3].Data.Life)
+				}
+			}
+		})
+	}
+}
+
+func TestBall_CollideDashingPaddle(t *testing.T) {
+	ball := NewBall(NewBallChannel(), 20, 30, 10, utils.CanvasSize, 1, 1, utils.NewRandom(1))
+	ball.Vx, ball.Vy = 3, 2
+	paddle := &Paddle{X: 10, Y: 10, Width: 20, Height: 60, Index: 0, Dashing: true, dash: PaddleDash{Factor: 2}}
+
+	ball.CollidePaddle(paddle)
+
+	if ball.Vx != -6 || ball.Vy != 4 {
+		t.Errorf("Expected the dashing paddle to double the reflected velocity to (-6, 4), got (%d, %d)", ball.Vx, ball.Vy)
+	}
+}
+
+func TestBall_CollideMovingPaddle_Spin(t *testing.T) {
+	ball := NewBall(NewBallChannel(), 20, 30, 10, utils.CanvasSize, 1, 1, utils.NewRandom(1))
+	ball.spin = BallSpin{Factor: 0.01}
+	ball.Vx, ball.Vy = 3, 2
+	paddle := &Paddle{X: 10, Y: 10, Width: 20, Height: 60, Index: 0, Velocity: 5, Direction: "left", CurrentVelocity: -5}
+
+	ball.CollidePaddle(paddle)
+
+	if ball.Spin != -0.05 {
+		t.Errorf("Expected a paddle moving left at speed 5 to give -0.05 spin, got %f", ball.Spin)
+	}
+}
+
+func TestBall_CollideCornerWedges(t *testing.T) {
+	paddles := [4]*Paddle{}
+	for index := range paddles {
+		paddles[index] = NewPaddle(NewPaddleChannel(), utils.CanvasSize, index)
+	}
+	corner := utils.CanvasSize - utils.PaddleWeight/2
+
+	ball := NewBall(NewBallChannel(), corner, utils.PaddleWeight/2, 5, utils.CanvasSize, 2, 1, utils.NewRandom(1))
+	ball.Vx, ball.Vy = 4, -4
+	ball.CollideCornerWedges(paddles)
+	if ball.Vx != -4 || ball.Vy != 4 {
+		t.Errorf("Expected the ball to bounce out of the top right corner, got (%d, %d)", ball.Vx, ball.Vy)
+	}
+
+	ball.Vx, ball.Vy = 4, -4
+	paddles[1] = nil
+	ball.CollideCornerWedges(paddles)
+	if ball.Vx != 4 || ball.Vy != -4 {
+		t.Errorf("Expected no wedge next to an empty slot, got (%d, %d)", ball.Vx, ball.Vy)
+	}
+
+	center := NewBall(NewBallChannel(), utils.CanvasSize/2, utils.CanvasSize/2, 5, utils.CanvasSize, 2, 2, utils.NewRandom(1))
+	center.Vx, center.Vy = 4, -4
+	center.CollideCornerWedges(paddles)
+	if center.Vx != 4 || center.Vy != -4 {
+		t.Errorf("Expected a ball away from the corners to keep its velocity, got (%d, %d)", center.Vx, center.Vy)
+	}
+}
+
+func TestBall_InGoal(t *testing.T) {
+	testCases := []struct {
+		name  string
+		wall  int
+		x, y  int
+		ratio float64
+		want  bool
+	}{
+		{"whole wall", 0, 100, 2, 1, true},
+		{"center of right wall", 0, 100, 50, 0.5, true},
+		{"edge of right wall", 0, 100, 20, 0.5, false},
+		{"goal boundary", 2, 0, 25, 0.5, true},
+		{"center of top wall", 1, 60, 0, 0.5, true},
+		{"edge of bottom wall", 3, 90, 100, 0.5, false},
+		{"no goal", 1, 50, 0, 0, false},
+	}
+	for _, tc := range testCases {
+		ball := &Ball{X: tc.x, Y: tc.y, canvasSize: 100}
+		if got := ball.InGoal(tc.wall, tc.ratio); got != tc.want {
+			t.Errorf("%s: expected InGoal %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+const maxRecentCues = 64
+
+type EventCue struct {
+	Seq  int    `json:"seq"`
+	Kind string `json:"kind"`
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+}
+
+type EventCues struct {
+	MessageType string     `json:"messageType"`
+	Events      []EventCue `json:"events"`
+}
+
+type cueKey struct {
+	kind     string
+	row, col int
+}
+
+// INFO Recent cues shared by every connection, each one sends the cues newer than the last it wrote
+type CueLog struct {
+	mutex  sync.Mutex
+	seq    int
+	recent []EventCue
+	lastAt map[cueKey]time.Time
+}
+
+// INFO Cues of the same kind in the same cell are kept at most once per tick
+func (log *CueLog) Publish(now time.Time, cues ...EventCue) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	if log.lastAt == nil {
+		log.lastAt = map[cueKey]time.Time{}
+	}
+	for _, cue := range cues {
+		key := cueKey{cue.Kind, cue.X / utils.CellSize, cue.Y / utils.CellSize}
+		if last, ok := log.lastAt[key]; ok && now.Sub(last) < utils.Period {
+			continue
+		}
+		log.lastAt[key] = now
+		log.seq++
+		cue.Seq = log.seq
+		log.recent = append(log.recent, cue)
+	}
+	if len(log.recent) > maxRecentCues {
+		log.recent = log.recent[len(log.recent)-maxRecentCues:]
+	}
+}
+
+func (log *CueLog) Since(seq int) []EventCue {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	cues := []EventCue{}
+	for _, cue := range log.recent {
+		if cue.Seq > seq {
+			cues = append(cues, cue)
+		}
+	}
+	return cues
+}
+
+func (log *CueLog) Latest() int {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	return log.seq
+}
+
+func (ball *Ball) addCue(kind string, x, y int) {
+	ball.cues = append(ball.cues, EventCue{Kind: kind, X: x, Y: y})
+}
+
+// INFO Collisions run on the ball's reader routine, which hands the cues they left over to the game
+func (ball *Ball) takeCues() []EventCue {
+	cues := ball.cues
+	ball.cues = nil
+	return cues
+}
+
+func brickCue(destroyed bool, row, col int) EventCue {
+	kind := "brickDamaged"
+	if destroyed {
+		kind = "brickDestroyed"
+	}
+	return EventCue{Kind: kind, X: row*utils.CellSize + utils.CellSize/2, Y: col*utils.CellSize + utils.CellSize/2}
+}
@@ -0,0 +1,67 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestCueLog_Publish(t *testing.T) {
+	log := &CueLog{}
+	now := time.Now()
+
+	log.Publish(now, EventCue{Kind: "wallHit", X: 1, Y: 1}, EventCue{Kind: "wallHit", X: 2, Y: 2})
+	log.Publish(now.Add(utils.Period/2), EventCue{Kind: "paddleHit", X: 1, Y: 1})
+	log.Publish(now.Add(utils.Period), EventCue{Kind: "wallHit", X: 1, Y: 1})
+
+	cues := log.Since(0)
+	if len(cues) != 3 {
+		t.Fatalf("Expected repeated cues in the same cell and tick to be dropped, got %v", cues)
+	}
+	kinds := []string{"wallHit", "paddleHit", "wallHit"}
+	for i, cue := range cues {
+		if cue.Seq != i+1 || cue.Kind != kinds[i] {
+			t.Errorf("Expected cue %d to be %s with seq %d, got %+v", i, kinds[i], i+1, cue)
+		}
+	}
+	if since := log.Since(2); len(since) != 1 || since[0].Seq != 3 {
+		t.Errorf("Expected only the cues after seq 2, got %v", since)
+	}
+	if log.Latest() != 3 {
+		t.Errorf("Expected latest seq 3, got %d", log.Latest())
+	}
+}
+
+func TestCueLog_KeepsRecentCues(t *testing.T) {
+	log := &CueLog{}
+	now := time.Now()
+	for i := 0; i < maxRecentCues*2; i++ {
+		log.Publish(now.Add(time.Duration(i)*utils.Period), EventCue{Kind: "wallHit"})
+	}
+
+	cues := log.Since(0)
+	if len(cues) != maxRecentCues || cues[0].Seq != maxRecentCues+1 {
+		t.Errorf("Expected the %d most recent cues, got %d starting at seq %d", maxRecentCues, len(cues), cues[0].Seq)
+	}
+}
+
+func TestBall_CollisionCues(t *testing.T) {
+	grid := NewGrid(utils.GridSize)
+	grid[2][2].Data = &BrickData{Type: utils.Cells.Brick, Life: 2, Level: 1}
+	ball := &Ball{Mass: 1, Channel: NewBallChannel()}
+
+	ball.handleCollideBrick([2]int{1, 2}, [2]int{2, 2}, grid)
+	ball.handleCollideBrick([2]int{1, 2}, [2]int{2, 2}, grid)
+
+	cues := ball.takeCues()
+	if len(cues) != 2 || cues[0].Kind != "brickDamaged" || cues[1].Kind != "brickDestroyed" {
+		t.Fatalf("Expected a damaged then a destroyed brick cue, got %v", cues)
+	}
+	if cues[0].X != 2*utils.CellSize+utils.CellSize/2 || cues[0].Y != 2*utils.CellSize+utils.CellSize/2 {
+		t.Errorf("Expected the cue at the brick's center, got (%d, %d)", cues[0].X, cues[0].Y)
+	}
+	if len(ball.takeCues()) != 0 {
+		t.Errorf("Expected the cues to be handed over only once")
+	}
+}
@@ -0,0 +1,56 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const maxGameEvents = 256
+
+type GameEvent struct {
+	At     time.Time         `json:"at"`
+	Kind   string            `json:"kind"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// INFO Recent significant events of a game kept for debugging, the oldest are dropped once it's full
+type EventLog struct {
+	mutex  sync.Mutex
+	events []GameEvent
+}
+
+// INFO Fields are key value pairs like the logger's, values are kept as text so any of them encodes
+func (log *EventLog) Record(now time.Time, kind string, keyvals ...interface{}) {
+	event := GameEvent{At: now, Kind: kind}
+	if len(keyvals) > 0 {
+		event.Fields = make(map[string]string, len(keyvals)/2)
+	}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		event.Fields[fmt.Sprint(keyvals[i])] = fmt.Sprint(keyvals[i+1])
+	}
+
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	log.events = append(log.events, event)
+	if len(log.events) > maxGameEvents {
+		log.events = log.events[len(log.events)-maxGameEvents:]
+	}
+}
+
+func (log *EventLog) Events() []GameEvent {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	events := make([]GameEvent, len(log.events))
+	copy(events, log.events)
+	return events
+}
+
+func (game *Game) recordEvent(kind string, keyvals ...interface{}) {
+	game.events.Record(game.clock.Now(), kind, keyvals...)
+}
+
+// INFO Read straight from the log instead of through the game channel, so a wedged game still shows what led up to it
+func (game *Game) Events() []GameEvent {
+	return game.events.Events()
+}
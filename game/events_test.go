@@ -0,0 +1,57 @@
+package game
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEventLog_Record(t *testing.T) {
+	log := EventLog{}
+	now := time.Unix(100, 0)
+	log.Record(now, "playerJoined", "player", 2, "name", "ada")
+	log.Record(now, "panic", "panic", fmt.Errorf("boom"))
+
+	expected := []GameEvent{
+		{At: now, Kind: "playerJoined", Fields: map[string]string{"player": "2", "name": "ada"}},
+		{At: now, Kind: "panic", Fields: map[string]string{"panic": "boom"}},
+	}
+	if events := log.Events(); !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected events %+v, got %+v", expected, events)
+	}
+}
+
+func TestEventLog_Bounded(t *testing.T) {
+	log := EventLog{}
+	for i := 0; i < maxGameEvents+10; i++ {
+		log.Record(time.Unix(int64(i), 0), "powerUp")
+	}
+
+	events := log.Events()
+	if len(events) != maxGameEvents {
+		t.Fatalf("Expected the log to keep %d events, got %d", maxGameEvents, len(events))
+	}
+	if !events[0].At.Equal(time.Unix(10, 0)) {
+		t.Errorf("Expected the oldest events to be dropped, got %v first", events[0].At)
+	}
+}
+
+func TestGame_Events(t *testing.T) {
+	game := StartGame()
+	clock := NewManualClock(time.Unix(100, 0))
+	game.SetClock(clock)
+	game.channel = make(chan GameMessage, 10)
+	game.Players[1] = &Player{Index: 1, Name: "ada"}
+	game.Paddles[1] = &Paddle{Index: 1}
+	game.RemovePlayer(1)
+	game.applyPowerUp(powerUpGhost, &Ball{Id: 3, OwnerIndex: 0})
+
+	expected := []GameEvent{
+		{At: time.Unix(100, 0), Kind: "playerLeft", Fields: map[string]string{"player": "1"}},
+		{At: time.Unix(100, 0), Kind: "powerUp", Fields: map[string]string{"kind": "ghost", "ball": "3", "player": "0"}},
+	}
+	if events := game.Events(); !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected events %+v, got %+v", expected, events)
+	}
+}
@@ -0,0 +1,77 @@
+package game
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+type frameKey struct {
+	codec       Codec
+	compression Compression
+}
+
+type cachedFrame struct {
+	data      []byte
+	encodedAt time.Time
+}
+
+// INFO Every connection writes the same frame, so it is encoded and compressed once per tick and shared by all of them
+type FrameCache struct {
+	mutex  sync.Mutex
+	frames map[frameKey]cachedFrame
+	seq    int64     //INFO Sequence of the latest tick encoded, shared by every codec and compression encoded within it
+	tickAt time.Time //INFO When the latest tick was first encoded
+}
+
+// INFO The game state as broadcast, stamped so clients can order frames and interpolate between them without touching the live game
+type stampedFrame struct {
+	*Game
+	FrameSeq   int64 `json:"frameSeq"`
+	ServerTime int64 `json:"serverTime"`
+}
+
+func (game *Game) EncodeFrame(codec Codec, compression Compression) ([]byte, error) {
+	return game.frames.get(frameKey{codec, compression}, time.Now(), func(seq int64, tickAt time.Time) ([]byte, error) {
+		data := game.encode(codec, stampedFrame{game, seq, tickAt.UnixMilli()})
+		if compression == NoCompression {
+			return data, nil
+		}
+		compressed, err := compression.Compress(data)
+		if err != nil {
+			return nil, err
+		}
+		game.recordCompression(len(data), len(compressed))
+		return compressed, nil
+	})
+}
+
+// INFO Frames older than half a tick are encoded again, so writers waking up on the next tick never get a stale one
+func (cache *FrameCache) get(key frameKey, now time.Time, encode func(seq int64, tickAt time.Time) ([]byte, error)) ([]byte, error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if frame, ok := cache.frames[key]; ok && now.Sub(frame.encodedAt) < utils.Period/2 {
+		return frame.data, nil
+	}
+	//INFO The first frame encoded on a tick starts it, the other codecs and compressions encoded on it share its stamp
+	if cache.seq == 0 || now.Sub(cache.tickAt) >= utils.Period/2 {
+		cache.seq++
+		cache.tickAt = now
+	}
+	data, err := encode(cache.seq, cache.tickAt)
+	if err != nil {
+		return nil, err
+	}
+	if cache.frames == nil {
+		cache.frames = map[frameKey]cachedFrame{}
+	}
+	cache.frames[key] = cachedFrame{data, now}
+	return data, nil
+}
+
+func (cache *FrameCache) latestSeq() int64 {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.seq
+}
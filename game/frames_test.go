@@ -0,0 +1,85 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestFrameCache_Get(t *testing.T) {
+	cache := FrameCache{}
+	encodes := 0
+	encode := func(seq int64, tickAt time.Time) ([]byte, error) {
+		encodes++
+		return []byte{byte(encodes)}, nil
+	}
+	now := time.Now()
+	key := frameKey{JSONCodec, NoCompression}
+
+	first, _ := cache.get(key, now, encode)
+	second, _ := cache.get(key, now.Add(utils.Period/4), encode)
+	if encodes != 1 || first[0] != second[0] {
+		t.Errorf("Expected writers within the same tick to share one encoded frame, got %d encodes", encodes)
+	}
+
+	cache.get(frameKey{MsgpackCodec, NoCompression}, now, encode)
+	if encodes != 2 {
+		t.Errorf("Expected each codec to get its own frame, got %d encodes", encodes)
+	}
+
+	third, _ := cache.get(key, now.Add(utils.Period), encode)
+	if encodes != 3 || third[0] == first[0] {
+		t.Errorf("Expected the frame to be encoded again on the next tick, got %d encodes", encodes)
+	}
+}
+
+func TestGame_EncodeFrame_Gzip(t *testing.T) {
+	game := StartGame()
+	compressed, err := game.EncodeFrame(JSONCodec, GzipCompression)
+	if err != nil {
+		t.Fatalf("Error encoding a compressed frame: %v", err)
+	}
+	if _, err := game.EncodeFrame(JSONCodec, GzipCompression); err != nil {
+		t.Fatalf("Error encoding a compressed frame: %v", err)
+	}
+	state, err := DecodeGameState(JSONCodec, GzipCompression, compressed)
+	if err != nil || state.Canvas == nil {
+		t.Fatalf("Expected the shared frame to decode to the game state, got %v", err)
+	}
+	raw, _ := GzipCompression.Decompress(compressed)
+	if game.rawFrameBytes != int64(len(raw)) {
+		t.Errorf("Expected the frame to be compressed once for every writer, got %d raw bytes recorded", game.rawFrameBytes)
+	}
+}
+
+func TestGame_EncodeFrame_Sequence(t *testing.T) {
+	game := StartGame()
+	previous := &Game{}
+	for i := 0; i < 3; i++ {
+		states := []*Game{}
+		for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+			data, err := game.EncodeFrame(codec, NoCompression)
+			if err != nil {
+				t.Fatalf("Error encoding frame: %v", err)
+			}
+			state, err := DecodeGameState(codec, NoCompression, data)
+			if err != nil {
+				t.Fatalf("Error decoding frame: %v", err)
+			}
+			states = append(states, state)
+		}
+		state := states[0]
+		if states[1].FrameSeq != state.FrameSeq || states[1].ServerTime != state.ServerTime {
+			t.Errorf("Expected every codec to share the tick's stamp, got %d at %d and %d at %d", state.FrameSeq, state.ServerTime, states[1].FrameSeq, states[1].ServerTime)
+		}
+		if state.FrameSeq != previous.FrameSeq+1 || state.ServerTime < previous.ServerTime || state.ServerTime == 0 {
+			t.Errorf("Expected frame %d to follow %d with a later server time, got %d at %d after %d", i+1, previous.FrameSeq, state.FrameSeq, state.ServerTime, previous.ServerTime)
+		}
+		previous = state
+		time.Sleep(utils.Period)
+	}
+	if game.FrameSeq != 0 || game.ServerTime != 0 || game.frames.latestSeq() != 3 {
+		t.Errorf("Expected the stamps to stay in the frame cache, got %d on the game and %d in the cache", game.FrameSeq, game.frames.latestSeq())
+	}
+}
@@ -2,8 +2,11 @@ package game
 
 import (
 	"encoding/json"
-	"fmt"
+	"io"
 	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lguibr/pongo/utils"
@@ -19,6 +22,12 @@ type AddBall struct {
 type RemoveBall struct {
 	Id int
 }
+type ExpirePermanentBall struct {
+	Id int
+}
+type TransferBalls struct {
+	FromIndex int
+}
 
 type IncreaseBallVelocity struct {
 	BallPayload *Ball
@@ -33,52 +42,183 @@ type BallPhasing struct {
 	ExpireIn    int
 }
 
+// INFO Sent by a ball routine when a paddle hit hands the ball over, the game routine tints it for the new owner
+type BallOwnerChanged struct {
+	BallPayload *Ball
+}
+
 type Game struct {
-	Canvas  *Canvas    `json:"canvas"`
-	Players [4]*Player `json:"players"`
-	Paddles [4]*Paddle `json:"paddles"`
-	Balls   []*Ball    `json:"balls"`
-	channel chan GameMessage
+	Canvas               *Canvas          `json:"canvas"`
+	Players              [4]*Player       `json:"players"`
+	Paddles              [4]*Paddle       `json:"paddles"`
+	Balls                []*Ball          `json:"balls"`
+	Lasers               []*Laser         `json:"lasers"`
+	GameOver             *GameOverMessage `json:"gameOver,omitempty"`
+	Wave                 int              `json:"wave"`
+	Waiting              bool             `json:"waiting"`             //INFO Waiting for enough players to start
+	Countdown            int              `json:"countdown,omitempty"` //INFO Seconds left before the game starts
+	ScoreMultiplier      int              `json:"scoreMultiplier"`     //INFO Factor applied to every score change, above 1 during a multiplier window
+	TeamScore            int64            `json:"teamScore"`           //INFO Score shared by every player in co-op mode
+	SuddenDeath          bool             `json:"suddenDeath"`         //INFO Time ran out on a tie, the next score breaking it ends the game
+	FrameSeq             int64            `json:"frameSeq"`            //INFO Increases with every tick broadcast, only set on the frames clients decode
+	ServerTime           int64            `json:"serverTime"`          //INFO Unix milliseconds when the tick was first encoded, only set on the frames clients decode
+	channel              chan GameMessage
+	config               utils.Config
+	random               *rand.Rand
+	gameTimer            Timer
+	leaderboard          *Leaderboard
+	queue                WaitingQueue
+	nextBallId           int64
+	tickCount            int64
+	tickDuration         int64
+	tickAverage          int64
+	degraded             int32
+	rawFrameBytes        int64
+	compressedFrameBytes int64
+	droppedFrames        int64
+	cues                 CueLog
+	events               EventLog
+	frames               FrameCache
+	pendingBalls         []*Ball
+	startedAt            time.Time
+	mapGrid              Grid
+	clock                Clock
+	spectators           int64
+	mutex                sync.Mutex //INFO Guards the state shared by the game, ball, paddle, player and writer routines
+	//INFO Players the game routine handed a slot to and not added yet, their slot and client are taken meanwhile
+	joining [4]*EnqueuePlayer
 }
 
 func StartGame() *Game {
-	rand.Seed(time.Now().UnixNano())
+	return StartGameWithConfig(utils.DefaultConfig())
+}
 
+func StartGameWithConfig(config utils.Config) *Game {
 	canvas := NewCanvas(0, 0)
-	canvas.Grid.Fill(0, 0, 0, 0)
 	players := [4]*Player{}
 
 	game := Game{
-		Canvas:  canvas,
-		Players: players,
-		channel: make(chan GameMessage),
+		Canvas:          canvas,
+		Players:         players,
+		channel:         make(chan GameMessage),
+		config:          config,
+		random:          utils.NewRandom(config.RandomSeed),
+		Waiting:         hasLobby(config),
+		ScoreMultiplier: 1,
+		clock:           realClock{},
 	}
+	game.loadMap()
+	game.ResetGrid()
 
 	return &game
 }
 
+func (game *Game) ResetGrid() {
+	game.Wave = 1
+	if game.mapGrid != nil {
+		game.Canvas.Grid = game.mapGrid.Copy()
+		game.Canvas.MaxLife = game.Canvas.Grid.MaxLife()
+		return
+	}
+	game.Canvas.Grid.Fill(game.random, 0, 0, 0, 0)
+	game.Canvas.Grid.PlaceCenterObstacle(game.config.CenterObstacle)
+	game.Canvas.Grid.MarkSteelBricks(game.random, game.config.SteelBrickRatio)
+	game.Canvas.Grid.MarkExplosiveBricks(game.random, game.config.ExplosiveBrickChance)
+	game.Canvas.MaxLife = game.Canvas.Grid.MaxLife()
+}
+
+// INFO Replaces the clock behind the game's timers and tickers, meant to be called before the game starts
+func (game *Game) SetClock(clock Clock) {
+	game.clock = clock
+}
+
+func (game *Game) SetLeaderboard(leaderboard *Leaderboard) {
+	game.leaderboard = leaderboard
+}
+
 func (game *Game) ToJson() []byte {
+	game.mutex.Lock()
+	defer game.mutex.Unlock()
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Println("Recovered from panic:", r)
+			utils.LogError("Recovered from panic", "panic", r)
+			game.recordEvent("panic", "panic", r)
 		}
 	}()
 
 	gameBytes, err := json.Marshal(game)
 	if err != nil {
-		fmt.Println("Error Marshaling the game state", err)
+		utils.LogError("Error marshaling the game state", "err", err)
 		return []byte{}
 	}
 	return gameBytes
 }
 
+func (game *Game) Encode(codec Codec) []byte {
+	if codec == JSONCodec {
+		return game.ToJson()
+	}
+	return game.encode(codec, game)
+}
+
+// INFO Marshals the game or a frame embedding it, holding the state lock so routines can't change it halfway
+func (game *Game) encode(codec Codec, state interface{}) []byte {
+	game.mutex.Lock()
+	defer game.mutex.Unlock()
+	defer func() {
+		if r := recover(); r != nil {
+			utils.LogError("Recovered from panic", "panic", r)
+			game.recordEvent("panic", "panic", r)
+		}
+	}()
+
+	gameBytes, err := codec.Marshal(state)
+	if err != nil {
+		utils.LogError("Error encoding the game state", "codec", codec, "err", err)
+		return []byte{}
+	}
+	return gameBytes
+}
+
+// INFO Returns -1 when every active slot is taken
 func (game *Game) GetNextIndex() int {
 	for i, player := range game.Players {
-		if player == nil {
+		if player == nil && game.joining[i] == nil && game.IsActiveSlot(i) {
 			return i
 		}
 	}
-	return 0
+	return -1
+}
+
+func (game *Game) MaxPlayers() int {
+	maxPlayers := 0
+	for i := range game.Players {
+		if game.IsActiveSlot(i) {
+			maxPlayers++
+		}
+	}
+	return maxPlayers
+}
+
+// INFO In 2-player mode only the right and left walls get paddles, the others just reflect the balls
+func (game *Game) IsActiveSlot(index int) bool {
+	return game.config.PlayerCount != 2 || index%2 == 0
+}
+
+// INFO Whether a player in the game, connected, within its grace period or handed a slot, came from this client
+func (game *Game) HasClient(clientId string) bool {
+	if clientId == "" {
+		return false
+	}
+	for index, player := range game.Players {
+		if player != nil && player.clientId == clientId {
+			return true
+		}
+		if joining := game.joining[index]; joining != nil && joining.ClientId == clientId {
+			return true
+		}
+	}
+	return false
 }
 
 func (game *Game) HasPlayer() bool {
@@ -90,57 +230,303 @@ func (game *Game) HasPlayer() bool {
 	return false
 }
 
-func (game *Game) WriteGameState(ws *websocket.Conn) {
+// INFO Every write is one frame, websockets get a frame per message and other writers like server sent events an event per message
+func (game *Game) WriteGameState(ws io.Writer, codec Codec, compression Compression) {
+	game.writeGameState(ws, codec, compression, nil)
+}
+
+// INFO A signal on resync makes the next frames carry the full state and scores, even if nothing changed since the last ones
+func (game *Game) writeGameState(ws io.Writer, codec Codec, compression Compression, resync <-chan struct{}) {
 	frame := 0
+	var lastBroadcast *GameSnapshot
+	var lastScoreboard *Scoreboard
+	lastScoreboardAt := time.Now()
+	lastCue := game.cues.Latest()
+	lastFrameAt := time.Now()
 	for {
-		time.Sleep(utils.Period)
-		gameState := game.ToJson()
-
-		_, err := ws.Write([]byte(gameState))
+		period := game.broadcastPeriod()
+		time.Sleep(period)
+		//INFO Frames are built from the latest snapshot, so the ones a slow client missed are coalesced into the next and only counted
+		now := time.Now()
+		if missed := int64(now.Sub(lastFrameAt)/period) - 1; missed > 0 {
+			atomic.AddInt64(&game.droppedFrames, missed)
+		}
+		lastFrameAt = now
+		select {
+		case <-resync:
+			lastBroadcast, lastScoreboard, lastScoreboardAt = nil, nil, time.Time{}
+		default:
+		}
+		//INFO A client whose buffers are full can't hold this writer forever
+		if conn, ok := ws.(interface{ SetWriteDeadline(time.Time) error }); ok && game.config.WriteTimeout > 0 {
+			if err := conn.SetWriteDeadline(time.Now().Add(game.config.WriteTimeout)); err != nil {
+				utils.LogError("Error setting write deadline", "err", err)
+				return
+			}
+		}
+		game.mutex.Lock()
+		snapshot := game.Snapshot()
+		game.mutex.Unlock()
+		//INFO Cues go out as soon as they happen, even on frames skipped for not changing enough
+		if cues := game.cues.Since(lastCue); len(cues) > 0 {
+			data, err := codec.Marshal(EventCues{MessageType: "events", Events: cues})
+			if err == nil {
+				_, err = ws.Write(data)
+			}
+			if err != nil {
+				game.writeFailed(ws, err, "Error writing event cues to client")
+				return
+			}
+			lastCue = cues[len(cues)-1].Seq
+		}
+		//INFO Scores go out together at a lower rate than the physics frames
+		if time.Since(lastScoreboardAt) >= game.config.ScoreboardInterval {
+			scoreboard := snapshot.Scoreboard()
+			if lastScoreboard == nil || scoreboard != *lastScoreboard {
+				data, err := codec.Marshal(scoreboard)
+				if err == nil {
+					_, err = ws.Write(data)
+				}
+				if err != nil {
+					game.writeFailed(ws, err, "Error writing scoreboard to client")
+					return
+				}
+				lastScoreboard = &scoreboard
+			}
+			lastScoreboardAt = time.Now()
+		}
+		//INFO Skip frames where nothing changed since the last one written to this client
+		if lastBroadcast != nil && !snapshot.Differs(*lastBroadcast, game.config.BroadcastPositionEpsilon) {
+			continue
+		}
+		gameState, err := game.EncodeFrame(codec, compression)
+		if err == nil {
+			if compression == NoCompression {
+				_, err = ws.Write(gameState)
+			} else {
+				err = writeCompressed(ws, gameState)
+			}
+		}
 
 		if err != nil {
-			fmt.Println("Error writing to client: ", err)
+			game.writeFailed(ws, err, "Error writing to client")
 			return
 		}
+		lastBroadcast = &snapshot
 		frame++
 	}
 }
 
+// INFO A timed out write leaves the connection's buffered writer failing every write after it, so the slow client is closed right away and its reader disconnects it
+func (game *Game) writeFailed(ws io.Writer, err error, message string) {
+	if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		utils.LogError(message, "err", err)
+		return
+	}
+	utils.LogWarn("Evicting slow client", "err", err)
+	if closer, ok := ws.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// INFO Compressed frames are always binary, whatever payload type the codec uses for the rest of the messages
+func writeCompressed(ws io.Writer, compressed []byte) error {
+	if conn, ok := ws.(*websocket.Conn); ok {
+		return websocket.Message.Send(conn, compressed)
+	}
+	_, err := ws.Write(compressed)
+	return err
+}
+
+func (game *Game) Reconnect(ws *websocket.Conn, reconnectToken string, codec Codec, compression Compression, close func()) bool {
+	game.mutex.Lock()
+	var player *Player
+	var paddle *Paddle
+	for index, candidate := range game.Players {
+		if candidate != nil && !candidate.Connected && candidate.reconnectToken == reconnectToken {
+			player, paddle = candidate, game.Paddles[index]
+			break
+		}
+	}
+	game.mutex.Unlock()
+	if player == nil || paddle == nil {
+		return false
+	}
+	player.Reconnect(close)
+	ws.PayloadType = codec.PayloadType()
+	err := player.WriteAssignment(ws, codec)
+	if err != nil {
+		utils.LogError("Error writing player assignment to client", "err", err)
+	}
+	clockSync := NewClockSync(game.clock)
+	go player.ReadInput(ws, paddle.channel, clockSync, game.config.PongTimeout, game.config.MaxInputsPerSecond)
+	go player.Heartbeat(ws, codec, game.clock, game.config.PingInterval)
+	go game.SyncServerTime(ws, codec, game.config.ServerTimeInterval, clockSync)
+	go game.writeGameState(ws, codec, compression, player.resync)
+	return true
+}
+
 func (game *Game) RemovePlayer(playerIndex int) {
+	game.recordEvent("playerLeft", "player", playerIndex)
 	game.Players[playerIndex] = nil
 	game.Paddles[playerIndex] = nil
+	if !game.HasPlayer() {
+		game.StopGameTimer()
+		game.startedAt = time.Time{}
+		//INFO The next players to join wait in the lobby again
+		game.Waiting = hasLobby(game.config)
+	}
+	//INFO Dropped with the slot, so a player taking it during the countdown doesn't get the ball of the one who left
+	pending := game.pendingBalls[:0]
+	for _, ball := range game.pendingBalls {
+		if ball.OwnerIndex != playerIndex {
+			pending = append(pending, ball)
+		}
+	}
+	game.pendingBalls = pending
+	//INFO Called by the player routine holding the state lock, so the follow up messages are posted
+	if game.config.TransferBallsOnLeave {
+		game.post(TransferBalls{playerIndex}, SlotFreed{})
+		return
+	}
+	messages := []GameMessage{}
 	for _, ball := range game.Balls {
 		if ball.OwnerIndex != playerIndex {
 			continue
 		}
 
-		game.channel <- RemoveBall{Id: ball.Id}
+		messages = append(messages, RemoveBall{Id: ball.Id})
 	}
+	game.post(append(messages, SlotFreed{})...)
 }
 
 func (g *Game) AddPlayer(index int, player *Player, playerPaddle *Paddle) {
+	g.recordEvent("playerJoined", "player", index, "name", player.Name)
 	g.Players[index] = player
 	g.Paddles[index] = playerPaddle
+	g.joining[index] = nil
+	playerPaddle.acceleration = g.config.PaddleAcceleration
+	if g.config.PaddleInputBuffer > 0 {
+		playerPaddle.inputs = make(chan paddleInput, g.config.PaddleInputBuffer)
+	}
+	playerPaddle.maxLagCompensation = g.config.MaxInputLagCompensation
+	playerPaddle.dash = PaddleDash{
+		Factor:   g.config.PaddleDashFactor,
+		Duration: g.config.PaddleDashDuration,
+		Cooldown: g.config.PaddleDashCooldown,
+	}
 	go playerPaddle.Engine()
+}
 
+// INFO Balls are spawned from several routines, the counter keeps their ids unique for the game's lifetime
+func (game *Game) NextBallId() int {
+	return int(atomic.AddInt64(&game.nextBallId, 1))
 }
 
 func (game *Game) AddBall(ball *Ball, expire int) {
+	ball.gravity = game.config.Gravity
+	ball.maxSpeed = game.config.MaxBallVelocity
+	ball.speedCap = int(float64(game.config.MaxBallVelocity) * game.config.AbsoluteSpeedCapRatio)
+	ball.substeps = game.config.PhysicsSubsteps
+	ball.substepDone = make(chan struct{}, 1)
+	ball.paddleHitCooldownTicks = game.config.PaddleHitCooldownTicks
+	ball.minDamageSpeed = game.config.MinDamageSpeed
+	ball.edgeAngleBoost = game.config.PaddleEdgeAngleBoost
+	ball.maxRadius = game.config.MaxBallRadius
+	//INFO A new ball spawned next to a paddle ignores paddles for a while, it still bounces off walls and bricks
+	ball.paddleHitCooldown = int(game.config.BallSpawnGracePeriod / utils.Period)
+	ball.spin = BallSpin{Factor: game.config.BallSpinFactor, Decay: game.config.BallSpinDecay}
+	ball.permanent = expire == 0
+	ball.cornerWedges = game.config.CornerWedges
+	ball.state = &game.mutex
+	game.tintBall(ball)
 	game.Balls = append(game.Balls, ball)
 	go game.ReadBallChannel(ball.OwnerIndex, ball)
 	go ball.Engine()
 
-	go func() {
-		if expire == 0 {
-			return
+	if ball.permanent {
+		game.schedulePermanentBallExpiry(ball.Id)
+	}
+
+	if expire != 0 {
+		//INFO Removing a ball that is already gone does nothing, so the timer doesn't need to look for it
+		game.clock.AfterFunc(time.Duration(expire)*time.Second, func() {
+			game.channel <- RemoveBall{Id: ball.Id}
+		})
+	}
+}
+
+// INFO Launches a ball spawned next to its owner's paddle toward the canvas center, within the configured jitter
+func (game *Game) aimAtCenter(ball *Ball) {
+	center := game.Canvas.CanvasSize / 2
+	ball.AimAt(center, center, game.config.InitialBallAimJitter, game.random)
+}
+
+// INFO Resolves the ball color from its owner so clients don't have to, ownerless balls are gray
+func (game *Game) tintBall(ball *Ball) {
+	ball.Color = NeutralBallColor
+	if ball.OwnerIndex < 0 || ball.OwnerIndex >= len(game.Players) {
+		return
+	}
+	if player := game.Players[ball.OwnerIndex]; player != nil {
+		ball.Color = player.Color
+	}
+}
+
+// INFO Hands every ball of a player who left to the connected player owning the fewest, balls are removed when nobody is left to take them
+func (game *Game) TransferBalls(fromIndex int) {
+	removed := []int{}
+	for _, ball := range game.Balls {
+		if ball.OwnerIndex != fromIndex {
+			continue
 		}
-		time.Sleep(time.Duration(expire) * time.Second)
-		for _, b := range game.Balls {
-			if b.Id == ball.Id {
-				game.channel <- RemoveBall{Id: ball.Id}
+		recipient, fewest := NoOwner, 0
+		for index, player := range game.Players {
+			if player == nil || !player.Connected || index == fromIndex {
+				continue
+			}
+			if owned := game.OwnedBalls(index); recipient == NoOwner || owned < fewest {
+				recipient, fewest = index, owned
 			}
 		}
-	}()
+		if recipient == NoOwner {
+			removed = append(removed, ball.Id)
+			continue
+		}
+		ball.OwnerIndex = recipient
+		ball.wallHitsSinceOwnerChange = 0
+		game.tintBall(ball)
+	}
+	for _, id := range removed {
+		game.RemoveBall(id)
+	}
+}
+
+func (game *Game) schedulePermanentBallExpiry(id int) {
+	if game.config.PermanentBallMaxLifetime <= 0 {
+		return
+	}
+	game.clock.AfterFunc(game.config.PermanentBallMaxLifetime, func() {
+		game.channel <- ExpirePermanentBall{Id: id}
+	})
+}
+
+// INFO A permanent ball outlives its lifetime only while a connected player owns it, otherwise it is left behind and removed
+func (game *Game) ExpirePermanentBall(id int) {
+	for _, ball := range game.Balls {
+		if ball.Id != id {
+			continue
+		}
+		if ball.OwnerIndex != NoOwner {
+			player := game.Players[ball.OwnerIndex]
+			if player != nil && player.Connected {
+				game.schedulePermanentBallExpiry(id)
+				return
+			}
+		}
+		game.RemoveBall(id)
+		return
+	}
 }
 
 func (game *Game) RemoveBall(id int) {
@@ -148,7 +534,7 @@ func (game *Game) RemoveBall(id int) {
 		if ball.Id != id {
 			continue
 		}
-		ball.open = false
+		ball.stop()
 		if index < len(game.Balls)-1 {
 			game.Balls = append(game.Balls[:index], game.Balls[index+1:]...)
 		} else {
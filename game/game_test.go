@@ -2,7 +2,11 @@ package game
 
 import (
 	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/lguibr/pongo/utils"
 )
@@ -27,6 +31,33 @@ func TestGame_HasPlayer(t *testing.T) {
 	}
 }
 
+func TestGame_HasClient(t *testing.T) {
+	testCases := []struct {
+		players   [4]*Player
+		clientId  string
+		hasClient bool
+	}{
+		{[4]*Player{{Id: "player1", clientId: "abc"}, nil, nil}, "abc", true},
+		{[4]*Player{{Id: "player1", clientId: "abc"}, nil, nil}, "xyz", false},
+		{[4]*Player{{Id: "player1"}, nil, nil}, "", false},
+		{[4]*Player{nil, nil, nil}, "abc", false},
+	}
+
+	for _, tc := range testCases {
+		game := Game{Players: tc.players}
+		result := game.HasClient(tc.clientId)
+		if result != tc.hasClient {
+			t.Errorf("Game.HasClient(%q) = %v, want %v", tc.clientId, result, tc.hasClient)
+		}
+	}
+
+	game := Game{}
+	game.joining[0] = &EnqueuePlayer{ClientId: "abc"}
+	if !game.HasClient("abc") || game.GetNextIndex() != 1 {
+		t.Errorf("Expected a slot handed to a joining client to hold it and its slot, got next index %d", game.GetNextIndex())
+	}
+}
+
 func TestGame_GetNextIndex(t *testing.T) {
 	testCases := []struct {
 		players   [4]*Player
@@ -36,7 +67,7 @@ func TestGame_GetNextIndex(t *testing.T) {
 		{[4]*Player{{Id: "player1"}, nil, nil}, 1},
 		{[4]*Player{{Id: "player1"}, {Id: "player2"}, nil}, 2},
 		{[4]*Player{{Id: "player1"}, {Id: "player2"}, {Id: "player3"}}, 3},
-		{[4]*Player{{Id: "player1"}, {Id: "player2"}, {Id: "player3"}, {Id: "player4"}}, 0},
+		{[4]*Player{{Id: "player1"}, {Id: "player2"}, {Id: "player3"}, {Id: "player4"}}, -1},
 	}
 
 	for _, tc := range testCases {
@@ -48,6 +79,38 @@ func TestGame_GetNextIndex(t *testing.T) {
 	}
 }
 
+func TestGame_MaxPlayers(t *testing.T) {
+	game := Game{}
+	if game.MaxPlayers() != 4 {
+		t.Errorf("Expected 4 player slots by default, got %d", game.MaxPlayers())
+	}
+	game.config.PlayerCount = 2
+	if game.MaxPlayers() != 2 {
+		t.Errorf("Expected 2 player slots in 2-player mode, got %d", game.MaxPlayers())
+	}
+}
+
+func TestGame_GetNextIndex_TwoPlayers(t *testing.T) {
+	testCases := []struct {
+		players   [4]*Player
+		nextIndex int
+	}{
+		{[4]*Player{nil, nil, nil, nil}, 0},
+		{[4]*Player{{Id: "player1"}, nil, nil, nil}, 2},
+		{[4]*Player{nil, nil, {Id: "player3"}, nil}, 0},
+		{[4]*Player{{Id: "player1"}, nil, {Id: "player3"}, nil}, -1},
+	}
+
+	for _, tc := range testCases {
+		game := Game{Players: tc.players}
+		game.config.PlayerCount = 2
+		result := game.GetNextIndex()
+		if result != tc.nextIndex {
+			t.Errorf("Game.GetNextIndex() = %v, want %v", result, tc.nextIndex)
+		}
+	}
+}
+
 func TestStartGame(t *testing.T) {
 	game := StartGame()
 	if game.Canvas == nil {
@@ -66,6 +129,27 @@ func TestStartGame(t *testing.T) {
 	}
 }
 
+func TestGame_Reconnect(t *testing.T) {
+	player := &Player{Index: 0, Connected: false, reconnectToken: "token"}
+	connected := &Player{Index: 1, Connected: true, reconnectToken: "connected"}
+	game := Game{Players: [4]*Player{player, connected, nil, nil}}
+
+	testCases := []struct {
+		token       string
+		reconnected bool
+	}{
+		{"unknown", false},
+		{"connected", false},
+		{"token", false}, //INFO Without a paddle the slot can't be rebound
+	}
+	for _, tc := range testCases {
+		result := game.Reconnect(nil, tc.token, JSONCodec, NoCompression, func() {})
+		if result != tc.reconnected {
+			t.Errorf("Game.Reconnect(%s) = %v, want %v", tc.token, result, tc.reconnected)
+		}
+	}
+}
+
 func TestGame_ToJson(t *testing.T) {
 	game := &Game{
 		Balls: []*Ball{
@@ -100,3 +184,277 @@ func TestGame_ToJson(t *testing.T) {
 		t.Errorf("Expected %s, got %s", string(gameBytes), string(result))
 	}
 }
+
+func TestStartGameWithConfig_RandomSeed(t *testing.T) {
+	config := utils.DefaultConfig()
+	config.RandomSeed = 7
+	first, second := StartGameWithConfig(config), StartGameWithConfig(config)
+
+	if !first.Canvas.Grid.Compare(second.Canvas.Grid) {
+		t.Errorf("Expected games with the same seed to generate the same grid")
+	}
+
+	firstBall := NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 1, first.NextBallId(), first.random)
+	secondBall := NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 1, second.NextBallId(), second.random)
+	if firstBall.Id != secondBall.Id || firstBall.Vx != secondBall.Vx || firstBall.Vy != secondBall.Vy {
+		t.Errorf("Expected games with the same seed to spawn the same balls, got %+v and %+v", firstBall, secondBall)
+	}
+
+	powerUps := func(game *Game, ball *Ball) []string {
+		game.channel = make(chan GameMessage, 100)
+		for i := 0; i < 20; i++ {
+			game.triggerRandomPowerUp(ball)
+		}
+		close(game.channel)
+		sequence := []string{}
+		for message := range game.channel {
+			sequence = append(sequence, fmt.Sprintf("%T", message))
+		}
+		return sequence
+	}
+	if !reflect.DeepEqual(powerUps(first, firstBall), powerUps(second, secondBall)) {
+		t.Errorf("Expected games with the same seed to roll the same power-ups")
+	}
+}
+
+func TestGame_NextBallId(t *testing.T) {
+	game := StartGame()
+	ids := make(chan int, 1000)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ids <- game.NextBallId()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := map[int]bool{}
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("Expected unique ball ids, got %d twice", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != 1000 {
+		t.Errorf("Expected 1000 ball ids, got %d", len(seen))
+	}
+}
+
+func TestGame_AddBall_SpawnGracePeriod(t *testing.T) {
+	game := StartGame()
+	game.config.BallSpawnGracePeriod = time.Second
+	ball := NewBall(NewBallChannel(), 20, 30, 10, utils.CanvasSize, 1, 1, utils.NewRandom(1))
+	ball.Vx, ball.Vy = 0, 0
+
+	game.mutex.Lock()
+	game.AddBall(ball, 0)
+	game.RemoveBall(ball.Id)
+	game.mutex.Unlock()
+
+	paddle := &Paddle{X: 10, Y: 10, Width: 20, Height: 60, Index: 2}
+	ball.CollidePaddle(paddle)
+	if ball.OwnerIndex != 1 {
+		t.Errorf("Expected a freshly spawned ball to ignore paddles, got owner %d", ball.OwnerIndex)
+	}
+}
+
+func TestGame_TintBall(t *testing.T) {
+	game := StartGame()
+	game.Players[1] = &Player{Index: 1, Color: [3]int{10, 20, 30}}
+	ball := &Ball{OwnerIndex: 1}
+
+	game.tintBall(ball)
+	if ball.Color != game.Players[1].Color {
+		t.Errorf("Expected the owner's color %v, got %v", game.Players[1].Color, ball.Color)
+	}
+
+	for _, owner := range []int{NoOwner, 2} {
+		ball.OwnerIndex = owner
+		game.tintBall(ball)
+		if ball.Color != NeutralBallColor {
+			t.Errorf("Expected a ball owned by %d to be neutral, got %v", owner, ball.Color)
+		}
+	}
+}
+
+func TestGame_BallOwnerChanged(t *testing.T) {
+	game := StartGame()
+	game.channel = make(chan GameMessage, 1)
+	game.Players[2] = &Player{Index: 2, Color: [3]int{10, 20, 30}}
+	game.Paddles[2] = &Paddle{X: 10, Y: 10, Width: 20, Height: 60, Index: 2}
+	ball := NewBall(NewBallChannel(), 20, 30, 10, utils.CanvasSize, 1, 1, utils.NewRandom(1))
+	ball.Vx, ball.Vy = 3, 0
+	color := ball.Color
+
+	game.handleBallMessage(BallPositionMessage{ball}).send()
+	if ball.OwnerIndex != 2 || ball.Color != color {
+		t.Fatalf("Expected the paddle to take the ball without the ball routine tinting it, got owner %d and color %v", ball.OwnerIndex, ball.Color)
+	}
+	message := <-game.channel
+	if changed, ok := message.(BallOwnerChanged); !ok || changed.BallPayload != ball {
+		t.Fatalf("Expected the game routine to be told about the new owner, got %#v", message)
+	}
+	game.handleGameMessage(message)
+	if ball.Color != game.Players[2].Color {
+		t.Errorf("Expected the new owner's color %v, got %v", game.Players[2].Color, ball.Color)
+	}
+}
+
+func TestGame_TransferBalls(t *testing.T) {
+	game := StartGame()
+	game.Players[0] = &Player{Index: 0, Connected: true}
+	game.Players[1] = &Player{Index: 1, Connected: true, Color: [3]int{1, 2, 3}}
+	game.Players[2] = &Player{Index: 2, Connected: true, Color: [3]int{4, 5, 6}}
+	game.Players[3] = &Player{Index: 3, Connected: false}
+	game.Balls = []*Ball{
+		{Id: 1, OwnerIndex: 0},
+		{Id: 2, OwnerIndex: 0},
+		{Id: 3, OwnerIndex: 0},
+		{Id: 4, OwnerIndex: 1},
+	}
+
+	game.TransferBalls(0)
+
+	owners := map[int]int{}
+	for _, ball := range game.Balls {
+		owners[ball.OwnerIndex]++
+		if ball.Id != 4 && ball.Color != game.Players[ball.OwnerIndex].Color {
+			t.Errorf("Expected ball %d to take its new owner's color, got %v", ball.Id, ball.Color)
+		}
+	}
+	if len(game.Balls) != 4 || owners[1] != 2 || owners[2] != 2 {
+		t.Errorf("Expected the balls to be spread evenly over the connected players, got %v", owners)
+	}
+
+	game.Players[0], game.Players[1], game.Players[2] = nil, nil, nil
+	game.TransferBalls(1)
+	game.TransferBalls(2)
+	if len(game.Balls) != 0 {
+		t.Errorf("Expected the balls to be removed with nobody left to take them, got %d", len(game.Balls))
+	}
+}
+
+func TestGame_ExpirePermanentBall(t *testing.T) {
+	game := StartGame()
+	clock := NewManualClock(time.Unix(100, 0))
+	game.SetClock(clock)
+	game.channel = make(chan GameMessage, 1)
+	game.config.PermanentBallMaxLifetime = time.Minute
+	game.Players[0] = &Player{Index: 0, Connected: true}
+	owned := &Ball{Id: 1, OwnerIndex: 0, permanent: true}
+	abandoned := &Ball{Id: 2, OwnerIndex: 1, permanent: true}
+	game.Balls = []*Ball{owned, abandoned}
+
+	game.ExpirePermanentBall(owned.Id)
+	game.ExpirePermanentBall(abandoned.Id)
+
+	if len(game.Balls) != 1 || game.Balls[0] != owned {
+		t.Errorf("Expected only the abandoned ball to be removed, got %d balls", len(game.Balls))
+	}
+	clock.Advance(time.Minute)
+	select {
+	case message := <-game.channel:
+		if expire, ok := message.(ExpirePermanentBall); !ok || expire.Id != owned.Id {
+			t.Errorf("Expected the connected player's ball to be checked again, got %#v", message)
+		}
+	default:
+		t.Errorf("Expected the connected player's ball to be checked again")
+	}
+}
+
+type frameWriter struct {
+	frames chan []byte
+	closed chan struct{}
+}
+
+func (writer *frameWriter) Write(data []byte) (int, error) {
+	select {
+	case writer.frames <- append([]byte{}, data...):
+		return len(data), nil
+	case <-writer.closed:
+		return 0, fmt.Errorf("writer closed")
+	}
+}
+
+func TestGame_WriteGameState_Resync(t *testing.T) {
+	game := StartGame()
+	writer := &frameWriter{frames: make(chan []byte, 16), closed: make(chan struct{})}
+	defer close(writer.closed)
+	resync := make(chan struct{}, 1)
+	go game.writeGameState(writer, JSONCodec, NoCompression, resync)
+
+	states := func(wait time.Duration) int {
+		count := 0
+		deadline := time.After(wait)
+		for {
+			select {
+			case frame := <-writer.frames:
+				if message, err := DecodeMessage(JSONCodec, frame); err == nil {
+					if _, ok := message.(*Game); ok {
+						count++
+					}
+				}
+			case <-deadline:
+				return count
+			}
+		}
+	}
+	if count := states(10 * utils.Period); count != 1 {
+		t.Fatalf("Expected a single state frame for an unchanged game, got %d", count)
+	}
+	resync <- struct{}{}
+	if count := states(10 * utils.Period); count != 1 {
+		t.Errorf("Expected a resync to send the full state again, got %d frames", count)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+type stalledWriter struct {
+	writes    int
+	deadlines int
+	closed    bool
+}
+
+func (writer *stalledWriter) Write(data []byte) (int, error) {
+	writer.writes++
+	return 0, timeoutError{}
+}
+
+func (writer *stalledWriter) SetWriteDeadline(deadline time.Time) error {
+	writer.deadlines++
+	return nil
+}
+
+func (writer *stalledWriter) Close() error {
+	writer.closed = true
+	return nil
+}
+
+func TestGame_WriteGameState_EvictsSlowClient(t *testing.T) {
+	game := StartGame()
+	writer := &stalledWriter{}
+
+	done := make(chan struct{})
+	go func() {
+		game.WriteGameState(writer, JSONCodec, NoCompression)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the slow client to be given up on")
+	}
+	if !writer.closed || writer.writes != 1 || writer.deadlines != 1 {
+		t.Errorf("Expected the client to be closed after its first timed out write, got closed %v after %d writes and %d deadlines", writer.closed, writer.writes, writer.deadlines)
+	}
+}
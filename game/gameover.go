@@ -0,0 +1,225 @@
+package game
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+type EndGame struct {
+	Reason string
+}
+type RestartGame struct{}
+type NextWave struct{}
+type CheckSuddenDeath struct{}
+
+const timeLimitReason = "time limit reached"
+
+type GameOverMessage struct {
+	Reason      string `json:"reason"`
+	WinnerIndex int    `json:"winnerIndex"`
+	Scores      [4]int `json:"scores"`
+	TeamScore   int64  `json:"teamScore,omitempty"`
+	ElapsedMs   int64  `json:"elapsedMs,omitempty"` //INFO Time since the balls went into play, co-op games compete on it instead of a winner
+}
+
+// INFO A cleared grid ends the game, unless endless mode refills it with the next wave
+func (game *Game) clearedGridMessage() GameMessage {
+	if game.config.EndlessMode {
+		return NextWave{}
+	}
+	return EndGame{Reason: "all bricks destroyed"}
+}
+
+// INFO Called from the game routine itself, so the follow up message is sent without blocking it
+func (game *Game) endWaveIfCleared() {
+	if game.Canvas.Grid.HasBricks() {
+		return
+	}
+	message := game.clearedGridMessage()
+	go func() { game.channel <- message }()
+}
+
+func (game *Game) StartGameTimer() {
+	if game.config.MaxGameDuration <= 0 || game.gameTimer != nil {
+		return
+	}
+	game.gameTimer = game.clock.AfterFunc(game.config.MaxGameDuration, func() {
+		game.channel <- EndGame{Reason: timeLimitReason}
+	})
+}
+
+func (game *Game) StopGameTimer() {
+	if game.gameTimer == nil {
+		return
+	}
+	game.gameTimer.Stop()
+	game.gameTimer = nil
+}
+
+// INFO Co-op games are timed from when the balls go into play, the team score starts over with the clock
+func (game *Game) restartClock() {
+	atomic.StoreInt64(&game.TeamScore, 0)
+	game.startedAt = time.Time{}
+	if game.HasPlayer() && !game.Waiting {
+		game.startedAt = time.Now()
+	}
+}
+
+// INFO Whether two or more players share the top score
+func (game *Game) Tied() bool {
+	winnerIndex := game.WinnerIndex()
+	if winnerIndex == -1 {
+		return false
+	}
+	for index, player := range game.Players {
+		if player != nil && index != winnerIndex && player.Score == game.Players[winnerIndex].Score {
+			return true
+		}
+	}
+	return false
+}
+
+// INFO A timed game tied at the time limit goes on until the tie is broken, false when the game should just end
+func (game *Game) StartSuddenDeath() bool {
+	if !game.config.SuddenDeath || game.GameOver != nil || !game.Tied() {
+		return false
+	}
+	game.SuddenDeath = true
+	center := utils.CanvasSize / 2
+	game.cues.Publish(time.Now(), EventCue{Kind: "suddenDeathStarted", X: center, Y: center})
+	return true
+}
+
+// INFO Checked after every score change during sudden death, the first one breaking the tie ends the game
+func (game *Game) CheckSuddenDeath() {
+	if !game.SuddenDeath || game.Tied() {
+		return
+	}
+	game.EndGame("sudden death")
+}
+
+func (game *Game) WinnerIndex() int {
+	winnerIndex := -1
+	for index, player := range game.Players {
+		if player == nil {
+			continue
+		}
+		if winnerIndex == -1 || player.Score > game.Players[winnerIndex].Score {
+			winnerIndex = index
+		}
+	}
+	return winnerIndex
+}
+
+func (game *Game) EndGame(reason string) {
+	if game.GameOver != nil {
+		return
+	}
+	game.StopGameTimer()
+	game.SuddenDeath = false
+
+	gameOver := &GameOverMessage{Reason: reason, WinnerIndex: game.WinnerIndex()}
+	if game.config.CoopMode {
+		gameOver.WinnerIndex = NoOwner
+		gameOver.TeamScore = atomic.LoadInt64(&game.TeamScore)
+		if !game.startedAt.IsZero() {
+			gameOver.ElapsedMs = time.Since(game.startedAt).Milliseconds()
+		}
+	}
+	for index, player := range game.Players {
+		if player != nil {
+			gameOver.Scores[index] = player.Score
+		}
+	}
+	game.GameOver = gameOver
+	game.recordEvent("gameOver", "reason", reason, "winner", gameOver.WinnerIndex)
+	game.reportGameOver()
+
+	balls := append([]*Ball{}, game.Balls...)
+	for _, ball := range balls {
+		game.RemoveBall(ball.Id)
+	}
+	for len(game.Lasers) > 0 {
+		game.RemoveLaser(game.Lasers[0].Id)
+	}
+
+	//INFO Keep the result on screen for a while before starting the next round
+	game.clock.AfterFunc(utils.GameOverRestartDelay, func() {
+		game.channel <- RestartGame{}
+	})
+}
+
+func (game *Game) reportGameOver() {
+	if game.leaderboard == nil {
+		return
+	}
+	names := [4]string{}
+	for index, player := range game.Players {
+		if player != nil {
+			names[index] = player.Name
+		}
+	}
+	game.leaderboard.channel <- RecordGameOver{GameOver: *game.GameOver, Names: names}
+}
+
+func (game *Game) RestartGame() {
+	if game.GameOver == nil {
+		return
+	}
+	game.GameOver = nil
+	game.ResetGrid()
+	game.restartClock()
+
+	for index, player := range game.Players {
+		if player == nil {
+			continue
+		}
+		player.Score = utils.InitialScore
+		ball := NewBall(
+			NewBallChannel(),
+			0,
+			0,
+			0,
+			game.Canvas.CanvasSize,
+			index,
+			game.NextBallId(),
+			game.random,
+		)
+		game.aimAtCenter(ball)
+		game.AddBall(ball, 0)
+	}
+
+	if game.HasPlayer() {
+		game.StartGameTimer()
+	}
+}
+
+// INFO Endless mode refills the cleared grid with tougher bricks instead of ending the game, scores carry over
+func (game *Game) NextWave() {
+	if game.GameOver != nil || game.Canvas.Grid.HasBricks() {
+		return
+	}
+	wave := game.Wave + 1
+	game.ResetGrid()
+	game.Wave = wave
+	game.Canvas.Grid.Strengthen((wave - 1) * game.config.EndlessWaveLifeIncrease)
+	game.Canvas.MaxLife = game.Canvas.Grid.MaxLife()
+}
+
+func (game *Game) Shutdown(ctx context.Context) bool {
+	select {
+	case game.channel <- EndGame{Reason: "server shutting down"}:
+	case <-ctx.Done():
+		return false
+	}
+	//INFO Give every connection a few frames to write the shutdown notice
+	select {
+	case <-time.After(utils.Period * 4):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,232 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestGame_WinnerIndex(t *testing.T) {
+	testCases := []struct {
+		players [4]*Player
+		winner  int
+	}{
+		{[4]*Player{nil, nil, nil, nil}, -1},
+		{[4]*Player{{Score: 10}, nil, nil, nil}, 0},
+		{[4]*Player{{Score: 10}, {Score: 30}, nil, {Score: 20}}, 1},
+		{[4]*Player{nil, {Score: -5}, nil, {Score: 20}}, 3},
+	}
+	for _, tc := range testCases {
+		game := Game{Players: tc.players}
+		result := game.WinnerIndex()
+		if result != tc.winner {
+			t.Errorf("Game.WinnerIndex() = %d, want %d", result, tc.winner)
+		}
+	}
+}
+
+func TestGame_EndGame(t *testing.T) {
+	game := StartGame()
+	game.Players[0] = &Player{Index: 0, Score: 120}
+	game.Players[2] = &Player{Index: 2, Score: 90}
+	game.Balls = []*Ball{{Id: 1}, {Id: 2}}
+
+	game.EndGame("time limit reached")
+
+	if game.GameOver == nil {
+		t.Fatalf("Expected game to be over")
+	}
+	expected := GameOverMessage{Reason: "time limit reached", WinnerIndex: 0, Scores: [4]int{120, 0, 90, 0}}
+	if *game.GameOver != expected {
+		t.Errorf("Expected game over %v, got %v", expected, *game.GameOver)
+	}
+	if len(game.Balls) != 0 {
+		t.Errorf("Expected all balls to be removed, got %d", len(game.Balls))
+	}
+
+	game.EndGame("all bricks destroyed")
+	if game.GameOver.Reason != "time limit reached" {
+		t.Errorf("Expected a finished game to keep its first result, got %s", game.GameOver.Reason)
+	}
+}
+
+func TestGame_EndGame_Coop(t *testing.T) {
+	game := StartGame()
+	game.config.CoopMode = true
+	game.mutex.Lock()
+	defer game.mutex.Unlock()
+	game.Players[0] = &Player{Index: 0, Score: 30}
+	game.Players[1] = &Player{Index: 1, Score: 50}
+	game.TeamScore = 80
+	game.startedAt = time.Now().Add(-90 * time.Second)
+
+	game.EndGame("all bricks destroyed")
+
+	if game.GameOver.WinnerIndex != NoOwner || game.GameOver.TeamScore != 80 {
+		t.Errorf("Expected a co-op game over without a winner and the team score, got %+v", *game.GameOver)
+	}
+	if game.GameOver.ElapsedMs < 90000 || game.GameOver.ElapsedMs > 91000 {
+		t.Errorf("Expected the clear time to be about 90s, got %dms", game.GameOver.ElapsedMs)
+	}
+
+	game.RestartGame()
+	if game.TeamScore != 0 || time.Since(game.startedAt) > time.Second {
+		t.Errorf("Expected a restart to reset the team score and the clock, got %d started %v ago", game.TeamScore, time.Since(game.startedAt))
+	}
+}
+
+func TestGame_StartGameTimer(t *testing.T) {
+	game := StartGame()
+	clock := NewManualClock(time.Unix(100, 0))
+	game.SetClock(clock)
+	game.config.MaxGameDuration = time.Minute
+	game.channel = make(chan GameMessage, 1)
+
+	game.StartGameTimer()
+	clock.Advance(time.Minute)
+	select {
+	case message := <-game.channel:
+		endGame, ok := message.(EndGame)
+		if !ok || endGame.Reason != "time limit reached" {
+			t.Errorf("Expected EndGame for the time limit, got %v", message)
+		}
+	default:
+		t.Errorf("Expected the game timer to end the game")
+	}
+
+	game.gameTimer = nil
+	game.StartGameTimer()
+	game.StopGameTimer()
+	clock.Advance(time.Hour)
+	select {
+	case message := <-game.channel:
+		t.Errorf("Expected a stopped timer not to end the game, got %v", message)
+	default:
+	}
+}
+
+func TestGame_SuddenDeath(t *testing.T) {
+	game := StartGame()
+	game.Players[0] = &Player{Index: 0, Score: 120}
+	game.Players[1] = &Player{Index: 1, Score: 120}
+	game.Players[2] = &Player{Index: 2, Score: 90}
+
+	if game.StartSuddenDeath() {
+		t.Fatalf("Expected no sudden death unless it is enabled")
+	}
+	game.config.SuddenDeath = true
+	if !game.StartSuddenDeath() || !game.SuddenDeath {
+		t.Fatalf("Expected a tie at the time limit to start sudden death")
+	}
+	if cues := game.cues.Since(0); len(cues) != 1 || cues[0].Kind != "suddenDeathStarted" {
+		t.Errorf("Expected a cue announcing sudden death, got %+v", cues)
+	}
+
+	game.AddPlayerScore(2, 10)
+	if game.GameOver != nil {
+		t.Fatalf("Expected sudden death to go on while the leaders are tied")
+	}
+
+	game.AddPlayerScore(1, 1)
+	if game.GameOver == nil || game.GameOver.WinnerIndex != 1 || game.GameOver.Reason != "sudden death" {
+		t.Fatalf("Expected the point breaking the tie to win the game, got %+v", game.GameOver)
+	}
+	if game.SuddenDeath {
+		t.Errorf("Expected sudden death to be over with the game")
+	}
+}
+
+func TestGame_StartSuddenDeath_NoTie(t *testing.T) {
+	game := StartGame()
+	game.config.SuddenDeath = true
+	game.Players[0] = &Player{Index: 0, Score: 120}
+	game.Players[1] = &Player{Index: 1, Score: 110}
+
+	if game.StartSuddenDeath() {
+		t.Errorf("Expected a game with a clear leader to end at the time limit")
+	}
+}
+
+func TestGame_StartGameTimer_Disabled(t *testing.T) {
+	game := StartGame()
+	game.config.MaxGameDuration = 0
+	game.StartGameTimer()
+	if game.gameTimer != nil {
+		t.Errorf("Expected no timer without a MaxGameDuration")
+	}
+}
+
+func TestGame_RestartGame(t *testing.T) {
+	game := StartGame()
+	game.Players[1] = &Player{Index: 1, Score: 150}
+	game.GameOver = &GameOverMessage{Reason: "time limit reached"}
+
+	game.RestartGame()
+
+	if game.GameOver != nil {
+		t.Errorf("Expected game over to be cleared")
+	}
+	if game.Players[1].Score != utils.InitialScore {
+		t.Errorf("Expected score to be reset to %d, got %d", utils.InitialScore, game.Players[1].Score)
+	}
+	if len(game.Balls) != 1 || game.Balls[0].OwnerIndex != 1 {
+		t.Errorf("Expected one ball for the remaining player, got %v", game.Balls)
+	}
+	for _, ball := range game.Balls {
+		ball.stop()
+	}
+}
+
+func TestGame_Shutdown(t *testing.T) {
+	game := StartGame()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if game.Shutdown(ctx) {
+		t.Errorf("Expected shutdown to time out without a game routine")
+	}
+
+	go game.ReadGameChannel()
+	game.Players[0] = &Player{Index: 0, Score: 100}
+	if !game.Shutdown(context.Background()) {
+		t.Fatalf("Expected shutdown to be acknowledged by the game routine")
+	}
+	snapshot, ok := game.RequestSnapshot(time.Second)
+	if !ok || snapshot.GameOver == nil || snapshot.GameOver.Reason != "server shutting down" {
+		t.Errorf("Expected game to be over because of the shutdown, got %v", snapshot.GameOver)
+	}
+}
+
+func TestGame_NextWave(t *testing.T) {
+	game := StartGame()
+	game.config.EndlessWaveLifeIncrease = 2
+	game.Players[0] = &Player{Index: 0, Score: 42}
+
+	game.NextWave()
+	if game.Wave != 1 {
+		t.Errorf("Expected no new wave while bricks are left, got wave %d", game.Wave)
+	}
+
+	game.Canvas.Grid = NewGrid(len(game.Canvas.Grid))
+	game.NextWave()
+	if game.Wave != 2 {
+		t.Fatalf("Expected wave 2 once the grid is cleared, got %d", game.Wave)
+	}
+	if !game.Canvas.Grid.HasBricks() {
+		t.Errorf("Expected the new wave to refill the grid")
+	}
+	for _, row := range game.Canvas.Grid {
+		for _, cell := range row {
+			if cell.Data.Type.IsBrick() && cell.Data.Life < 3 {
+				t.Errorf("Expected wave 2 bricks to have at least 3 life, got %d", cell.Data.Life)
+			}
+		}
+	}
+	if game.Canvas.MaxLife != game.Canvas.Grid.MaxLife() || game.Canvas.MaxLife < 3 {
+		t.Errorf("Expected the canvas max life to follow the strengthened bricks, got %d", game.Canvas.MaxLife)
+	}
+	if game.GameOver != nil || game.Players[0].Score != 42 {
+		t.Errorf("Expected the game to go on with scores kept")
+	}
+}
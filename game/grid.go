@@ -1,6 +1,9 @@
 package game
 
 import (
+	"math"
+	"math/rand"
+
 	"github.com/lguibr/pongo/utils"
 )
 
@@ -33,9 +36,9 @@ func NewGrid(gridSize int) Grid {
 	return grid
 }
 
-func (grid Grid) CreateQuarterGridSeed(numberOfVectors, maxVectorSize int) {
+func (grid Grid) CreateQuarterGridSeed(random *rand.Rand, numberOfVectors, maxVectorSize int) {
 	vectorZero := [2]int{0, 0}
-	randomVectors := utils.NewRandomPositiveVectors(numberOfVectors, maxVectorSize)
+	randomVectors := utils.NewRandomPositiveVectors(random, numberOfVectors, maxVectorSize)
 
 	randomLines := [][2][2]int{}
 	for _, vector := range randomVectors {
@@ -113,7 +116,7 @@ func (grid Grid) Rotate() Grid {
 	return result
 }
 
-func (grid Grid) RandomWalker(numberOfSteps int) {
+func (grid Grid) RandomWalker(random *rand.Rand, numberOfSteps int) {
 	gridSize := len(grid)
 	startPoint := [2]int{gridSize / 2, gridSize / 2}
 	grid[startPoint[0]][startPoint[1]].Data.Type = utils.Cells.Brick
@@ -121,7 +124,7 @@ func (grid Grid) RandomWalker(numberOfSteps int) {
 	var getNextPoint func(currentPoint [2]int) [2]int
 	getNextPoint = func(currentPoint [2]int) [2]int {
 
-		nextPoint := [2]int{currentPoint[0] + utils.RandomNumber(2), currentPoint[1] + utils.RandomNumber(2)}
+		nextPoint := [2]int{currentPoint[0] + utils.RandomNumber(random, 2), currentPoint[1] + utils.RandomNumber(random, 2)}
 		if nextPoint[0] < 0 || nextPoint[0] > gridSize || nextPoint[1] < 0 || nextPoint[1] > gridSize {
 			return getNextPoint(currentPoint)
 		}
@@ -141,6 +144,159 @@ func (grid Grid) RandomWalker(numberOfSteps int) {
 	}
 }
 
+func (grid Grid) HasBricks() bool {
+	for i := range grid {
+		for j := range grid[i] {
+			if grid[i][j].Data.Type.IsBrick() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// INFO Converts mirrored groups of bricks so steel keeps the grid symmetric across both axes
+func (grid Grid) MarkSteelBricks(random *rand.Rand, ratio float64) {
+	n := len(grid)
+	for i := 0; i < (n+1)/2; i++ {
+		m := len(grid[i])
+		for j := 0; j < (m+1)/2; j++ {
+			if random.Float64() >= ratio {
+				continue
+			}
+			for _, index := range [][2]int{{i, j}, {i, m - 1 - j}, {n - 1 - i, j}, {n - 1 - i, m - 1 - j}} {
+				data := grid[index[0]][index[1]].Data
+				if data.Type.IsBrick() {
+					data.Type = utils.Cells.Steel
+				}
+			}
+		}
+	}
+}
+
+// INFO Clears the middle of the grid and draws the obstacle in steel, every shape is symmetric so all players face the same map
+func (grid Grid) PlaceCenterObstacle(shape string) {
+	n := len(grid)
+	if shape == "" || n == 0 {
+		return
+	}
+	center := float64(n-1) / 2
+	radius := float64(n) / 4
+	for i := range grid {
+		for j := range grid[i] {
+			di, dj := math.Abs(float64(i)-center), math.Abs(float64(j)-center)
+			if di > radius || dj > radius {
+				continue
+			}
+			solid := false
+			switch shape {
+			case "cross":
+				solid = di < 1 || dj < 1
+			case "diamond":
+				solid = di+dj <= radius
+			case "ring":
+				distance := math.Hypot(di, dj)
+				solid = distance > radius-1 && distance <= radius
+			}
+			data := grid[i][j].Data
+			data.Type = utils.Cells.Empty
+			if solid {
+				data.Type = utils.Cells.Steel
+			}
+		}
+	}
+}
+
+func (grid Grid) Strengthen(extraLife int) {
+	if extraLife <= 0 {
+		return
+	}
+	for i := range grid {
+		for j := range grid[i] {
+			if grid[i][j].Data.Type.IsBrick() {
+				grid[i][j].Data.Life += extraLife
+				grid[i][j].Data.Level += extraLife
+			}
+		}
+	}
+}
+
+// INFO Highest life among the breakable bricks, steel never breaks so it has no life to render
+func (grid Grid) MaxLife() int {
+	maxLife := 0
+	for i := range grid {
+		for j := range grid[i] {
+			data := grid[i][j].Data
+			if data.Type.IsBrick() {
+				maxLife = utils.MaxInt(maxLife, data.Life)
+			}
+		}
+	}
+	return maxLife
+}
+
+func (grid Grid) MarkExplosiveBricks(random *rand.Rand, chance float64) {
+	for i := range grid {
+		for j := range grid[i] {
+			if grid[i][j].Data.Type == utils.Cells.Brick && random.Float64() < chance {
+				grid[i][j].Data.Type = utils.Cells.Explosive
+			}
+		}
+	}
+}
+
+// INFO Damages the brick and chains through exploding neighbors breadth first, every cell is visited at most once
+func (grid Grid) DamageBrick(row, col, damage int) (level int, destroyed bool) {
+	type hit struct{ row, col, damage int }
+	visited := map[[2]int]bool{{row, col}: true}
+	queue := []hit{{row, col, damage}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		data := grid[current.row][current.col].Data
+		if !data.Type.IsBrick() {
+			continue
+		}
+		data.Life -= current.damage
+		if data.Life > 0 {
+			continue
+		}
+		exploded := data.Type == utils.Cells.Explosive
+		data.Type = utils.Cells.Empty
+		level += data.Level
+		destroyed = true
+		if !exploded {
+			continue
+		}
+		for i := -1; i <= 1; i++ {
+			for j := -1; j <= 1; j++ {
+				neighbor := [2]int{current.row + i, current.col + j}
+				if neighbor[0] < 0 || neighbor[0] >= len(grid) || neighbor[1] < 0 || neighbor[1] >= len(grid[neighbor[0]]) || visited[neighbor] {
+					continue
+				}
+				visited[neighbor] = true
+				neighborData := grid[neighbor[0]][neighbor[1]].Data
+				if neighborData.Type.IsBrick() {
+					queue = append(queue, hit{neighbor[0], neighbor[1], neighborData.Life})
+				}
+			}
+		}
+	}
+	return level, destroyed
+}
+
+func (grid Grid) Copy() Grid {
+	copied := make(Grid, len(grid))
+	for i := range grid {
+		copied[i] = make([]Cell, len(grid[i]))
+		for j, cell := range grid[i] {
+			data := *cell.Data
+			copied[i][j] = Cell{X: cell.X, Y: cell.Y, Data: &data}
+		}
+	}
+	return copied
+}
+
 func (grid Grid) Compare(comparedGrid Grid) bool {
 	if len(grid) != len(comparedGrid) {
 		return false
@@ -159,7 +315,7 @@ func (grid Grid) Compare(comparedGrid Grid) bool {
 	return true
 }
 
-func (grid Grid) Fill(numberOfVectors, maxVectorSize, randomWalkers, randomSteps int) {
+func (grid Grid) Fill(random *rand.Rand, numberOfVectors, maxVectorSize, randomWalkers, randomSteps int) {
 	if numberOfVectors == 0 {
 		numberOfVectors = utils.NumberOfVectors
 	}
@@ -179,9 +335,9 @@ func (grid Grid) Fill(numberOfVectors, maxVectorSize, randomWalkers, randomSteps
 
 	for i := 0; i < 4; i++ {
 		gridSeed := NewGrid(halfGridSize)
-		gridSeed.CreateQuarterGridSeed(numberOfVectors, maxVectorSize)
+		gridSeed.CreateQuarterGridSeed(random, numberOfVectors, maxVectorSize)
 		for j := 0; j < randomWalkers; j++ {
-			gridSeed.RandomWalker(randomSteps)
+			gridSeed.RandomWalker(random, randomSteps)
 		}
 		quarters[i] = gridSeed.Rotate().Rotate()
 	}
@@ -111,7 +111,7 @@ func TestCreateQuarterGridSeed(t *testing.T) {
 				grid = append(grid, row)
 			}
 
-			grid.CreateQuarterGridSeed(test.numberOfVectors, test.maxVectorSize)
+			grid.CreateQuarterGridSeed(utils.NewRandom(1), test.numberOfVectors, test.maxVectorSize)
 
 			// check that the correct number of cells have been modified
 			count := 0
@@ -246,7 +246,7 @@ func TestGrid_RandomWalker(t *testing.T) {
 	}
 
 	for _, test := range testCases {
-		test.grid.RandomWalker(test.steps)
+		test.grid.RandomWalker(utils.NewRandom(1), test.steps)
 		totalBricks := 0
 		for i := range test.grid {
 			for j := range test.grid[i] {
@@ -285,7 +285,7 @@ func TestGrid_Fill(t *testing.T) {
 	for _, test := range testCases {
 		for i := 0; i < 100; i++ {
 
-			test.grid.Fill(test.numberOfVectors, test.maxVectorSize, test.randomSteps, test.randomWalkers)
+			test.grid.Fill(utils.NewRandom(1), test.numberOfVectors, test.maxVectorSize, test.randomSteps, test.randomWalkers)
 			totalBricks := 0
 			for i := range test.grid {
 				for j := range test.grid[i] {
@@ -405,3 +405,186 @@ func TestGrid_Compare(t *testing.T) {
 		}
 	}
 }
+
+func TestGrid_Copy(t *testing.T) {
+	grid := NewGrid(4)
+	grid[1][2] = NewCell(1, 2, 3, utils.Cells.Brick)
+
+	copied := grid.Copy()
+	if !grid.Compare(copied) {
+		t.Errorf("Expected copied grid to match the original")
+	}
+
+	copied[1][2].Data.Life = 0
+	if grid[1][2].Data.Life != 3 {
+		t.Errorf("Expected original grid to be unaffected by changes to the copy, got life %d", grid[1][2].Data.Life)
+	}
+}
+
+func TestGrid_HasBricks(t *testing.T) {
+	grid := NewGrid(4)
+	if grid.HasBricks() {
+		t.Errorf("Expected empty grid to have no bricks")
+	}
+	grid[3][1] = NewCell(3, 1, 1, utils.Cells.Brick)
+	if !grid.HasBricks() {
+		t.Errorf("Expected grid with a brick to report bricks")
+	}
+}
+
+func TestGrid_HasBricks_Explosive(t *testing.T) {
+	grid := NewGrid(4)
+	grid[0][0] = NewCell(0, 0, 1, utils.Cells.Explosive)
+	if !grid.HasBricks() {
+		t.Errorf("Expected grid with an explosive brick to report bricks")
+	}
+}
+
+func TestGrid_MarkExplosiveBricks(t *testing.T) {
+	grid := NewGrid(4)
+	grid[1][1] = NewCell(1, 1, 1, utils.Cells.Brick)
+	grid[2][2] = NewCell(2, 2, 1, utils.Cells.Block)
+
+	grid.MarkExplosiveBricks(utils.NewRandom(1), 1)
+
+	if grid[1][1].Data.Type != utils.Cells.Explosive {
+		t.Errorf("Expected brick to become explosive, got %v", grid[1][1].Data.Type)
+	}
+	if grid[2][2].Data.Type != utils.Cells.Block || grid[0][0].Data.Type != utils.Cells.Empty {
+		t.Errorf("Expected only bricks to become explosive")
+	}
+}
+
+func TestGrid_DamageBrick(t *testing.T) {
+	newBrick := func(x, y, life, level int, cellType utils.CellType) Cell {
+		cell := NewCell(x, y, life, cellType)
+		cell.Data.Level = level
+		return cell
+	}
+
+	t.Run("Plain brick loses life", func(t *testing.T) {
+		grid := NewGrid(3)
+		grid[1][1] = newBrick(1, 1, 3, 3, utils.Cells.Brick)
+		level, destroyed := grid.DamageBrick(1, 1, 1)
+		if destroyed || level != 0 || grid[1][1].Data.Life != 2 {
+			t.Errorf("Expected brick to survive with 2 life, got destroyed %v level %d life %d", destroyed, level, grid[1][1].Data.Life)
+		}
+	})
+
+	t.Run("Explosive brick chains through its neighbors", func(t *testing.T) {
+		grid := NewGrid(5)
+		grid[1][1] = newBrick(1, 1, 1, 1, utils.Cells.Explosive)
+		grid[2][2] = newBrick(2, 2, 3, 3, utils.Cells.Explosive)
+		grid[3][3] = newBrick(3, 3, 2, 2, utils.Cells.Brick)
+		grid[0][2] = newBrick(0, 2, 1, 1, utils.Cells.Block)
+		grid[4][4] = newBrick(4, 4, 5, 5, utils.Cells.Brick)
+
+		level, destroyed := grid.DamageBrick(1, 1, 1)
+
+		if !destroyed || level != 6 {
+			t.Errorf("Expected the chain to award level 6, got destroyed %v level %d", destroyed, level)
+		}
+		for _, index := range [][2]int{{1, 1}, {2, 2}, {3, 3}} {
+			if grid[index[0]][index[1]].Data.Type != utils.Cells.Empty {
+				t.Errorf("Expected cell %v to be cleared by the explosion", index)
+			}
+		}
+		if grid[0][2].Data.Type != utils.Cells.Block {
+			t.Errorf("Expected blocks to survive explosions")
+		}
+		if grid[4][4].Data.Type != utils.Cells.Brick || grid[4][4].Data.Life != 5 {
+			t.Errorf("Expected bricks out of reach to be untouched")
+		}
+	})
+}
+
+func TestGrid_MarkSteelBricks(t *testing.T) {
+	grid := NewGrid(4)
+	for i := range grid {
+		for j := range grid[i] {
+			grid[i][j] = NewCell(i, j, 1, utils.Cells.Brick)
+		}
+	}
+	grid[0][3] = NewCell(0, 3, 1, utils.Cells.Empty)
+
+	grid.MarkSteelBricks(utils.NewRandom(1), 1)
+
+	for i := range grid {
+		for j := range grid[i] {
+			expected := utils.Cells.Steel
+			if i == 0 && j == 3 {
+				expected = utils.Cells.Empty
+			}
+			if grid[i][j].Data.Type != expected {
+				t.Errorf("Expected cell (%d, %d) to be %v, got %v", i, j, expected, grid[i][j].Data.Type)
+			}
+		}
+	}
+	if grid.HasBricks() {
+		t.Errorf("Expected a grid of steel to have no bricks left to break")
+	}
+}
+
+func TestGrid_DamageBrick_Steel(t *testing.T) {
+	grid := NewGrid(3)
+	grid[1][1] = NewCell(1, 1, 1, utils.Cells.Explosive)
+	grid[1][2] = NewCell(1, 2, 2, utils.Cells.Steel)
+
+	_, destroyed := grid.DamageBrick(1, 2, 5)
+	if destroyed || grid[1][2].Data.Life != 2 {
+		t.Errorf("Expected steel to take no damage, got destroyed %v life %d", destroyed, grid[1][2].Data.Life)
+	}
+
+	grid.DamageBrick(1, 1, 1)
+	if grid[1][2].Data.Type != utils.Cells.Steel {
+		t.Errorf("Expected steel to survive explosions")
+	}
+}
+
+func TestGrid_PlaceCenterObstacle(t *testing.T) {
+	for _, shape := range utils.CenterObstacles {
+		grid := NewGrid(utils.GridSize)
+		grid.Fill(utils.NewRandom(1), 0, 0, 0, 0)
+		grid.PlaceCenterObstacle(shape)
+
+		n := len(grid)
+		steel := 0
+		for i := range grid {
+			for j := range grid[i] {
+				cellType := grid[i][j].Data.Type
+				if cellType == utils.Cells.Steel {
+					steel++
+				}
+				for _, mirror := range [][2]int{{i, n - 1 - j}, {n - 1 - i, j}, {j, i}} {
+					if (cellType == utils.Cells.Steel) != (grid[mirror[0]][mirror[1]].Data.Type == utils.Cells.Steel) {
+						t.Fatalf("Expected the %s obstacle to be symmetric, (%d, %d) and (%d, %d) differ", shape, i, j, mirror[0], mirror[1])
+					}
+				}
+			}
+		}
+		if steel == 0 {
+			t.Errorf("Expected the %s obstacle to place steel cells", shape)
+		}
+	}
+
+	grid := NewGrid(utils.GridSize)
+	grid.PlaceCenterObstacle("cross")
+	center := utils.GridSize / 2
+	if grid[center][0].Data.Type == utils.Cells.Steel || grid[center][center].Data.Type != utils.Cells.Steel {
+		t.Errorf("Expected the cross to only cover the middle of the grid")
+	}
+}
+
+func TestGrid_MaxLife(t *testing.T) {
+	grid := NewGrid(6)
+	if grid.MaxLife() != 0 {
+		t.Errorf("Expected an empty grid to have no max life, got %d", grid.MaxLife())
+	}
+	grid[0][0] = NewCell(0, 0, 3, utils.Cells.Brick)
+	grid[1][1] = NewCell(1, 1, 5, utils.Cells.Explosive)
+	grid[2][2] = NewCell(2, 2, 9, utils.Cells.Steel)
+
+	if grid.MaxLife() != 5 {
+		t.Errorf("Expected the highest breakable brick life 5, got %d", grid.MaxLife())
+	}
+}
@@ -0,0 +1,114 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+type GetHealth struct {
+	Reply chan GameHealth
+}
+
+type GameHealth struct {
+	Ready          bool `json:"ready"`
+	Players        int  `json:"players"`
+	Reconnecting   int  `json:"reconnecting"`
+	Balls          int  `json:"balls"`
+	TickersRunning bool `json:"tickersRunning"`
+}
+
+// INFO Health of the main game with the players and balls of every room that answered added up, a busy room doesn't fail the check
+type ServerHealth struct {
+	GameHealth
+	Rooms             int `json:"rooms"`
+	UnresponsiveRooms int `json:"unresponsiveRooms"`
+}
+
+func AggregateHealth(main GameHealth, rooms []GameHealth, unresponsiveRooms int) ServerHealth {
+	health := ServerHealth{GameHealth: main, Rooms: 1 + len(rooms) + unresponsiveRooms, UnresponsiveRooms: unresponsiveRooms}
+	for _, room := range rooms {
+		health.Players += room.Players
+		health.Reconnecting += room.Reconnecting
+		health.Balls += room.Balls
+		health.TickersRunning = health.TickersRunning || room.TickersRunning
+	}
+	return health
+}
+
+func (game *Game) Health() GameHealth {
+	health := GameHealth{
+		Ready:          game.GameOver == nil,
+		Balls:          len(game.Balls),
+		TickersRunning: len(game.Balls) > 0 || game.gameTimer != nil,
+	}
+	for _, player := range game.Players {
+		if player == nil {
+			continue
+		}
+		if player.Connected {
+			health.Players++
+		} else if player.reconnectTimer != nil {
+			health.Reconnecting++
+		}
+	}
+	return health
+}
+
+// INFO A game still moving balls or counting down its timer with nobody left to play is stuck, players within their reconnect grace period still hold their slot
+func (health GameHealth) Stuck() bool {
+	return health.TickersRunning && health.Players == 0 && health.Reconnecting == 0
+}
+
+func (game *Game) RequestHealth(timeout time.Duration) (GameHealth, bool) {
+	reply := make(chan GameHealth, 1)
+	select {
+	case game.channel <- GetHealth{Reply: reply}:
+	case <-time.After(timeout):
+		return GameHealth{}, false
+	}
+	select {
+	case health := <-reply:
+		return health, true
+	case <-time.After(timeout):
+		return GameHealth{}, false
+	}
+}
+
+// INFO Polls the game's health and ends it when it is stuck, so its balls and timer stop until players come back.
+// A game missing failures health checks in a row is wedged and handed to onUnresponsive, the watch stops there
+func (game *Game) WatchHealth(ctx context.Context, interval, timeout time.Duration, failures int, onUnresponsive func()) {
+	if interval <= 0 {
+		return
+	}
+	ticker := game.clock.NewTicker(interval)
+	defer ticker.Stop()
+	missed := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+		health, ok := game.RequestHealth(timeout)
+		if !ok {
+			missed++
+			utils.LogWarn("Game did not answer the health check in time", "missed", missed)
+			if missed >= failures {
+				utils.LogError("Game stopped answering health checks", "missed", missed)
+				onUnresponsive()
+				return
+			}
+			continue
+		}
+		missed = 0
+		if health.Stuck() {
+			select {
+			case game.channel <- EndGame{Reason: "no players left"}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestGame_RequestHealth(t *testing.T) {
+	game := StartGame()
+	go game.ReadGameChannel()
+	game.Players[0] = &Player{Index: 0, Connected: true}
+	game.Players[1] = &Player{Index: 1, Connected: false}
+
+	health, ok := game.RequestHealth(time.Second)
+	if !ok {
+		t.Fatalf("Expected the game to answer the health check")
+	}
+	if !health.Ready || health.Players != 1 || health.Balls != 0 || health.TickersRunning {
+		t.Errorf("Unexpected health %+v", health)
+	}
+	if health.Stuck() {
+		t.Errorf("Expected a game with a player and no balls not to be stuck")
+	}
+}
+
+func TestGame_Health_Reconnecting(t *testing.T) {
+	game := StartGame()
+	clock := NewManualClock(time.Unix(100, 0))
+	game.Balls = []*Ball{{Id: 1}}
+	game.Players[0] = &Player{Index: 0, Connected: true, channel: make(chan PlayerMessage, 1)}
+	game.Players[0].StartReconnectGracePeriod(clock, 10*time.Second)
+	//INFO A player whose grace period ran out but wasn't removed yet no longer holds the slot
+	game.Players[1] = &Player{Index: 1, Connected: false}
+
+	health := game.Health()
+	if health.Players != 0 || health.Reconnecting != 1 {
+		t.Errorf("Expected one player reconnecting, got %+v", health)
+	}
+	if health.Stuck() {
+		t.Errorf("Expected a game waiting for a player to reconnect not to be stuck")
+	}
+}
+
+func TestGame_RequestHealth_NotResponding(t *testing.T) {
+	game := StartGame()
+
+	_, ok := game.RequestHealth(10 * time.Millisecond)
+	if ok {
+		t.Errorf("Expected no health without a routine reading the game channel")
+	}
+}
+
+func TestGameHealth_Stuck(t *testing.T) {
+	testCases := []struct {
+		health   GameHealth
+		expected bool
+	}{
+		{GameHealth{Players: 0, TickersRunning: true}, true},
+		{GameHealth{Players: 0, TickersRunning: false}, false},
+		{GameHealth{Players: 2, TickersRunning: true}, false},
+		{GameHealth{Players: 0, Reconnecting: 1, TickersRunning: true}, false},
+	}
+	for _, tc := range testCases {
+		if tc.health.Stuck() != tc.expected {
+			t.Errorf("Stuck() for %+v = %v, want %v", tc.health, !tc.expected, tc.expected)
+		}
+	}
+}
+
+func TestAggregateHealth(t *testing.T) {
+	main := GameHealth{Ready: true, Players: 1, Balls: 1}
+	rooms := []GameHealth{{Players: 2, Reconnecting: 1, Balls: 3, TickersRunning: true}}
+	health := AggregateHealth(main, rooms, 1)
+	if !health.Ready || health.Players != 3 || health.Reconnecting != 1 || health.Balls != 4 || !health.TickersRunning {
+		t.Errorf("Expected the rooms added to the main game, got %+v", health)
+	}
+	if health.Rooms != 3 || health.UnresponsiveRooms != 1 {
+		t.Errorf("Expected 3 rooms with 1 unresponsive, got %+v", health)
+	}
+}
+
+func TestGame_WatchHealth_EndsStuckGame(t *testing.T) {
+	game := StartGame()
+	clock := NewManualClock(time.Unix(100, 0))
+	game.SetClock(clock)
+	game.channel = make(chan GameMessage, 1)
+	game.Balls = []*Ball{NewBall(NewBallChannel(), 100, 100, utils.BallSize, utils.CanvasSize, 0, 1, utils.NewRandom(1))}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go game.WatchHealth(ctx, time.Minute, time.Second, 1, func() {})
+
+	request := advanceUntil(t, clock, time.Minute, game.channel).(GetHealth)
+	request.Reply <- game.Health()
+
+	select {
+	case message := <-game.channel:
+		end, ok := message.(EndGame)
+		if !ok || end.Reason != "no players left" {
+			t.Errorf("Expected the stuck game to be ended, got %#v", message)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the stuck game to be ended")
+	}
+}
+
+func TestGame_WatchHealth_StopsWhileEnding(t *testing.T) {
+	game := StartGame()
+	clock := NewManualClock(time.Unix(100, 0))
+	game.SetClock(clock)
+	game.Balls = []*Ball{{Id: 1}}
+	ctx, cancel := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+	go func() {
+		game.WatchHealth(ctx, time.Minute, time.Second, 1, func() {})
+		close(exited)
+	}()
+
+	request := advanceUntil(t, clock, time.Minute, game.channel).(GetHealth)
+	request.Reply <- game.Health()
+	//INFO Nobody takes the end of the stuck game, the watch still has to stop with its context
+	cancel()
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Errorf("Expected the watch to stop while waiting to end the game")
+	}
+}
+
+func TestGame_WatchHealth_Unresponsive(t *testing.T) {
+	game := StartGame()
+	clock := NewManualClock(time.Unix(100, 0))
+	game.SetClock(clock)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reaped := make(chan bool, 1)
+	go game.WatchHealth(ctx, time.Minute, 10*time.Millisecond, 2, func() {
+		reaped <- true
+	})
+	expectNotReaped := func() {
+		t.Helper()
+		select {
+		case <-reaped:
+			t.Fatalf("Expected a single missed health check to be forgiven")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	advanceOnceTicking(t, clock, time.Minute)
+	expectNotReaped()
+
+	//INFO An answered check starts the count over
+	clock.Advance(time.Minute)
+	select {
+	case message := <-game.channel:
+		message.(GetHealth).Reply <- game.Health()
+	case <-time.After(time.Second):
+		t.Fatalf("Expected another health check")
+	}
+	clock.Advance(time.Minute)
+	expectNotReaped()
+
+	clock.Advance(time.Minute)
+	select {
+	case <-reaped:
+	case <-time.After(time.Second):
+		t.Errorf("Expected the game to be reaped after missing two health checks in a row")
+	}
+}
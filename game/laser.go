@@ -0,0 +1,137 @@
+package game
+
+import (
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+type GrantLaserCharges struct {
+	PlayerIndex int
+	Charges     int
+}
+type LaserFired struct {
+	PlayerIndex int
+}
+type LaserMoved struct {
+	LaserPayload *Laser
+}
+type LaserRemoved struct {
+	Id int
+}
+
+type Laser struct {
+	Id         int `json:"id"`
+	OwnerIndex int `json:"ownerIndex"`
+	X          int `json:"x"`
+	Y          int `json:"y"`
+	Vx         int `json:"vx"`
+	Vy         int `json:"vy"`
+	done       chan struct{}
+}
+
+// INFO Lasers leave the middle of the paddle straight toward the center of the canvas
+func NewLaser(paddle *Paddle, id, speed int) *Laser {
+	laser := &Laser{
+		Id:         id,
+		OwnerIndex: paddle.Index,
+		X:          paddle.X + paddle.Width/2,
+		Y:          paddle.Y + paddle.Height/2,
+		done:       make(chan struct{}),
+	}
+	center := paddle.canvasSize / 2
+	if paddle.Index%2 == 0 {
+		laser.Vx = speed
+		if laser.X > center {
+			laser.Vx = -speed
+		}
+	} else {
+		laser.Vy = speed
+		if laser.Y > center {
+			laser.Vy = -speed
+		}
+	}
+	return laser
+}
+
+func (laser *Laser) Engine(channel chan GameMessage) {
+	for {
+		time.Sleep(utils.Period)
+		select {
+		case <-laser.done:
+			return
+		case channel <- LaserMoved{laser}:
+		}
+	}
+}
+
+func (laser *Laser) getCenterIndex() (row, col int) {
+	return laser.X / utils.CellSize, laser.Y / utils.CellSize
+}
+
+// INFO Spends one of the player's charges to fire a laser, players without charges are ignored
+func (game *Game) FireLaser(playerIndex int) {
+	player := game.Players[playerIndex]
+	paddle := game.Paddles[playerIndex]
+	if player == nil || paddle == nil || player.LaserCharges <= 0 || game.GameOver != nil {
+		return
+	}
+	player.LaserCharges--
+	laser := NewLaser(paddle, game.NextBallId(), game.config.LaserSpeed)
+	game.Lasers = append(game.Lasers, laser)
+	go laser.Engine(game.channel)
+}
+
+// INFO Moves the laser and damages the first brick it reaches, lasers stop at bricks, blocks and the canvas edge
+func (game *Game) MoveLaser(laser *Laser) {
+	if !game.hasLaser(laser.Id) {
+		return
+	}
+	laser.X += laser.Vx
+	laser.Y += laser.Vy
+	grid := game.Canvas.Grid
+	row, col := laser.getCenterIndex()
+	if laser.X < 0 || laser.Y < 0 || row >= len(grid) || col >= len(grid[row]) {
+		game.RemoveLaser(laser.Id)
+		return
+	}
+
+	data := grid[row][col].Data
+	if data.Type.IsBrick() {
+		level, destroyed := grid.DamageBrick(row, col, 1)
+		game.cues.Publish(time.Now(), brickCue(destroyed, row, col))
+		player := game.Players[laser.OwnerIndex]
+		if destroyed && player != nil {
+			//INFO The player routine may be waiting on the game channel, so the score is sent without blocking the game routine
+			go func() { player.channel <- PlayerScore{level} }()
+		}
+		game.RemoveLaser(laser.Id)
+		if destroyed {
+			game.endWaveIfCleared()
+		}
+		return
+	}
+	if data.Type == utils.Cells.Block || data.Type == utils.Cells.Steel {
+		game.RemoveLaser(laser.Id)
+	}
+}
+
+func (game *Game) hasLaser(id int) bool {
+	for _, laser := range game.Lasers {
+		if laser.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (game *Game) RemoveLaser(id int) {
+	for index, laser := range game.Lasers {
+		if laser.Id != id {
+			continue
+		}
+		close(laser.done)
+		game.Lasers = append(game.Lasers[:index], game.Lasers[index+1:]...)
+		return
+	}
+}
@@ -0,0 +1,105 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestNewLaser_TowardCenter(t *testing.T) {
+	for index := 0; index < 4; index++ {
+		paddle := NewPaddle(NewPaddleChannel(), utils.CanvasSize, index)
+		laser := NewLaser(paddle, 1, 10)
+		if laser.OwnerIndex != index {
+			t.Errorf("Expected laser to be owned by player %d, got %d", index, laser.OwnerIndex)
+		}
+		center := utils.CanvasSize / 2
+		distance := utils.Abs(laser.X-center) + utils.Abs(laser.Y-center)
+		laser.X += laser.Vx
+		laser.Y += laser.Vy
+		if utils.Abs(laser.X-center)+utils.Abs(laser.Y-center) >= distance {
+			t.Errorf("Expected paddle %d's laser to head toward the center, got velocity (%d, %d)", index, laser.Vx, laser.Vy)
+		}
+	}
+}
+
+func newLaserTestGame() *Game {
+	game := StartGame()
+	game.Canvas.Grid = NewGrid(utils.GridSize)
+	game.Players[0] = &Player{Index: 0, Connected: true, channel: NewPlayerChannel()}
+	game.Paddles[0] = NewPaddle(NewPaddleChannel(), utils.CanvasSize, 0)
+	return game
+}
+
+func TestGame_FireLaser(t *testing.T) {
+	game := newLaserTestGame()
+
+	game.FireLaser(0)
+	if len(game.Lasers) != 0 {
+		t.Fatalf("Expected no laser without charges")
+	}
+
+	game.Players[0].LaserCharges = 1
+	game.FireLaser(0)
+	if len(game.Lasers) != 1 || game.Players[0].LaserCharges != 0 {
+		t.Errorf("Expected one laser and no charges left, got %d lasers and %d charges", len(game.Lasers), game.Players[0].LaserCharges)
+	}
+	game.RemoveLaser(game.Lasers[0].Id)
+	if len(game.Lasers) != 0 {
+		t.Errorf("Expected the laser to be removed")
+	}
+}
+
+func TestGame_MoveLaser_DamagesFirstBrick(t *testing.T) {
+	game := newLaserTestGame()
+	game.Canvas.Grid[2][5].Data = &BrickData{Type: utils.Cells.Brick, Life: 1, Level: 3}
+	game.Canvas.Grid[1][5].Data = &BrickData{Type: utils.Cells.Brick, Life: 1, Level: 1}
+	laser := &Laser{Id: 1, X: 3*utils.CellSize + 1, Y: 5*utils.CellSize + 1, Vx: -utils.CellSize, done: make(chan struct{})}
+	game.Lasers = []*Laser{laser}
+
+	game.MoveLaser(laser)
+
+	if game.Canvas.Grid[2][5].Data.Type != utils.Cells.Empty {
+		t.Errorf("Expected the first brick on the laser's path to break")
+	}
+	if game.Canvas.Grid[1][5].Data.Type != utils.Cells.Brick {
+		t.Errorf("Expected the laser to stop at the first brick")
+	}
+	if len(game.Lasers) != 0 {
+		t.Errorf("Expected the laser to disappear after hitting a brick")
+	}
+	select {
+	case message := <-game.Players[0].channel:
+		if score, ok := message.(PlayerScore); !ok || score.Score != 3 {
+			t.Errorf("Expected the owner to score the brick's level, got %#v", message)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the owner to score the broken brick")
+	}
+}
+
+func TestGame_MoveLaser_LeavesCanvas(t *testing.T) {
+	game := newLaserTestGame()
+	laser := &Laser{Id: 1, X: 5, Y: 100, Vx: -10, done: make(chan struct{})}
+	game.Lasers = []*Laser{laser}
+
+	game.MoveLaser(laser)
+
+	if len(game.Lasers) != 0 {
+		t.Errorf("Expected the laser to be removed once it leaves the canvas")
+	}
+}
+
+func TestGame_Encode_WithLasers(t *testing.T) {
+	game := newLaserTestGame()
+	game.Lasers = []*Laser{{Id: 1, X: 10, Y: 20, Vx: 5, done: make(chan struct{})}}
+
+	decoded, err := DecodeGameState(MsgpackCodec, NoCompression, game.Encode(MsgpackCodec))
+	if err != nil {
+		t.Fatalf("Error decoding game state with lasers: %v", err)
+	}
+	if len(decoded.Lasers) != 1 || decoded.Lasers[0].X != 10 || decoded.Lasers[0].Vx != 5 {
+		t.Errorf("Expected the laser to be encoded, got %+v", decoded.Lasers)
+	}
+}
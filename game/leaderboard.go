@@ -0,0 +1,105 @@
+package game
+
+import (
+	"sort"
+	"time"
+)
+
+type LeaderboardMessage interface{}
+
+type RecordGameOver struct {
+	GameOver GameOverMessage
+	Names    [4]string
+}
+type GetLeaderboard struct {
+	Limit int
+	Reply chan []LeaderboardEntry
+}
+
+type LeaderboardEntry struct {
+	Name        string `json:"name"`
+	Wins        int    `json:"wins"`
+	BestScore   int    `json:"bestScore"`
+	GamesPlayed int    `json:"gamesPlayed"`
+}
+
+type Leaderboard struct {
+	entries map[string]*LeaderboardEntry
+	channel chan LeaderboardMessage
+}
+
+func NewLeaderboard() *Leaderboard {
+	return &Leaderboard{
+		entries: make(map[string]*LeaderboardEntry),
+		channel: make(chan LeaderboardMessage),
+	}
+}
+
+func (leaderboard *Leaderboard) ReadLeaderboardChannel() {
+	for message := range leaderboard.channel {
+		switch message := message.(type) {
+		case RecordGameOver:
+			leaderboard.Record(message.GameOver, message.Names)
+		case GetLeaderboard:
+			message.Reply <- leaderboard.Top(message.Limit)
+		default:
+			continue
+		}
+	}
+}
+
+// INFO Only named players are ranked since anonymous ids are reused by every game
+func (leaderboard *Leaderboard) Record(gameOver GameOverMessage, names [4]string) {
+	for index, name := range names {
+		if name == "" {
+			continue
+		}
+		entry, ok := leaderboard.entries[name]
+		if !ok {
+			entry = &LeaderboardEntry{Name: name, BestScore: gameOver.Scores[index]}
+			leaderboard.entries[name] = entry
+		}
+		entry.GamesPlayed++
+		if gameOver.Scores[index] > entry.BestScore {
+			entry.BestScore = gameOver.Scores[index]
+		}
+		if index == gameOver.WinnerIndex {
+			entry.Wins++
+		}
+	}
+}
+
+func (leaderboard *Leaderboard) Top(limit int) []LeaderboardEntry {
+	entries := make([]LeaderboardEntry, 0, len(leaderboard.entries))
+	for _, entry := range leaderboard.entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Wins != entries[j].Wins {
+			return entries[i].Wins > entries[j].Wins
+		}
+		if entries[i].BestScore != entries[j].BestScore {
+			return entries[i].BestScore > entries[j].BestScore
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+func (leaderboard *Leaderboard) RequestTop(limit int, timeout time.Duration) ([]LeaderboardEntry, bool) {
+	reply := make(chan []LeaderboardEntry, 1)
+	select {
+	case leaderboard.channel <- GetLeaderboard{Limit: limit, Reply: reply}:
+	case <-time.After(timeout):
+		return nil, false
+	}
+	select {
+	case entries := <-reply:
+		return entries, true
+	case <-time.After(timeout):
+		return nil, false
+	}
+}
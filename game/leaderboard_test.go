@@ -0,0 +1,74 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLeaderboard_Record(t *testing.T) {
+	leaderboard := NewLeaderboard()
+	leaderboard.Record(
+		GameOverMessage{WinnerIndex: 1, Scores: [4]int{90, 150, 0, 0}},
+		[4]string{"alice", "bob", "", ""},
+	)
+	leaderboard.Record(
+		GameOverMessage{WinnerIndex: 0, Scores: [4]int{200, 80, 120, 0}},
+		[4]string{"alice", "bob", "", ""},
+	)
+	leaderboard.Record(
+		GameOverMessage{WinnerIndex: 2, Scores: [4]int{0, 0, 300, 0}},
+		[4]string{"", "", "bob", ""},
+	)
+
+	expected := []LeaderboardEntry{
+		{Name: "bob", Wins: 2, BestScore: 300, GamesPlayed: 3},
+		{Name: "alice", Wins: 1, BestScore: 200, GamesPlayed: 2},
+	}
+	result := leaderboard.Top(10)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected leaderboard %v, got %v", expected, result)
+	}
+
+	limited := leaderboard.Top(1)
+	if len(limited) != 1 || limited[0].Name != "bob" {
+		t.Errorf("Expected top 1 to be bob, got %v", limited)
+	}
+}
+
+func TestLeaderboard_RequestTop(t *testing.T) {
+	leaderboard := NewLeaderboard()
+	go leaderboard.ReadLeaderboardChannel()
+
+	leaderboard.channel <- RecordGameOver{
+		GameOver: GameOverMessage{WinnerIndex: 0, Scores: [4]int{120, 0, 0, 0}},
+		Names:    [4]string{"carol", "", "", ""},
+	}
+	entries, ok := leaderboard.RequestTop(5, time.Second)
+	if !ok {
+		t.Fatalf("Expected leaderboard to answer")
+	}
+	if len(entries) != 1 || entries[0].Name != "carol" || entries[0].Wins != 1 {
+		t.Errorf("Expected carol with one win, got %v", entries)
+	}
+}
+
+func TestGame_EndGame_ReportsToLeaderboard(t *testing.T) {
+	leaderboard := NewLeaderboard()
+	go leaderboard.ReadLeaderboardChannel()
+
+	game := StartGame()
+	game.SetLeaderboard(leaderboard)
+	game.Players[0] = &Player{Index: 0, Name: "dave", Score: 130}
+	game.Players[3] = &Player{Index: 3, Score: 110}
+	game.EndGame("all bricks destroyed")
+
+	entries, ok := leaderboard.RequestTop(5, time.Second)
+	if !ok {
+		t.Fatalf("Expected leaderboard to answer")
+	}
+	expected := []LeaderboardEntry{{Name: "dave", Wins: 1, BestScore: 130, GamesPlayed: 1}}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected leaderboard %v, got %v", expected, entries)
+	}
+}
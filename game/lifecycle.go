@@ -1,18 +1,45 @@
 package game
 
 import (
-	"time"
-
+	"github.com/lguibr/pongo/utils"
 	"golang.org/x/net/websocket"
 )
 
-func (game *Game) LifeCycle(ws *websocket.Conn, close func()) {
+type StatusMessage struct {
+	MessageType string `json:"messageType"`
+	Reason      string `json:"reason,omitempty"`
+	Position    int    `json:"position,omitempty"`
+}
+
+func WriteStatus(ws *websocket.Conn, codec Codec, status StatusMessage) error {
+	data, err := codec.Marshal(status)
+	if err != nil {
+		return err
+	}
+	_, err = ws.Write(data)
+	return err
+}
+
+// INFO Hands the connection to the game routine, which gives it a slot or a place in the queue
+func (game *Game) LifeCycle(ws *websocket.Conn, codec Codec, compression Compression, playerName string, clientId string, close func()) {
 	//INFO Start the WebSocket connection
-	playerIndex := game.GetNextIndex()
+	ws.PayloadType = codec.PayloadType()
+	game.channel <- EnqueuePlayer{Ws: ws, Codec: codec, Compression: compression, PlayerName: playerName, ClientId: clientId, Close: close}
+}
 
+// INFO Called by the game routine on the slot it reserved, so no other connection can take the slot or the client meanwhile
+func (game *Game) join(playerIndex int, joining EnqueuePlayer) {
+	game.joining[playerIndex] = &joining
+	go game.startPlayer(playerIndex, joining)
+}
+
+func (game *Game) startPlayer(playerIndex int, joining EnqueuePlayer) {
+	ws, codec, compression, close := joining.Ws, joining.Codec, joining.Compression, joining.Close
+	game.mutex.Lock()
 	//INFO Initiate a new game if there is no player
 	if !game.HasPlayer() {
-		game.Canvas.Grid.Fill(0, 0, 0, 0)
+		game.ResetGrid()
+		game.GameOver = nil
 	}
 	//INFO Initiating channels
 	playerChannel := NewPlayerChannel()
@@ -20,8 +47,11 @@ func (game *Game) LifeCycle(ws *websocket.Conn, close func()) {
 	paddleChannel := NewPaddleChannel()
 	// INFO Initiate the player and player's dependencies
 
-	player := NewPlayer(game.Canvas, playerIndex, playerChannel)
+	player := NewPlayer(game.Canvas, playerIndex, playerChannel, game.random)
+	player.Name = SanitizePlayerName(joining.PlayerName)
+	player.clientId = joining.ClientId
 	playerPaddle := NewPaddle(paddleChannel, game.Canvas.CanvasSize, playerIndex)
+	playerPaddle.state = &game.mutex
 	initialPlayerBall := NewBall(
 		NewBallChannel(),
 		0,
@@ -29,15 +59,25 @@ func (game *Game) LifeCycle(ws *websocket.Conn, close func()) {
 		0,
 		game.Canvas.CanvasSize,
 		playerIndex,
-		time.Now().Nanosecond(),
+		game.NextBallId(),
+		game.random,
 	)
+	game.aimAtCenter(initialPlayerBall)
+	game.mutex.Unlock()
 	//INFO Start reading from game's entities channels
 	go game.ReadPlayerChannel(playerIndex, playerChannel, playerPaddle, initialPlayerBall, close)
 	go playerPaddle.ReadPaddleChannel(paddleChannel)
 	go game.ReadBallChannel(playerIndex, initialPlayerBall)
 	//INFO Connect the player
 	player.Connect()
+	err := player.WriteAssignment(ws, codec)
+	if err != nil {
+		utils.LogError("Error writing player assignment to client", "err", err)
+	}
 	//INFO Start reading input from player and writing game state to player
-	go player.ReadInput(ws, paddleChannel)
-	go game.WriteGameState(ws)
+	clockSync := NewClockSync(game.clock)
+	go player.ReadInput(ws, paddleChannel, clockSync, game.config.PongTimeout, game.config.MaxInputsPerSecond)
+	go player.Heartbeat(ws, codec, game.clock, game.config.PingInterval)
+	go game.SyncServerTime(ws, codec, game.config.ServerTimeInterval, clockSync)
+	go game.writeGameState(ws, codec, compression, player.resync)
 }
@@ -0,0 +1,74 @@
+package game
+
+import (
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+// INFO Sent when a player joins, their ball only enters play once enough players are connected
+type StartWhenReady struct {
+	BallPayload *Ball
+}
+type CountdownTick struct {
+	SecondsRemaining int
+}
+
+// INFO Games needing several players or a countdown hold the balls in a lobby until they start
+func hasLobby(config utils.Config) bool {
+	return config.MinPlayersToStart > 1 || config.StartCountdownSeconds > 0
+}
+
+func (game *Game) ConnectedPlayers() int {
+	connected := 0
+	for _, player := range game.Players {
+		if player != nil && player.Connected {
+			connected++
+		}
+	}
+	return connected
+}
+
+// INFO Holds the joining player's ball while the game waits for players, then counts down and puts every held ball in play at once
+func (game *Game) StartWhenReady(ball *Ball) {
+	if !game.Waiting {
+		if game.startedAt.IsZero() {
+			game.startedAt = time.Now()
+		}
+		game.StartGameTimer()
+		game.AddBall(ball, 0)
+		return
+	}
+	game.pendingBalls = append(game.pendingBalls, ball)
+	//INFO Players joining during the countdown just get their ball held with the others
+	if game.Countdown > 0 || game.ConnectedPlayers() < game.config.MinPlayersToStart {
+		return
+	}
+	game.CountdownTick(game.config.StartCountdownSeconds)
+}
+
+// INFO Every second of the countdown is a state change clients render, the game starts when it runs out
+func (game *Game) CountdownTick(secondsRemaining int) {
+	game.Countdown = secondsRemaining
+	if secondsRemaining > 0 {
+		game.clock.AfterFunc(time.Second, func() {
+			game.channel <- CountdownTick{secondsRemaining - 1}
+		})
+		return
+	}
+	//INFO Players left during the countdown, wait for new ones and count down again
+	if game.ConnectedPlayers() < game.config.MinPlayersToStart {
+		return
+	}
+	game.Waiting = false
+	for _, pending := range game.pendingBalls {
+		//INFO Players who left while waiting don't get their ball
+		if pending.OwnerIndex < 0 || game.Players[pending.OwnerIndex] == nil {
+			continue
+		}
+		game.AddBall(pending, 0)
+	}
+	game.pendingBalls = nil
+	game.startedAt = time.Now()
+	game.StartGameTimer()
+}
@@ -0,0 +1,163 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestGame_StartWhenReady(t *testing.T) {
+	config := utils.DefaultConfig()
+	config.MinPlayersToStart = 2
+	config.MaxGameDuration = time.Minute
+	game := StartGameWithConfig(config)
+	//INFO The test plays the game routine, so it holds the state lock the balls put in play wait on
+	game.mutex.Lock()
+	defer game.mutex.Unlock()
+	if !game.Waiting {
+		t.Fatalf("Expected a game needing two players to start out waiting")
+	}
+
+	game.Players[0] = &Player{Index: 0, Connected: true}
+	first := NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 0, game.NextBallId(), game.random)
+	game.StartWhenReady(first)
+	if len(game.Balls) != 0 || game.gameTimer != nil {
+		t.Fatalf("Expected no ball in play and no timer with a single player, got %d balls", len(game.Balls))
+	}
+
+	game.Players[1] = &Player{Index: 1, Connected: true}
+	second := NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 1, game.NextBallId(), game.random)
+	game.StartWhenReady(second)
+	defer game.StopGameTimer()
+	defer game.RemoveBall(first.Id)
+	defer game.RemoveBall(second.Id)
+	if game.Waiting || len(game.Balls) != 2 {
+		t.Errorf("Expected both balls in play once the second player joined, got %d balls", len(game.Balls))
+	}
+	if game.gameTimer == nil {
+		t.Errorf("Expected the game timer to start with the game")
+	}
+
+	game.Players[2] = &Player{Index: 2, Connected: true}
+	third := NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 2, game.NextBallId(), game.random)
+	game.StartWhenReady(third)
+	defer game.RemoveBall(third.Id)
+	if len(game.Balls) != 3 {
+		t.Errorf("Expected players joining a started game to get their ball right away, got %d balls", len(game.Balls))
+	}
+}
+
+func TestGame_StartWhenReady_SkipsLeftPlayers(t *testing.T) {
+	config := utils.DefaultConfig()
+	config.MinPlayersToStart = 2
+	game := StartGameWithConfig(config)
+	game.mutex.Lock()
+	defer game.mutex.Unlock()
+
+	game.Players[0] = &Player{Index: 0, Connected: true}
+	game.StartWhenReady(NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 0, 1, game.random))
+	game.Players[0] = nil
+
+	game.Players[1] = &Player{Index: 1, Connected: true}
+	game.StartWhenReady(NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 1, 2, game.random))
+	if len(game.Balls) != 0 {
+		t.Errorf("Expected the game to keep waiting after the first player left, got %d balls", len(game.Balls))
+	}
+
+	game.Players[2] = &Player{Index: 2, Connected: true}
+	third := NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 2, 3, game.random)
+	game.StartWhenReady(third)
+	defer game.RemoveBall(2)
+	defer game.RemoveBall(3)
+	if len(game.Balls) != 2 {
+		t.Errorf("Expected only the connected players' balls in play, got %d", len(game.Balls))
+	}
+	for _, ball := range game.Balls {
+		if ball.Id == 1 {
+			t.Errorf("Expected the ball of the player who left to be dropped")
+		}
+	}
+}
+
+func TestGame_RemovePlayer_Lobby(t *testing.T) {
+	config := utils.DefaultConfig()
+	config.MinPlayersToStart = 2
+	game := StartGameWithConfig(config)
+	game.channel = make(chan GameMessage, 10)
+	game.mutex.Lock()
+	defer game.mutex.Unlock()
+
+	game.Players[0] = &Player{Index: 0, Connected: true}
+	left := NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 0, 1, game.random)
+	game.StartWhenReady(left)
+	game.RemovePlayer(0)
+	if len(game.pendingBalls) != 0 {
+		t.Fatalf("Expected the pending ball of the player who left to be dropped, got %d", len(game.pendingBalls))
+	}
+
+	game.Players[0] = &Player{Index: 0, Connected: true}
+	game.StartWhenReady(NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 0, 2, game.random))
+	game.Players[1] = &Player{Index: 1, Connected: true}
+	game.StartWhenReady(NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 1, 3, game.random))
+	defer game.RemoveBall(2)
+	defer game.RemoveBall(3)
+	if game.Waiting || len(game.Balls) != 2 {
+		t.Fatalf("Expected the game to start with the two players, got %d balls", len(game.Balls))
+	}
+	for _, ball := range game.Balls {
+		if ball == left {
+			t.Errorf("Expected the player taking the slot not to get the ball of the one who left")
+		}
+	}
+
+	game.RemovePlayer(0)
+	if game.Waiting {
+		t.Errorf("Expected the game to go on while a player is left")
+	}
+	game.RemovePlayer(1)
+	if !game.Waiting {
+		t.Errorf("Expected the game to wait for players again once everyone left")
+	}
+}
+
+func TestGame_CountdownTick(t *testing.T) {
+	config := utils.DefaultConfig()
+	config.StartCountdownSeconds = 3
+	game := StartGameWithConfig(config)
+	clock := NewManualClock(time.Unix(100, 0))
+	game.SetClock(clock)
+	game.channel = make(chan GameMessage, 1)
+	if !game.Waiting {
+		t.Fatalf("Expected a game with a countdown to start out waiting")
+	}
+
+	//INFO The test plays the game routine, so it holds the state lock the balls put in play wait on
+	game.mutex.Lock()
+	defer game.mutex.Unlock()
+	game.Players[0] = &Player{Index: 0, Connected: true}
+	ball := NewBall(NewBallChannel(), 0, 0, 0, utils.CanvasSize, 0, game.NextBallId(), game.random)
+	game.StartWhenReady(ball)
+	defer game.RemoveBall(ball.Id)
+
+	seconds := []int{game.Countdown}
+	for game.Countdown > 0 {
+		if len(game.Balls) != 0 {
+			t.Fatalf("Expected no ball in play during the countdown")
+		}
+		clock.Advance(time.Second)
+		select {
+		case message := <-game.channel:
+			game.CountdownTick(message.(CountdownTick).SecondsRemaining)
+		default:
+			t.Fatalf("Expected a countdown tick every second")
+		}
+		seconds = append(seconds, game.Countdown)
+	}
+	if len(seconds) != 4 || seconds[0] != 3 || seconds[1] != 2 || seconds[2] != 1 || seconds[3] != 0 {
+		t.Errorf("Expected a 3-2-1 countdown, got %v", seconds)
+	}
+	if game.Waiting || len(game.Balls) != 1 {
+		t.Errorf("Expected the ball in play once the countdown ran out, got %d balls", len(game.Balls))
+	}
+}
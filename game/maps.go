@@ -0,0 +1,226 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+type mapCell struct {
+	Type  utils.CellType `json:"type"`
+	Life  int            `json:"life"`
+	Level *int           `json:"level"`
+	Layer int            `json:"layer"`
+}
+
+// INFO A saved map only keeps the brick data of each cell, positions follow from the row and column
+func (grid Grid) ExportMap() ([]byte, error) {
+	rows := make([][]BrickData, len(grid))
+	for i := range grid {
+		rows[i] = make([]BrickData, len(grid[i]))
+		for j, cell := range grid[i] {
+			rows[i][j] = *cell.Data
+		}
+	}
+	return json.Marshal(rows)
+}
+
+// INFO Builds a grid from a saved map, the map has to be gridSize by gridSize and only hold known cell types
+func ImportMap(data []byte, gridSize int) (Grid, error) {
+	var rows [][]mapCell
+	err := json.Unmarshal(data, &rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) != gridSize {
+		return nil, fmt.Errorf("map has %d rows, the grid size is %d", len(rows), gridSize)
+	}
+	grid := NewGrid(gridSize)
+	for i, row := range rows {
+		if len(row) != gridSize {
+			return nil, fmt.Errorf("map row %d has %d cells, the grid size is %d", i, len(row), gridSize)
+		}
+		for j, cell := range row {
+			if issue := cell.issue(); issue != "" {
+				return nil, fmt.Errorf("map cell %d,%d %s", i, j, issue)
+			}
+			data := NewBrickData(cell.Type, cell.Life)
+			//INFO Maps authored by hand can leave the level out, bricks then score their life
+			if cell.Level != nil {
+				data.Level = *cell.Level
+			}
+			if cell.Type.IsBrick() {
+				data.Layer = cell.Layer
+			}
+			grid[i][j].Data = data
+		}
+	}
+	return grid, nil
+}
+
+func (cell mapCell) issue() string {
+	if cell.Type.String() == "Unknown" {
+		return fmt.Sprintf("has unknown type %d", cell.Type)
+	}
+	if cell.Type.IsBrick() && cell.Life < 0 {
+		return fmt.Sprintf("has negative life %d", cell.Life)
+	}
+	if cell.Layer < 0 {
+		return fmt.Sprintf("has negative layer %d", cell.Layer)
+	}
+	return ""
+}
+
+func LoadMap(path string, gridSize int) (Grid, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ImportMap(data, gridSize)
+}
+
+func SaveMap(path string, grid Grid) error {
+	data, err := grid.ExportMap()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// INFO Rooms start every wave from the configured map instead of a generated grid, a broken map falls back to generating
+func (game *Game) loadMap() {
+	if game.config.MapFile == "" {
+		return
+	}
+	grid, err := LoadMap(game.config.MapFile, utils.GridSize)
+	if err != nil {
+		utils.LogError("Error loading the map, generating grids instead", "path", game.config.MapFile, "err", err)
+		return
+	}
+	game.mapGrid = grid
+}
+
+type MapIssue struct {
+	Message string `json:"message"`
+	Cell    []int  `json:"cell,omitempty"`
+}
+
+type MapValidation struct {
+	Valid  bool       `json:"valid"`
+	Issues []MapIssue `json:"issues"`
+}
+
+// INFO Lists everything wrong with a map instead of stopping at the first problem, so a map editor can show all of it at once
+func ValidateMap(data []byte, gridSize int, symmetric bool) MapValidation {
+	issues := []MapIssue{}
+	var rows [][]mapCell
+	err := json.Unmarshal(data, &rows)
+	if err != nil {
+		return MapValidation{Issues: append(issues, MapIssue{Message: "map is not a grid of cells: " + err.Error()})}
+	}
+	if len(rows) != gridSize {
+		issues = append(issues, MapIssue{Message: fmt.Sprintf("map has %d rows, the grid size is %d", len(rows), gridSize)})
+	}
+	if len(rows)%2 != 0 {
+		issues = append(issues, MapIssue{Message: fmt.Sprintf("map size %d must be even", len(rows))})
+	}
+	for i, row := range rows {
+		if len(row) != len(rows) {
+			issues = append(issues, MapIssue{Message: fmt.Sprintf("row has %d cells, the map has %d rows", len(row), len(rows)), Cell: []int{i}})
+			continue
+		}
+		for j, cell := range row {
+			if issue := cell.issue(); issue != "" {
+				issues = append(issues, MapIssue{Message: "cell " + issue, Cell: []int{i, j}})
+			}
+		}
+	}
+	//INFO Symmetry and reachability only make sense on a square grid of known cells
+	if len(issues) > 0 {
+		return MapValidation{Issues: issues}
+	}
+	if symmetric {
+		issues = append(issues, asymmetricCells(rows)...)
+	}
+	issues = append(issues, unreachableBricks(rows)...)
+	return MapValidation{Valid: len(issues) == 0, Issues: issues}
+}
+
+// INFO Every cell has to match its mirror across both axes, so all players face the same map
+func asymmetricCells(rows [][]mapCell) []MapIssue {
+	issues := []MapIssue{}
+	n := len(rows)
+	for i := range rows {
+		for j, cell := range rows[i] {
+			for _, mirror := range [][2]int{{i, n - 1 - j}, {n - 1 - i, j}} {
+				if mirror[0] < i || mirror[1] < j || mirror == [2]int{i, j} {
+					continue
+				}
+				other := rows[mirror[0]][mirror[1]]
+				if cell.Type != other.Type || cell.Life != other.Life {
+					issues = append(issues, MapIssue{
+						Message: fmt.Sprintf("cell does not match its mirror %d,%d", mirror[0], mirror[1]),
+						Cell:    []int{i, j},
+					})
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// INFO Balls come in from the edges and break through bricks, a brick walled in by blocks or steel can never be cleared
+func unreachableBricks(rows [][]mapCell) []MapIssue {
+	n := len(rows)
+	solid := func(cell mapCell) bool {
+		return cell.Type == utils.Cells.Block || cell.Type == utils.Cells.Steel
+	}
+	reached := make([][]bool, n)
+	for i := range reached {
+		reached[i] = make([]bool, n)
+	}
+	queue := [][2]int{}
+	for i := 0; i < n; i++ {
+		for _, edge := range [][2]int{{i, 0}, {i, n - 1}, {0, i}, {n - 1, i}} {
+			if !reached[edge[0]][edge[1]] && !solid(rows[edge[0]][edge[1]]) {
+				reached[edge[0]][edge[1]] = true
+				queue = append(queue, edge)
+			}
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, step := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			next := [2]int{current[0] + step[0], current[1] + step[1]}
+			if next[0] < 0 || next[0] >= n || next[1] < 0 || next[1] >= n {
+				continue
+			}
+			if reached[next[0]][next[1]] || solid(rows[next[0]][next[1]]) {
+				continue
+			}
+			reached[next[0]][next[1]] = true
+			queue = append(queue, next)
+		}
+	}
+
+	issues := []MapIssue{}
+	bricks := 0
+	for i := range rows {
+		for j, cell := range rows[i] {
+			if !cell.Type.IsBrick() {
+				continue
+			}
+			bricks++
+			if !reached[i][j] {
+				issues = append(issues, MapIssue{Message: "brick is walled in by unbreakable cells", Cell: []int{i, j}})
+			}
+		}
+	}
+	if bricks == 0 {
+		issues = append(issues, MapIssue{Message: "map has no bricks to break"})
+	}
+	return issues
+}
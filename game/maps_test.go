@@ -0,0 +1,164 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestGrid_ExportMap_RoundTrip(t *testing.T) {
+	grid := NewGrid(utils.GridSize)
+	grid.Fill(utils.NewRandom(1), 0, 0, 0, 0)
+	grid.MarkSteelBricks(utils.NewRandom(1), 0.2)
+	grid.Strengthen(2)
+	grid[0][0].Data = NewBrickData(utils.Cells.Brick, 1)
+	grid[0][0].Data.Layer = 2
+
+	data, err := grid.ExportMap()
+	if err != nil {
+		t.Fatalf("Expected the grid to export, got %v", err)
+	}
+	imported, err := ImportMap(data, utils.GridSize)
+	if err != nil {
+		t.Fatalf("Expected the exported map to import, got %v", err)
+	}
+	if !grid.Compare(imported) {
+		t.Errorf("Expected the imported grid to match the exported one")
+	}
+	if imported[3][5].X != 3 || imported[3][5].Y != 5 {
+		t.Errorf("Expected imported cells to know their position, got %d,%d", imported[3][5].X, imported[3][5].Y)
+	}
+}
+
+func TestImportMap_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"not json", `bricks`},
+		{"too few rows", `[[{"type":2}]]`},
+		{"short row", `[[{"type":2},{"type":2}],[{"type":2}]]`},
+		{"unknown type", `[[{"type":2},{"type":9}],[{"type":2},{"type":2}]]`},
+		{"negative life", `[[{"type":0,"life":-1},{"type":2}],[{"type":2},{"type":2}]]`},
+		{"negative layer", `[[{"type":0,"layer":-1},{"type":2}],[{"type":2},{"type":2}]]`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ImportMap([]byte(tc.data), 2)
+			if err == nil {
+				t.Errorf("Expected %s to be rejected", tc.name)
+			}
+		})
+	}
+}
+
+func TestImportMap_HandAuthored(t *testing.T) {
+	grid, err := ImportMap([]byte(`[[{"type":0,"life":3},{"type":2}],[{"type":4},{"type":0}]]`), 2)
+	if err != nil {
+		t.Fatalf("Expected the map to import, got %v", err)
+	}
+	if grid[0][0].Data.Life != 3 || grid[0][0].Data.Level != 3 {
+		t.Errorf("Expected a brick without a level to score its life, got %+v", grid[0][0].Data)
+	}
+	if grid[1][1].Data.Life != 1 {
+		t.Errorf("Expected a brick without life to take one hit, got %d", grid[1][1].Data.Life)
+	}
+	if grid[1][0].Data.Type != utils.Cells.Steel {
+		t.Errorf("Expected steel to be kept, got %v", grid[1][0].Data.Type)
+	}
+}
+
+func TestStartGameWithConfig_MapFile(t *testing.T) {
+	grid := NewGrid(utils.GridSize)
+	grid[2][3].Data = NewBrickData(utils.Cells.Brick, 4)
+	grid[9][8].Data = NewBrickData(utils.Cells.Steel, 0)
+	path := filepath.Join(t.TempDir(), "map.json")
+	err := SaveMap(path, grid)
+	if err != nil {
+		t.Fatalf("Expected the map to be saved, got %v", err)
+	}
+
+	config := utils.DefaultConfig()
+	config.MapFile = path
+	config.CenterObstacle = "cross"
+	game := StartGameWithConfig(config)
+	if !game.Canvas.Grid.Compare(grid) {
+		t.Errorf("Expected the room to start from the map")
+	}
+	if game.Canvas.MaxLife != 4 {
+		t.Errorf("Expected the max life of the map, got %d", game.Canvas.MaxLife)
+	}
+
+	game.Canvas.Grid.DamageBrick(2, 3, 4)
+	game.ResetGrid()
+	if !game.Canvas.Grid.Compare(grid) {
+		t.Errorf("Expected a reset to restore the map")
+	}
+}
+
+func TestStartGameWithConfig_MissingMapFile(t *testing.T) {
+	config := utils.DefaultConfig()
+	config.MapFile = filepath.Join(os.TempDir(), "missing-pongo-map.json")
+	game := StartGameWithConfig(config)
+	if !game.Canvas.Grid.HasBricks() {
+		t.Errorf("Expected a missing map to fall back to a generated grid")
+	}
+}
+
+func TestValidateMap(t *testing.T) {
+	testCases := []struct {
+		name      string
+		data      string
+		symmetric bool
+		issues    int
+	}{
+		{"valid", `[[{"type":2},{"type":0}],[{"type":0},{"type":2}]]`, false, 0},
+		{"not json", `bricks`, false, 1},
+		{"wrong size and short row", `[[{"type":0}]]`, false, 2},
+		{"unknown types", `[[{"type":7},{"type":9}],[{"type":0},{"type":2}]]`, false, 2},
+		{"asymmetric", `[[{"type":0},{"type":2}],[{"type":2},{"type":2}]]`, true, 2},
+		{"asymmetric allowed", `[[{"type":0},{"type":2}],[{"type":2},{"type":2}]]`, false, 0},
+		{"no bricks", `[[{"type":2},{"type":2}],[{"type":2},{"type":2}]]`, false, 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			validation := ValidateMap([]byte(tc.data), 2, tc.symmetric)
+			if len(validation.Issues) != tc.issues {
+				t.Errorf("Expected %d issues, got %+v", tc.issues, validation.Issues)
+			}
+			if validation.Valid != (tc.issues == 0) {
+				t.Errorf("Expected valid to be %v", tc.issues == 0)
+			}
+		})
+	}
+}
+
+func TestValidateMap_WalledInBrick(t *testing.T) {
+	rows := make([][]map[string]int, 6)
+	for i := range rows {
+		rows[i] = make([]map[string]int, 6)
+		for j := range rows[i] {
+			rows[i][j] = map[string]int{"type": int(utils.Cells.Empty)}
+		}
+	}
+	for i := 1; i <= 3; i++ {
+		for j := 1; j <= 3; j++ {
+			rows[i][j] = map[string]int{"type": int(utils.Cells.Steel)}
+		}
+	}
+	rows[2][2] = map[string]int{"type": int(utils.Cells.Brick), "life": 1}
+	rows[0][5] = map[string]int{"type": int(utils.Cells.Brick), "life": 1}
+	data, _ := json.Marshal(rows)
+
+	validation := ValidateMap(data, 6, false)
+	if validation.Valid || len(validation.Issues) != 1 {
+		t.Fatalf("Expected only the walled in brick to be reported, got %+v", validation.Issues)
+	}
+	if !reflect.DeepEqual(validation.Issues[0].Cell, []int{2, 2}) {
+		t.Errorf("Expected the issue to point at the walled in brick, got %v", validation.Issues[0].Cell)
+	}
+}
@@ -0,0 +1,56 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+)
+
+// INFO Builds an empty value of a message type for the decoder to fill
+type MessageFactory func() interface{}
+
+var (
+	messageTypesMutex sync.RWMutex
+	messageTypes      = map[string]MessageFactory{
+		"ping":       func() interface{} { return &Heartbeat{} },
+		"pong":       func() interface{} { return &Heartbeat{} },
+		"events":     func() interface{} { return &EventCues{} },
+		"scoreboard": func() interface{} { return &Scoreboard{} },
+		"queued":     func() interface{} { return &StatusMessage{} },
+		"rejected":   func() interface{} { return &StatusMessage{} },
+		"serverTime": func() interface{} { return &ServerTime{} },
+		"assignment": func() interface{} { return &PlayerAssignment{} },
+	}
+)
+
+// INFO New message types only need to be registered once for DecodeMessage to understand them
+func RegisterMessageType(messageType string, factory MessageFactory) {
+	messageTypesMutex.Lock()
+	defer messageTypesMutex.Unlock()
+	messageTypes[messageType] = factory
+}
+
+// INFO Decodes any uncompressed frame the server sends into its concrete type, frames without a messageType are game states
+func DecodeMessage(codec Codec, data []byte) (interface{}, error) {
+	header := struct {
+		MessageType string `json:"messageType"`
+	}{}
+	err := codec.Unmarshal(data, &header)
+	if err != nil {
+		return nil, err
+	}
+	if header.MessageType == "" {
+		return DecodeGameState(codec, NoCompression, data)
+	}
+	messageTypesMutex.RLock()
+	factory, ok := messageTypes[header.MessageType]
+	messageTypesMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown message type %q", header.MessageType)
+	}
+	message := factory()
+	err = codec.Unmarshal(data, message)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
@@ -0,0 +1,66 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeMessage(t *testing.T) {
+	game := StartGame()
+	testCases := []struct {
+		name     string
+		message  interface{}
+		expected interface{}
+	}{
+		{"heartbeat", Heartbeat{MessageType: "ping"}, &Heartbeat{MessageType: "ping"}},
+		{"scoreboard", Scoreboard{MessageType: "scoreboard"}, &Scoreboard{MessageType: "scoreboard"}},
+		{"status", StatusMessage{MessageType: "queued", Position: 2}, &StatusMessage{MessageType: "queued", Position: 2}},
+		{"server time", ServerTime{MessageType: "serverTime", UnixNanos: 12, FrameSeq: 3}, &ServerTime{MessageType: "serverTime", UnixNanos: 12, FrameSeq: 3}},
+		{"assignment", PlayerAssignment{MessageType: "assignment", Index: 1, ReconnectToken: "token"}, &PlayerAssignment{MessageType: "assignment", Index: 1, ReconnectToken: "token"}},
+		{"events", EventCues{MessageType: "events", Events: []EventCue{{Seq: 1, Kind: "wallHit", X: 3, Y: 4}}}, &EventCues{MessageType: "events", Events: []EventCue{{Seq: 1, Kind: "wallHit", X: 3, Y: 4}}}},
+	}
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		for _, tc := range testCases {
+			data, err := codec.Marshal(tc.message)
+			if err != nil {
+				t.Fatalf("Error encoding %s: %v", tc.name, err)
+			}
+			decoded, err := DecodeMessage(codec, data)
+			if err != nil {
+				t.Errorf("%s %s: unexpected error %v", codec, tc.name, err)
+				continue
+			}
+			if !reflect.DeepEqual(decoded, tc.expected) {
+				t.Errorf("%s %s: expected %+v, got %+v", codec, tc.name, tc.expected, decoded)
+			}
+		}
+
+		decoded, err := DecodeMessage(codec, game.Encode(codec))
+		if state, ok := decoded.(*Game); err != nil || !ok || state.Canvas == nil {
+			t.Errorf("%s: expected a frame without a message type to decode as a game state, got %T %v", codec, decoded, err)
+		}
+	}
+}
+
+func TestDecodeMessage_UnknownType(t *testing.T) {
+	_, err := DecodeMessage(JSONCodec, []byte(`{"messageType":"teleport"}`))
+	if err == nil {
+		t.Errorf("Expected an error for an unregistered message type")
+	}
+}
+
+func TestRegisterMessageType(t *testing.T) {
+	type teleport struct {
+		MessageType string `json:"messageType"`
+		X           int    `json:"x"`
+	}
+	RegisterMessageType("teleportTest", func() interface{} { return &teleport{} })
+
+	decoded, err := DecodeMessage(JSONCodec, []byte(`{"messageType":"teleportTest","x":7}`))
+	if err != nil {
+		t.Fatalf("Unexpected error decoding a registered message type: %v", err)
+	}
+	if message, ok := decoded.(*teleport); !ok || message.X != 7 {
+		t.Errorf("Expected the registered type to be decoded, got %+v", decoded)
+	}
+}
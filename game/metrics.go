@@ -0,0 +1,184 @@
+package game
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+const tickAverageWindow = 16
+
+type GetMetrics struct {
+	Reply chan GameMetrics
+}
+
+type GameMetrics struct {
+	Players             int     `json:"players"`
+	MaxPlayers          int     `json:"maxPlayers"`
+	Balls               int     `json:"balls"`
+	TickCount           int64   `json:"tickCount"`
+	AverageTickDuration float64 `json:"averageTickDurationMs"`
+	CompressionRatio    float64 `json:"compressionRatio"`
+	Degraded            bool    `json:"degraded"`
+	DroppedFrames       int64   `json:"droppedFrames"`
+	//INFO Set by the server to the room name, games without one are labeled by their index
+	Room string `json:"room,omitempty"`
+}
+
+type ServerMetrics struct {
+	Rooms      int           `json:"rooms"`
+	Players    int           `json:"players"`
+	MaxPlayers int           `json:"maxPlayers"`
+	Balls      int           `json:"balls"`
+	Games      []GameMetrics `json:"games"`
+}
+
+// INFO Ball routines record their physics ticks concurrently, so the counters are updated atomically
+func (game *Game) recordTick(start time.Time) {
+	atomic.AddInt64(&game.tickCount, 1)
+	duration := int64(time.Since(start))
+	atomic.AddInt64(&game.tickDuration, duration)
+	game.updateTickBudget(duration)
+}
+
+// INFO Keeps a rolling average of the last ticks and flags the game as degraded while it is over budget
+func (game *Game) updateTickBudget(duration int64) {
+	var average int64
+	for {
+		previous := atomic.LoadInt64(&game.tickAverage)
+		average = previous + (duration-previous)/tickAverageWindow
+		if atomic.CompareAndSwapInt64(&game.tickAverage, previous, average) {
+			break
+		}
+	}
+	if game.config.TickBudgetWarnRatio <= 0 {
+		return
+	}
+	budget := int64(float64(utils.Period) * game.config.TickBudgetWarnRatio)
+	//INFO Recovering only under half the budget keeps a game hovering around it from flapping
+	if average > budget && atomic.CompareAndSwapInt32(&game.degraded, 0, 1) {
+		utils.LogWarn("Physics ticks over budget", "average", time.Duration(average), "budget", time.Duration(budget))
+	} else if average < budget/2 && atomic.CompareAndSwapInt32(&game.degraded, 1, 0) {
+		utils.LogInfo("Physics ticks back within budget", "average", time.Duration(average), "budget", time.Duration(budget))
+	}
+}
+
+func (game *Game) Degraded() bool {
+	return atomic.LoadInt32(&game.degraded) == 1
+}
+
+// INFO Broadcast interval of the game, doubled while degraded if throttling is enabled
+func (game *Game) broadcastPeriod() time.Duration {
+	if game.config.ThrottleWhenDegraded && game.Degraded() {
+		return 2 * utils.Period
+	}
+	return utils.Period
+}
+
+func (game *Game) recordCompression(raw, compressed int) {
+	atomic.AddInt64(&game.rawFrameBytes, int64(raw))
+	atomic.AddInt64(&game.compressedFrameBytes, int64(compressed))
+}
+
+func (game *Game) Metrics() GameMetrics {
+	metrics := GameMetrics{
+		MaxPlayers:    game.MaxPlayers(),
+		Balls:         len(game.Balls),
+		TickCount:     atomic.LoadInt64(&game.tickCount),
+		Degraded:      game.Degraded(),
+		DroppedFrames: atomic.LoadInt64(&game.droppedFrames),
+	}
+	for _, player := range game.Players {
+		if player != nil && player.Connected {
+			metrics.Players++
+		}
+	}
+	if metrics.TickCount > 0 {
+		average := time.Duration(atomic.LoadInt64(&game.tickDuration) / metrics.TickCount)
+		metrics.AverageTickDuration = float64(average) / float64(time.Millisecond)
+	}
+	//INFO Compressed size over raw size of every gzipped frame, zero until a client asks for compression
+	rawFrameBytes := atomic.LoadInt64(&game.rawFrameBytes)
+	if rawFrameBytes > 0 {
+		metrics.CompressionRatio = float64(atomic.LoadInt64(&game.compressedFrameBytes)) / float64(rawFrameBytes)
+	}
+	return metrics
+}
+
+func (game *Game) RequestMetrics(timeout time.Duration) (GameMetrics, bool) {
+	reply := make(chan GameMetrics, 1)
+	select {
+	case game.channel <- GetMetrics{Reply: reply}:
+	case <-time.After(timeout):
+		return GameMetrics{}, false
+	}
+	select {
+	case metrics := <-reply:
+		return metrics, true
+	case <-time.After(timeout):
+		return GameMetrics{}, false
+	}
+}
+
+func AggregateMetrics(games []GameMetrics) ServerMetrics {
+	metrics := ServerMetrics{Rooms: len(games), Games: games}
+	for _, game := range games {
+		metrics.Players += game.Players
+		metrics.MaxPlayers += game.MaxPlayers
+		metrics.Balls += game.Balls
+	}
+	return metrics
+}
+
+// INFO Renders the metrics in the Prometheus text exposition format, games are labeled by their room name or else their index
+func (metrics ServerMetrics) WritePrometheus(w io.Writer) error {
+	builder := &strings.Builder{}
+	writeGauge := func(name, help string, value interface{}) {
+		fmt.Fprintf(builder, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+	}
+	writeGauge("pongo_active_rooms", "Number of active rooms.", metrics.Rooms)
+	writeGauge("pongo_connected_players", "Number of connected players across rooms.", metrics.Players)
+	writeGauge("pongo_max_players", "Number of player slots across rooms.", metrics.MaxPlayers)
+	writeGauge("pongo_balls_total", "Number of balls in play across rooms.", metrics.Balls)
+
+	builder.WriteString("# HELP pongo_physics_tick_seconds Average physics tick duration per room.\n# TYPE pongo_physics_tick_seconds gauge\n")
+	for room, game := range metrics.Games {
+		fmt.Fprintf(builder, "pongo_physics_tick_seconds{room=\"%s\"} %g\n", roomLabel(room, game), game.AverageTickDuration/1000)
+	}
+	builder.WriteString("# HELP pongo_physics_ticks_total Physics ticks processed per room.\n# TYPE pongo_physics_ticks_total counter\n")
+	for room, game := range metrics.Games {
+		fmt.Fprintf(builder, "pongo_physics_ticks_total{room=\"%s\"} %d\n", roomLabel(room, game), game.TickCount)
+	}
+	builder.WriteString("# HELP pongo_compression_ratio Compressed over raw size of gzipped frames per room.\n# TYPE pongo_compression_ratio gauge\n")
+	for room, game := range metrics.Games {
+		fmt.Fprintf(builder, "pongo_compression_ratio{room=\"%s\"} %g\n", roomLabel(room, game), game.CompressionRatio)
+	}
+
+	builder.WriteString("# HELP pongo_dropped_frames_total Frames clients fell too far behind to receive per room.\n# TYPE pongo_dropped_frames_total counter\n")
+	for room, game := range metrics.Games {
+		fmt.Fprintf(builder, "pongo_dropped_frames_total{room=\"%s\"} %d\n", roomLabel(room, game), game.DroppedFrames)
+	}
+	builder.WriteString("# HELP pongo_degraded Whether the room's physics ticks are over budget.\n# TYPE pongo_degraded gauge\n")
+	for room, game := range metrics.Games {
+		degraded := 0
+		if game.Degraded {
+			degraded = 1
+		}
+		fmt.Fprintf(builder, "pongo_degraded{room=\"%s\"} %d\n", roomLabel(room, game), degraded)
+	}
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+func roomLabel(index int, game GameMetrics) string {
+	if game.Room != "" {
+		return game.Room
+	}
+	return strconv.Itoa(index)
+}
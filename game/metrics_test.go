@@ -0,0 +1,145 @@
+package game
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestGame_Metrics(t *testing.T) {
+	game := StartGame()
+	game.Players[0] = &Player{Index: 0, Connected: true}
+	game.Players[1] = &Player{Index: 1, Connected: false}
+	game.Balls = []*Ball{{Id: 1}, {Id: 2}}
+
+	metrics := game.Metrics()
+	if metrics.Players != 1 || metrics.MaxPlayers != 4 || metrics.Balls != 2 {
+		t.Errorf("Expected 1 of 4 players and 2 balls, got %d of %d players and %d balls", metrics.Players, metrics.MaxPlayers, metrics.Balls)
+	}
+	if metrics.TickCount != 0 || metrics.AverageTickDuration != 0 {
+		t.Errorf("Expected no ticks yet, got %d ticks averaging %fms", metrics.TickCount, metrics.AverageTickDuration)
+	}
+
+	game.recordTick(time.Now().Add(-2 * time.Millisecond))
+	game.recordTick(time.Now().Add(-4 * time.Millisecond))
+	metrics = game.Metrics()
+	if metrics.TickCount != 2 {
+		t.Errorf("Expected 2 ticks, got %d", metrics.TickCount)
+	}
+	if metrics.AverageTickDuration < 3 {
+		t.Errorf("Expected an average tick of at least 3ms, got %fms", metrics.AverageTickDuration)
+	}
+}
+
+func TestGame_RecordTick_Degraded(t *testing.T) {
+	game := StartGame()
+	if game.broadcastPeriod() != utils.Period {
+		t.Errorf("Expected a broadcast every tick period, got %v", game.broadcastPeriod())
+	}
+
+	for i := 0; i < 5*tickAverageWindow; i++ {
+		game.recordTick(time.Now().Add(-utils.Period))
+	}
+	if !game.Metrics().Degraded {
+		t.Fatalf("Expected ticks taking a whole period to degrade the game")
+	}
+	if game.broadcastPeriod() != 2*utils.Period {
+		t.Errorf("Expected a degraded game to broadcast every other period, got %v", game.broadcastPeriod())
+	}
+
+	for i := 0; i < 10*tickAverageWindow; i++ {
+		game.recordTick(time.Now())
+	}
+	if game.Metrics().Degraded {
+		t.Errorf("Expected instant ticks to bring the game back within budget")
+	}
+	if game.broadcastPeriod() != utils.Period {
+		t.Errorf("Expected a recovered game to broadcast every tick period, got %v", game.broadcastPeriod())
+	}
+}
+
+type slowWriter struct {
+	writes int
+}
+
+func (writer *slowWriter) Write(data []byte) (int, error) {
+	writer.writes++
+	if writer.writes > 1 {
+		return 0, io.ErrClosedPipe
+	}
+	time.Sleep(4 * utils.Period)
+	return len(data), nil
+}
+
+func TestGame_WriteGameState_DroppedFrames(t *testing.T) {
+	game := StartGame()
+	game.WriteGameState(&slowWriter{}, JSONCodec, NoCompression)
+
+	if dropped := game.Metrics().DroppedFrames; dropped < 2 {
+		t.Errorf("Expected the frames missed while writing to be counted, got %d", dropped)
+	}
+}
+
+func TestGame_Metrics_CompressionRatio(t *testing.T) {
+	game := StartGame()
+	if ratio := game.Metrics().CompressionRatio; ratio != 0 {
+		t.Errorf("Expected no compression ratio before any compressed frame, got %f", ratio)
+	}
+
+	game.recordCompression(1000, 200)
+	game.recordCompression(1000, 300)
+	if ratio := game.Metrics().CompressionRatio; ratio != 0.25 {
+		t.Errorf("Expected a compression ratio of 0.25, got %f", ratio)
+	}
+}
+
+func TestGame_RequestMetrics(t *testing.T) {
+	game := StartGame()
+
+	_, ok := game.RequestMetrics(10 * time.Millisecond)
+	if ok {
+		t.Errorf("Expected metrics request to time out without a game routine")
+	}
+
+	go game.ReadGameChannel()
+	_, ok = game.RequestMetrics(time.Second)
+	if !ok {
+		t.Errorf("Expected metrics request to be answered by the game routine")
+	}
+}
+
+func TestAggregateMetrics(t *testing.T) {
+	metrics := AggregateMetrics([]GameMetrics{{Players: 2, Balls: 3}, {Players: 1, Balls: 4}})
+	if metrics.Rooms != 2 || metrics.Players != 3 || metrics.Balls != 7 {
+		t.Errorf("Expected 2 rooms, 3 players and 7 balls, got %+v", metrics)
+	}
+}
+
+func TestServerMetrics_WritePrometheus(t *testing.T) {
+	metrics := AggregateMetrics([]GameMetrics{{Players: 2, Balls: 3, TickCount: 10, AverageTickDuration: 1.5, Degraded: true}})
+	builder := &strings.Builder{}
+	if err := metrics.WritePrometheus(builder); err != nil {
+		t.Fatalf("WritePrometheus returned error %v", err)
+	}
+	output := builder.String()
+	expectedLines := []string{
+		"# TYPE pongo_active_rooms gauge",
+		"pongo_active_rooms 1",
+		"pongo_connected_players 2",
+		"pongo_balls_total 3",
+		"# TYPE pongo_physics_tick_seconds gauge",
+		`pongo_physics_tick_seconds{room="0"} 0.0015`,
+		"# TYPE pongo_physics_ticks_total counter",
+		`pongo_physics_ticks_total{room="0"} 10`,
+		`pongo_dropped_frames_total{room="0"} 0`,
+		`pongo_degraded{room="0"} 1`,
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected prometheus output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
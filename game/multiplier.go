@@ -0,0 +1,81 @@
+package game
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+type ToggleScoreMultiplier struct {
+	Active bool
+}
+type AddPlayerScore struct {
+	PlayerIndex int
+	Score       int
+}
+
+// INFO Opens a score multiplier window on every interval while the event is configured
+func (game *Game) ScoreMultiplierEvents(ctx context.Context) {
+	if game.config.ScoreMultiplierInterval <= 0 || game.config.ScoreMultiplierValue <= 1 {
+		return
+	}
+	ticker := game.clock.NewTicker(game.config.ScoreMultiplierInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case game.channel <- ToggleScoreMultiplier{true}:
+		}
+	}
+}
+
+// INFO Every score change is multiplied while the window is open, clients get a cue when it opens and closes
+func (game *Game) ToggleScoreMultiplier(active bool) {
+	center := utils.CanvasSize / 2
+	if !active {
+		if game.ScoreMultiplier <= 1 {
+			return
+		}
+		game.ScoreMultiplier = 1
+		game.cues.Publish(time.Now(), EventCue{Kind: "scoreMultiplierEnded", X: center, Y: center})
+		return
+	}
+	//INFO A window still open when the next one starts just keeps running
+	if game.ScoreMultiplier > 1 {
+		return
+	}
+	game.ScoreMultiplier = game.config.ScoreMultiplierValue
+	game.cues.Publish(time.Now(), EventCue{Kind: "scoreMultiplierStarted", X: center, Y: center})
+	game.clock.AfterFunc(game.config.ScoreMultiplierDuration, func() {
+		game.channel <- ToggleScoreMultiplier{false}
+	})
+}
+
+func (game *Game) scoreMultiplier() int {
+	if game.ScoreMultiplier < 1 {
+		return 1
+	}
+	return game.ScoreMultiplier
+}
+
+// INFO Score changes are applied by the game routine, the one opening and closing multiplier windows
+func (game *Game) AddPlayerScore(index, score int) {
+	player := game.Players[index]
+	if player == nil {
+		return
+	}
+	score *= game.scoreMultiplier()
+	player.Score += score
+	if game.config.CoopMode {
+		atomic.AddInt64(&game.TeamScore, int64(score))
+	}
+	game.CheckSuddenDeath()
+}
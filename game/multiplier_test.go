@@ -0,0 +1,92 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGame_ToggleScoreMultiplier(t *testing.T) {
+	game := StartGame()
+	game.config.ScoreMultiplierValue = 3
+	game.config.ScoreMultiplierDuration = 10 * time.Millisecond
+	game.channel = make(chan GameMessage, 1)
+	clock := NewManualClock(time.Now())
+	game.SetClock(clock)
+	if game.scoreMultiplier() != 1 {
+		t.Fatalf("Expected scores to count once outside a window, got %d", game.scoreMultiplier())
+	}
+
+	game.ToggleScoreMultiplier(true)
+	game.ToggleScoreMultiplier(true)
+	if game.scoreMultiplier() != 3 {
+		t.Errorf("Expected scores to count three times during the window, got %d", game.scoreMultiplier())
+	}
+
+	clock.Advance(5 * time.Millisecond)
+	select {
+	case message := <-game.channel:
+		t.Fatalf("Expected the window to stay open, got %+v", message)
+	default:
+	}
+	clock.Advance(5 * time.Millisecond)
+	select {
+	case message := <-game.channel:
+		game.ToggleScoreMultiplier(message.(ToggleScoreMultiplier).Active)
+	default:
+		t.Fatalf("Expected the window to close")
+	}
+	if game.scoreMultiplier() != 1 {
+		t.Errorf("Expected scores to count once after the window, got %d", game.scoreMultiplier())
+	}
+	clock.Advance(time.Second)
+	select {
+	case message := <-game.channel:
+		t.Errorf("Expected a single window for overlapping starts, got %+v", message)
+	default:
+	}
+
+	cues := game.cues.Since(0)
+	if len(cues) != 2 || cues[0].Kind != "scoreMultiplierStarted" || cues[1].Kind != "scoreMultiplierEnded" {
+		t.Errorf("Expected a cue when the window opened and closed, got %+v", cues)
+	}
+}
+
+func TestGame_AddPlayerScore(t *testing.T) {
+	game := StartGame()
+	game.channel = make(chan GameMessage, 1)
+	game.config.CoopMode = true
+	game.Players[0] = &Player{Index: 0, Score: 10}
+	game.ScoreMultiplier = 3
+
+	callback := func() {}
+	game.handlePlayerMessage(0, PlayerScore{2}, nil, nil, &callback).send()
+	if game.Players[0].Score != 10 {
+		t.Fatalf("Expected the player routine to leave the score to the game routine, got %d", game.Players[0].Score)
+	}
+	message := <-game.channel
+	if message != (AddPlayerScore{0, 2}) {
+		t.Fatalf("Expected the score to be forwarded to the game routine, got %+v", message)
+	}
+	game.handleGameMessage(message)
+	if game.Players[0].Score != 16 || game.TeamScore != 6 {
+		t.Errorf("Expected the multiplied score to count for the player and the team, got %d and %d", game.Players[0].Score, game.TeamScore)
+	}
+}
+
+func TestGame_ScoreMultiplierEvents(t *testing.T) {
+	game := StartGame()
+	game.config.ScoreMultiplierInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go game.ScoreMultiplierEvents(ctx)
+
+	select {
+	case message := <-game.channel:
+		if message != (ToggleScoreMultiplier{true}) {
+			t.Errorf("Expected a window to open, got %+v", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected a window to open on the interval")
+	}
+}
@@ -2,7 +2,8 @@ package game
 
 import (
 	"encoding/json"
-	"fmt"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/lguibr/pongo/utils"
@@ -15,18 +16,60 @@ type PaddlePositionMessage struct {
 }
 type PaddleDirectionMessage struct {
 	Direction []byte
+	//INFO Smoothed lag of the connection the input came from, zero when it never echoed a ServerTime
+	Lag time.Duration
 }
 
 type Paddle struct {
-	X          int    `json:"x"`
-	Y          int    `json:"y"`
-	Width      int    `json:"width"`
-	Height     int    `json:"height"`
-	Index      int    `json:"index"`
-	Direction  string `json:"direction"`
-	Velocity   int    `json:"velocity"`
-	canvasSize int
-	channel    chan PaddleMessage
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Index     int    `json:"index"`
+	Direction string `json:"direction"`
+	Velocity  int    `json:"velocity"`
+	Sticky    bool   `json:"sticky"`
+	Dashing   bool   `json:"dashing"`
+	//INFO Signed speed along the movement axis, easing toward Velocity by acceleration every tick
+	CurrentVelocity float64 `json:"currentVelocity"`
+	acceleration    float64
+	canvasSize      int
+	channel         chan PaddleMessage
+	stuckBalls      []*Ball
+	shrunk          bool
+	unshrunkLength  int
+	shrinkTimer     Timer
+	dash            PaddleDash
+	dashEndsAt      time.Time
+	nextDashAt      time.Time
+	//INFO Direction changes waiting to be applied one per tick, nil applies them as they arrive
+	inputs chan paddleInput
+	//INFO Longest client lag replayed for a direction change, zero applies inputs as received
+	maxLagCompensation time.Duration
+	//INFO Ticks the current direction missed on its way from the client, replayed on the next move
+	catchUpTicks int
+	//INFO When the last catch-up was granted, a paddle gets at most one per compensation window
+	lastCatchUpAt time.Time
+	//INFO Where the paddle was after each recent tick, a catch-up rewinds to it instead of adding moves
+	history []paddlePosition
+	//INFO Lock of the game the paddle plays in, held by its engine and reader
+	state sync.Locker
+}
+
+type paddleInput struct {
+	direction    string
+	catchUpTicks int
+}
+
+type paddlePosition struct {
+	position        int
+	currentVelocity float64
+}
+
+type PaddleDash struct {
+	Factor   float64
+	Duration time.Duration
+	Cooldown time.Duration
 }
 
 func (p *Paddle) GetX() int      { return p.X }
@@ -39,34 +82,110 @@ func NewPaddleChannel() chan PaddleMessage {
 }
 
 func (paddle *Paddle) Move() {
-	if paddle.Direction != "left" && paddle.Direction != "right" {
+	paddle.applyQueuedDirection()
+	if paddle.catchUpTicks > 0 {
+		paddle.rewind(paddle.catchUpTicks)
+		paddle.catchUpTicks = 0
+	}
+	paddle.step()
+	paddle.record()
+}
+
+// INFO Replays the last ticks with the current direction from where the paddle was before them, so a late input replaces the moves of the old direction
+func (paddle *Paddle) rewind(ticks int) {
+	ticks = utils.MinInt(ticks, len(paddle.history)-1)
+	if ticks <= 0 {
 		return
 	}
+	from := paddle.history[len(paddle.history)-1-ticks]
+	paddle.setPosition(from.position)
+	paddle.CurrentVelocity = from.currentVelocity
+	paddle.history = paddle.history[:len(paddle.history)-ticks]
+	for i := 0; i < ticks; i++ {
+		paddle.step()
+		paddle.record()
+	}
+}
 
-	velocity := [2]int{0, paddle.Velocity}
+// INFO Keeps the positions of as many ticks as the compensation window can rewind
+func (paddle *Paddle) record() {
+	if paddle.maxLagCompensation <= 0 {
+		return
+	}
+	paddle.history = append(paddle.history, paddlePosition{position: paddle.position(), currentVelocity: paddle.CurrentVelocity})
+	if limit := int(paddle.maxLagCompensation/utils.Period) + 1; len(paddle.history) > limit {
+		paddle.history = paddle.history[len(paddle.history)-limit:]
+	}
+}
 
-	if paddle.Index%2 != 0 {
-		velocity = utils.SwapVectorCoordinates(velocity)
+// INFO Position along the movement axis
+func (paddle *Paddle) position() int {
+	if paddle.Index%2 == 0 {
+		return paddle.Y
 	}
+	return paddle.X
+}
 
-	velocityX, velocityY := velocity[0], velocity[1]
-	if paddle.Direction == "left" {
+func (paddle *Paddle) setPosition(position int) {
+	if paddle.Index%2 == 0 {
+		paddle.Y = position
+	} else {
+		paddle.X = position
+	}
+}
 
-		if paddle.X-velocityX < 0 || paddle.Y+velocityY < 0 {
-			return
-		}
+func (paddle *Paddle) step() {
+	target := 0.0
+	switch paddle.Direction {
+	case "left":
+		target = -float64(paddle.Speed())
+	case "right":
+		target = float64(paddle.Speed())
+	}
+	paddle.CurrentVelocity = approach(paddle.CurrentVelocity, target, paddle.acceleration)
 
-		paddle.X -= velocityX
-		paddle.Y -= velocityY
+	step := int(math.Round(paddle.CurrentVelocity))
+	if step == 0 {
+		return
+	}
+	if paddle.Index%2 == 0 {
+		paddle.Y += step
 	} else {
+		paddle.X += step
+	}
+	//INFO A paddle pushed against the wall stops until its player picks a direction again
+	if paddle.Clamp() {
+		paddle.Direction = ""
+		paddle.CurrentVelocity = 0
+	}
+}
 
-		if paddle.X+paddle.Width+velocityX > paddle.canvasSize || paddle.Y+paddle.Height-velocityY > paddle.canvasSize {
-			return
-		}
+// INFO Moves value toward target by at most step, a step of zero or less jumps straight to the target
+func approach(value, target, step float64) float64 {
+	if step <= 0 || math.Abs(target-value) <= step {
+		return target
+	}
+	if target > value {
+		return value + step
+	}
+	return value - step
+}
 
-		paddle.X += velocityX
-		paddle.Y += velocityY
+// INFO Keeps the paddle fully inside the canvas along its movement axis, reporting whether it had to be pushed back
+func (paddle *Paddle) Clamp() bool {
+	clamp := func(position, length int) int {
+		return utils.MaxInt(0, utils.MinInt(position, paddle.canvasSize-length))
 	}
+
+	clampedX, clampedY := paddle.X, paddle.Y
+	if paddle.Index%2 == 0 {
+		clampedY = clamp(paddle.Y, paddle.Height)
+	} else {
+		clampedX = clamp(paddle.X, paddle.Width)
+	}
+	clamped := clampedX != paddle.X || clampedY != paddle.Y
+	paddle.X, paddle.Y = clampedX, clampedY
+	return clamped
 }
 
 func NewPaddle(channel chan PaddleMessage, canvasSize, index int) *Paddle {
@@ -109,25 +228,147 @@ type Direction struct {
 	Direction string `json:"direction"`
 }
 
-func (paddle *Paddle) SetDirection(buffer []byte) (Direction, error) {
+// INFO Applies a direction change that took lag to reach the server, see lagCompensationTicks
+func (paddle *Paddle) SetDirection(buffer []byte, lag time.Duration) (Direction, error) {
 	direction := Direction{}
 	err := json.Unmarshal(buffer, &direction)
 	if err != nil {
-		fmt.Println("Error unmarshalling message:", err)
+		utils.LogWarn("Error unmarshalling message", "paddle", paddle.Index, "err", err)
 		return direction, err
 	}
+	if direction.Direction == "Dash" {
+		paddle.Dash(time.Now())
+		return direction, nil
+	}
 	newDirection := utils.DirectionFromString(direction.Direction)
-
-	paddle.Direction = newDirection
+	catchUpTicks := 0
+	now := time.Now()
+	if newDirection != "" && now.Sub(paddle.lastCatchUpAt) >= paddle.maxLagCompensation {
+		catchUpTicks = lagCompensationTicks(lag, paddle.maxLagCompensation)
+		if catchUpTicks > 0 {
+			paddle.lastCatchUpAt = now
+		}
+	}
+	if paddle.inputs == nil {
+		paddle.Direction = newDirection
+		paddle.catchUpTicks = catchUpTicks
+		return direction, nil
+	}
+	paddle.queueDirection(paddleInput{direction: newDirection, catchUpTicks: catchUpTicks})
 	return direction, nil
 }
 
+// INFO Whole ticks an input missed on its way from a connection with the given lag, capped to the window so a client can't rewind further than the configured lag
+func lagCompensationTicks(lag, window time.Duration) int {
+	if lag <= 0 || window <= 0 {
+		return 0
+	}
+	if lag > window {
+		lag = window
+	}
+	return int(lag / utils.Period)
+}
+
+// INFO Queues a direction change for the next ticks, a full buffer drops its oldest change so the latest taps always count
+func (paddle *Paddle) queueDirection(direction paddleInput) {
+	select {
+	case paddle.inputs <- direction:
+		return
+	default:
+	}
+	select {
+	case <-paddle.inputs:
+	default:
+	}
+	select {
+	case paddle.inputs <- direction:
+	default:
+	}
+}
+
+func (paddle *Paddle) applyQueuedDirection() {
+	select {
+	case input := <-paddle.inputs:
+		paddle.Direction = input.direction
+		paddle.catchUpTicks = input.catchUpTicks
+	default:
+	}
+}
+
+// INFO Speeds the paddle up for a short while, ignored while the previous dash is cooling down
+func (paddle *Paddle) Dash(now time.Time) bool {
+	if paddle.dash.Factor <= 1 || now.Before(paddle.nextDashAt) {
+		return false
+	}
+	paddle.Dashing = true
+	paddle.dashEndsAt = now.Add(paddle.dash.Duration)
+	paddle.nextDashAt = now.Add(paddle.dash.Cooldown)
+	return true
+}
+
+func (paddle *Paddle) Speed() int {
+	speed := paddle.Velocity
+	if paddle.Dashing {
+		if time.Now().After(paddle.dashEndsAt) {
+			paddle.Dashing = false
+		} else {
+			speed = int(float64(speed) * paddle.dash.Factor)
+		}
+	}
+	return speed
+}
+
+// INFO Signed speed along the paddle's movement axis, negative when moving left
+func (paddle *Paddle) AxisVelocity() int {
+	return int(math.Round(paddle.CurrentVelocity))
+}
+
+func (paddle *Paddle) Length() int {
+	if paddle.Index%2 == 0 {
+		return paddle.Height
+	}
+	return paddle.Width
+}
+
+// INFO Resizes the paddle along its movement axis keeping it centered and inside the canvas
+func (paddle *Paddle) Resize(length int) {
+	if length <= 0 {
+		return
+	}
+	clamp := func(position int) int {
+		return utils.MaxInt(0, utils.MinInt(position, paddle.canvasSize-length))
+	}
+
+	if paddle.Index%2 == 0 {
+		center := paddle.Y + paddle.Height/2
+		paddle.Height = length
+		paddle.Y = clamp(center - length/2)
+	} else {
+		center := paddle.X + paddle.Width/2
+		paddle.Width = length
+		paddle.X = clamp(center - length/2)
+	}
+}
+
+func (paddle *Paddle) Catch(ball *Ball) {
+	paddle.Sticky = false
+	ball.StickTo(paddle)
+	paddle.stuckBalls = append(paddle.stuckBalls, ball)
+}
+
+func (paddle *Paddle) LaunchStuckBalls() {
+	for _, ball := range paddle.stuckBalls {
+		ball.Launch()
+	}
+	paddle.stuckBalls = nil
+}
+
 func (paddle *Paddle) Engine() {
 	for {
 		if paddle == nil {
 			break
 		}
-		paddle.Move()
+		withState(paddle.state, paddle.Move)
 		paddle.channel <- PaddlePositionMessage{Paddle: paddle}
 		time.Sleep(utils.Period)
 	}
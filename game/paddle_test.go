@@ -1,7 +1,9 @@
 package game
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/lguibr/pongo/utils"
 )
@@ -20,7 +22,7 @@ func TestPaddle_SetDirection(t *testing.T) {
 		{[]byte(``), "", false},
 	}
 	for _, tc := range testCases {
-		_, err := paddle.SetDirection(tc.buffer)
+		_, err := paddle.SetDirection(tc.buffer, 0)
 		if err != nil {
 			println(err)
 		}
@@ -79,3 +81,239 @@ func TestPaddle_Move(t *testing.T) {
 		t.Errorf("Expected paddle to remain at (%d, %d) but got (%d, %d)", utils.CanvasSize-paddle.Width, utils.CanvasSize-paddle.Height, paddle.X, paddle.Y)
 	}
 }
+
+func TestPaddle_Resize(t *testing.T) {
+	testCases := []struct {
+		name                          string
+		paddle                        Paddle
+		length                        int
+		expectedX, expectedY          int
+		expectedWidth, expectedHeight int
+	}{
+		{
+			"Vertical paddle shrinks around its center",
+			Paddle{Index: 0, X: 0, Y: 100, Width: 10, Height: 60, canvasSize: 300},
+			30,
+			0, 115, 10, 30,
+		},
+		{
+			"Horizontal paddle grows around its center",
+			Paddle{Index: 1, X: 100, Y: 0, Width: 60, Height: 10, canvasSize: 300},
+			90,
+			85, 0, 90, 10,
+		},
+		{
+			"Growing paddle stays inside the canvas",
+			Paddle{Index: 3, X: 240, Y: 290, Width: 60, Height: 10, canvasSize: 300},
+			120,
+			180, 290, 120, 10,
+		},
+		{
+			"Invalid length is ignored",
+			Paddle{Index: 2, X: 290, Y: 10, Width: 10, Height: 60, canvasSize: 300},
+			0,
+			290, 10, 10, 60,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			paddle := tc.paddle
+			paddle.Resize(tc.length)
+			if paddle.X != tc.expectedX || paddle.Y != tc.expectedY || paddle.Width != tc.expectedWidth || paddle.Height != tc.expectedHeight {
+				t.Errorf("Expected paddle (%d, %d, %d, %d), got (%d, %d, %d, %d)",
+					tc.expectedX, tc.expectedY, tc.expectedWidth, tc.expectedHeight,
+					paddle.X, paddle.Y, paddle.Width, paddle.Height,
+				)
+			}
+		})
+	}
+}
+
+func TestPaddle_Move_StaysInsideCanvas(t *testing.T) {
+	for _, index := range []int{0, 1, 2, 3} {
+		for _, direction := range []string{"left", "right"} {
+			paddle := NewPaddle(NewPaddleChannel(), utils.CanvasSize, index)
+			paddle.Velocity = 7
+			for step := 0; step < utils.CanvasSize; step++ {
+				paddle.Direction = direction
+				paddle.Move()
+				if paddle.X < 0 || paddle.Y < 0 || paddle.X+paddle.Width > utils.CanvasSize || paddle.Y+paddle.Height > utils.CanvasSize {
+					t.Fatalf("Paddle %d moving %s left the canvas at (%d, %d)", index, direction, paddle.X, paddle.Y)
+				}
+			}
+			if paddle.Direction != "" {
+				t.Errorf("Expected paddle %d moving %s to stop at the wall, got direction %q", index, direction, paddle.Direction)
+			}
+		}
+	}
+}
+
+func TestPaddle_Dash(t *testing.T) {
+	paddle := Paddle{Index: 0, X: 0, Y: 100, Width: 10, Height: 60, Velocity: 5, Direction: "right", canvasSize: 300}
+	paddle.dash = PaddleDash{Factor: 2, Duration: time.Minute, Cooldown: time.Hour}
+
+	_, err := paddle.SetDirection([]byte(`{"direction":"Dash"}`), 0)
+	if err != nil {
+		t.Fatalf("SetDirection returned error %v", err)
+	}
+	if !paddle.Dashing || paddle.Direction != "right" {
+		t.Fatalf("Expected the paddle to dash while keeping its direction, got dashing %v direction %q", paddle.Dashing, paddle.Direction)
+	}
+
+	paddle.Move()
+	if paddle.Y != 110 {
+		t.Errorf("Expected a dashing paddle to move twice as fast to y 110, got %d", paddle.Y)
+	}
+
+	if paddle.Dash(time.Now()) {
+		t.Errorf("Expected a second dash during the cooldown to be ignored")
+	}
+
+	paddle.dashEndsAt = time.Now().Add(-time.Second)
+	paddle.Move()
+	if paddle.Dashing || paddle.Y != 115 {
+		t.Errorf("Expected the dash to wear off and the paddle to move normally to y 115, got dashing %v y %d", paddle.Dashing, paddle.Y)
+	}
+}
+
+func TestPaddle_Dash_Disabled(t *testing.T) {
+	paddle := Paddle{Velocity: 5}
+	if paddle.Dash(time.Now()) || paddle.Dashing {
+		t.Errorf("Expected dashes to be disabled without a dash factor")
+	}
+}
+
+func TestPaddle_Move_Acceleration(t *testing.T) {
+	paddle := Paddle{Index: 0, X: 0, Y: 100, Width: 10, Height: 60, Velocity: 4, Direction: "right", acceleration: 1.5, canvasSize: 300}
+
+	positions := []int{}
+	for i := 0; i < 4; i++ {
+		paddle.Move()
+		positions = append(positions, paddle.Y)
+	}
+	paddle.Direction = ""
+	for i := 0; i < 3; i++ {
+		paddle.Move()
+		positions = append(positions, paddle.Y)
+	}
+
+	expected := []int{102, 105, 109, 113, 116, 117, 117}
+	if !reflect.DeepEqual(positions, expected) {
+		t.Errorf("Expected the paddle to ease in and out along %v, got %v", expected, positions)
+	}
+	if paddle.CurrentVelocity != 0 {
+		t.Errorf("Expected the paddle to come to a stop, got velocity %f", paddle.CurrentVelocity)
+	}
+}
+
+func TestPaddle_SetDirection_Buffered(t *testing.T) {
+	paddle := Paddle{Index: 0, X: 0, Y: 100, Width: 10, Height: 60, Velocity: 4, canvasSize: 300, inputs: make(chan paddleInput, 4)}
+	for _, key := range []string{"ArrowLeft", "ArrowRight", "ArrowLeft", "Stop"} {
+		_, err := paddle.SetDirection([]byte(`{"direction":"`+key+`"}`), 0)
+		if err != nil {
+			t.Fatalf("Expected the direction to be accepted, got %v", err)
+		}
+	}
+	if paddle.Direction != "" {
+		t.Errorf("Expected buffered taps to wait for the next tick, got direction %q", paddle.Direction)
+	}
+
+	positions := []int{}
+	for i := 0; i < 5; i++ {
+		paddle.Move()
+		positions = append(positions, paddle.Y)
+	}
+	expected := []int{96, 100, 96, 96, 96}
+	if !reflect.DeepEqual(positions, expected) {
+		t.Errorf("Expected every tap to nudge the paddle along %v, got %v", expected, positions)
+	}
+}
+
+func TestPaddle_SetDirection_BufferFull(t *testing.T) {
+	paddle := Paddle{inputs: make(chan paddleInput, 2)}
+	for _, key := range []string{"ArrowLeft", "ArrowRight", "Stop"} {
+		paddle.SetDirection([]byte(`{"direction":"`+key+`"}`), 0)
+	}
+	paddle.applyQueuedDirection()
+	if paddle.Direction != "right" {
+		t.Errorf("Expected a full buffer to drop its oldest tap, got %q", paddle.Direction)
+	}
+	paddle.applyQueuedDirection()
+	if paddle.Direction != "" {
+		t.Errorf("Expected the latest tap to be kept, got %q", paddle.Direction)
+	}
+}
+
+func TestLagCompensationTicks(t *testing.T) {
+	window := 100 * time.Millisecond
+	testCases := []struct {
+		name     string
+		lag      time.Duration
+		window   time.Duration
+		expected int
+	}{
+		{"unknown lag", 0, window, 0},
+		{"disabled", 3 * utils.Period, 0, 0},
+		{"within the window", 3 * utils.Period, window, 3},
+		{"partial tick", utils.Period / 2, window, 0},
+		{"beyond the window", window + time.Second, window, int(window / utils.Period)},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ticks := lagCompensationTicks(testCase.lag, testCase.window)
+			if ticks != testCase.expected {
+				t.Errorf("Expected %d ticks, got %d", testCase.expected, ticks)
+			}
+		})
+	}
+}
+
+func TestPaddle_SetDirection_LagCompensation(t *testing.T) {
+	paddle := Paddle{Index: 0, X: 0, Y: 100, Width: 10, Height: 60, Velocity: 4, Direction: "left", canvasSize: 300, maxLagCompensation: time.Second}
+	for i := 0; i < 3; i++ {
+		paddle.Move()
+	}
+	if paddle.Y != 88 {
+		t.Fatalf("Expected the paddle to move left first, got y %d", paddle.Y)
+	}
+
+	lag := 2*utils.Period + utils.Period/2
+	_, err := paddle.SetDirection([]byte(`{"direction":"ArrowRight"}`), lag)
+	if err != nil {
+		t.Fatalf("Expected the direction to be accepted, got %v", err)
+	}
+	paddle.Move()
+	if paddle.Y != 108 {
+		t.Errorf("Expected the late input to replace the two ticks moved left, got y %d", paddle.Y)
+	}
+
+	_, err = paddle.SetDirection([]byte(`{"direction":"ArrowLeft"}`), lag)
+	if err != nil {
+		t.Fatalf("Expected the direction to be accepted, got %v", err)
+	}
+	paddle.Move()
+	if paddle.Y != 104 {
+		t.Errorf("Expected a single catch-up per window, got y %d", paddle.Y)
+	}
+}
+
+func TestPaddle_LagCompensation_SpeedLimit(t *testing.T) {
+	for _, everyInput := range []bool{false, true} {
+		paddle := Paddle{Index: 0, X: 0, Y: 5000, Width: 10, Height: 60, Velocity: 4, canvasSize: 10000, maxLagCompensation: 100 * time.Millisecond}
+		ticks := 100
+		for i := 0; i < ticks; i++ {
+			//INFO Even when every input gets a catch-up the rewind keeps the paddle on its own track
+			if everyInput {
+				paddle.lastCatchUpAt = time.Time{}
+			}
+			_, err := paddle.SetDirection([]byte(`{"direction":"ArrowRight"}`), time.Second)
+			if err != nil {
+				t.Fatalf("Expected the direction to be accepted, got %v", err)
+			}
+			paddle.Move()
+		}
+		if moved := paddle.Y - 5000; moved > paddle.Velocity*ticks {
+			t.Errorf("Expected at most %d per tick on average, moved %d in %d ticks with a catch-up for every input %v", paddle.Velocity, moved, ticks, everyInput)
+		}
+	}
+}
@@ -1,8 +1,15 @@
 package game
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	mathrand "math/rand"
+	"net"
+	"strings"
+	"time"
 
 	"github.com/lguibr/pongo/utils"
 	"golang.org/x/net/websocket"
@@ -14,32 +21,79 @@ type PlayerConnectMessage struct {
 	PlayerPayload *Player
 }
 type PlayerDisconnectMessage struct{}
+type PlayerReconnectMessage struct {
+	Close func()
+}
+type PlayerGraceExpiredMessage struct{}
+type PlayerFireMessage struct{}
 type PlayerScore struct {
 	Score int
 }
 
 type Player struct {
-	Index   int     `json:"index"`
-	Id      string  `json:"id"`
-	Canvas  *Canvas `json:"canvas"`
-	Color   [3]int  `json:"color"`
-	Score   int     `json:"score"`
-	channel chan PlayerMessage
+	Index          int     `json:"index"`
+	Id             string  `json:"id"`
+	Name           string  `json:"name"`
+	Canvas         *Canvas `json:"canvas"`
+	Color          [3]int  `json:"color"`
+	Score          int     `json:"score"`
+	Connected      bool    `json:"connected"`
+	LaserCharges   int     `json:"laserCharges"`
+	Afk            bool    `json:"afk"`
+	channel        chan PlayerMessage
+	reconnectToken string
+	reconnectTimer Timer
+	//INFO Magnet power-ups currently active for the player
+	magnets int
+	resync  chan struct{}
+	//INFO Stable id the client sends on every connection, empty when it doesn't
+	clientId string
+	//INFO Unix nanoseconds of the last move or shot, written by the input reader and read by the game
+	lastInput int64
+}
+
+type Heartbeat struct {
+	MessageType string `json:"messageType"`
+}
+
+type PlayerAssignment struct {
+	MessageType    string `json:"messageType"`
+	Index          int    `json:"index"`
+	ReconnectToken string `json:"reconnectToken"`
 }
 
 func NewPlayerChannel() chan PlayerMessage {
 	return make(chan PlayerMessage)
 }
 
-func NewPlayer(canvas *Canvas, index int, channel chan PlayerMessage) *Player {
+func NewPlayer(canvas *Canvas, index int, channel chan PlayerMessage, random *mathrand.Rand) *Player {
 	return &Player{
-		Index:   index,
-		Id:      "player" + fmt.Sprint(index),
-		Canvas:  canvas,
-		Color:   utils.NewRandomColor(),
-		channel: channel,
-		Score:   utils.InitialScore,
+		Index:          index,
+		Id:             "player" + fmt.Sprint(index),
+		Canvas:         canvas,
+		Color:          utils.NewRandomColor(random),
+		channel:        channel,
+		Score:          utils.InitialScore,
+		reconnectToken: newReconnectToken(),
+		resync:         make(chan struct{}, 1),
+	}
+}
+
+func SanitizePlayerName(name string) string {
+	name = strings.TrimSpace(name)
+	runes := []rune(name)
+	if len(runes) > utils.MaxPlayerNameLength {
+		runes = runes[:utils.MaxPlayerNameLength]
 	}
+	return string(runes)
+}
+
+func newReconnectToken() string {
+	buffer := make([]byte, 16)
+	if _, err := rand.Read(buffer); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buffer)
 }
 
 func (player *Player) Connect() {
@@ -50,24 +104,167 @@ func (player *Player) Disconnect() {
 	player.channel <- PlayerDisconnectMessage{}
 }
 
-func (player *Player) ReadInput(ws *websocket.Conn, paddleChannel chan PaddleMessage) {
+func (player *Player) Reconnect(close func()) {
+	player.channel <- PlayerReconnectMessage{Close: close}
+}
+
+func (player *Player) StartReconnectGracePeriod(clock Clock, gracePeriod time.Duration) {
+	player.Connected = false
+	player.reconnectTimer = clock.AfterFunc(gracePeriod, func() {
+		player.channel <- PlayerGraceExpiredMessage{}
+	})
+}
+
+func (player *Player) StopReconnectGracePeriod() {
+	if player.reconnectTimer != nil {
+		player.reconnectTimer.Stop()
+		player.reconnectTimer = nil
+	}
+	player.Connected = true
+}
+
+func (player *Player) WriteAssignment(ws *websocket.Conn, codec Codec) error {
+	assignment := PlayerAssignment{MessageType: "assignment", Index: player.Index, ReconnectToken: player.reconnectToken}
+	data, err := codec.Marshal(assignment)
+	if err != nil {
+		return err
+	}
+	_, err = ws.Write(data)
+	return err
+}
+
+// INFO Pings the client every interval until the connection stops accepting writes
+func (player *Player) Heartbeat(ws *websocket.Conn, codec Codec, clock Clock, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ping, err := codec.Marshal(Heartbeat{MessageType: "ping"})
+	if err != nil {
+		utils.LogError("Error marshalling ping", "err", err)
+		return
+	}
+	ticker := clock.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C() {
+		_, err := ws.Write(ping)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func IsPong(message []byte) bool {
+	heartbeat := Heartbeat{}
+	err := json.Unmarshal(message, &heartbeat)
+	return err == nil && heartbeat.MessageType == "pong"
+}
+
+// INFO Clients that lost track of the game ask for the full state with {"direction":"resync"}
+func IsResync(message []byte) bool {
+	direction := Direction{}
+	err := json.Unmarshal(message, &direction)
+	return err == nil && direction.Direction == "resync"
+}
+
+func IsFire(message []byte) bool {
+	direction := Direction{}
+	err := json.Unmarshal(message, &direction)
+	return err == nil && direction.Direction == "Fire"
+}
+
+// INFO Spaces inputs at least interval apart, a zero interval lets everything through
+type InputLimiter struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func NewInputLimiter(maxInputsPerSecond int) *InputLimiter {
+	if maxInputsPerSecond <= 0 {
+		return &InputLimiter{}
+	}
+	return &InputLimiter{interval: time.Second / time.Duration(maxInputsPerSecond)}
+}
+
+// INFO Reserves the next input slot and returns how long to wait for it
+func (limiter *InputLimiter) Reserve(now time.Time) time.Duration {
+	if limiter.interval <= 0 {
+		return 0
+	}
+	if now.After(limiter.next) {
+		limiter.next = now
+	}
+	wait := limiter.next.Sub(now)
+	limiter.next = limiter.next.Add(limiter.interval)
+	return wait
+}
+
+// INFO Forwards inputs at the limiter's pace, inputs arriving faster overwrite the one still waiting
+func ForwardLatestInput(latest chan PaddleDirectionMessage, paddleChannel chan PaddleMessage, limiter *InputLimiter) {
+	for direction := range latest {
+		time.Sleep(limiter.Reserve(time.Now()))
+		paddleChannel <- direction
+	}
+}
+
+func (player *Player) ReadInput(ws *websocket.Conn, paddleChannel chan PaddleMessage, clockSync *ClockSync, pongTimeout time.Duration, maxInputsPerSecond int) {
+	latest := make(chan PaddleDirectionMessage, 1)
+	go ForwardLatestInput(latest, paddleChannel, NewInputLimiter(maxInputsPerSecond))
 	defer func() {
+		close(latest)
 		player.Disconnect()
 	}()
 
 	for {
+		//INFO Any message, pongs included, proves the client is alive until the next deadline
+		if pongTimeout > 0 {
+			if err := ws.SetReadDeadline(time.Now().Add(pongTimeout)); err != nil {
+				utils.LogError("Error setting read deadline", "player", player.Index, "err", err)
+				return
+			}
+		}
 		buffer := make([]byte, 1024)
 		size, err := ws.Read(buffer)
 		if err != nil {
-			fmt.Println("EOFError reading from client:", err)
+			utils.LogDebug("Error reading from client", "player", player.Index, "err", err)
 			if err == io.EOF {
-				fmt.Println("Connection closed by the client:", err)
+				utils.LogInfo("Connection closed by the client", "player", player.Index)
 				return
 			}
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				utils.LogInfo("Client missed its heartbeat", "player", player.Index, "err", err)
+				return
+			}
+			//INFO A connection closed by the server, like a slow client being evicted, fails every read after this one
+			utils.LogInfo("Stopped reading from client", "player", player.Index, "err", err)
+			return
+		}
+		if IsPong(buffer[:size]) {
 			continue
 		}
-		//Send I/O message to change the paddle direction
+		if unixNanos, ok := ServerTimeEcho(buffer[:size]); ok {
+			clockSync.Echo(unixNanos)
+			continue
+		}
+		//INFO Shots go straight to the game, coalescing them with movement would drop them
+		if IsFire(buffer[:size]) {
+			player.touchInput(time.Now())
+			player.channel <- PlayerFireMessage{}
+			continue
+		}
+		if IsResync(buffer[:size]) {
+			select {
+			case player.resync <- struct{}{}:
+			default:
+			}
+			continue
+		}
+		//Send I/O message to change the paddle direction, replacing any input still waiting to be forwarded
 		newDirection := buffer[:size]
-		paddleChannel <- PaddleDirectionMessage{Direction: newDirection}
+		player.touchInput(time.Now())
+		select {
+		case <-latest:
+		default:
+		}
+		latest <- PaddleDirectionMessage{Direction: newDirection, Lag: clockSync.Lag()}
 	}
 }
@@ -2,9 +2,12 @@ package game
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/lguibr/pongo/utils"
+	"golang.org/x/net/websocket"
 )
 
 func TestNewPlayer(t *testing.T) {
@@ -17,7 +20,7 @@ func TestNewPlayer(t *testing.T) {
 	canvasSize := 800
 	canvas := &Canvas{Width: canvasSize, Height: canvasSize}
 
-	color := utils.NewRandomColor()
+	color := utils.NewRandomColor(utils.NewRandom(1))
 	testCases := []NewPlayerTestCase{
 		{
 			canvas: canvas,
@@ -44,14 +47,254 @@ func TestNewPlayer(t *testing.T) {
 	}
 
 	for _, test := range testCases {
-		result := NewPlayer(test.canvas, test.index, make(chan PlayerMessage))
+		result := NewPlayer(test.canvas, test.index, make(chan PlayerMessage), utils.NewRandom(1))
 
 		//INFO Can't compare pointers
 		result.Color = test.expectedPlayer.Color
 		result.channel = test.expectedPlayer.channel
+		result.reconnectToken = test.expectedPlayer.reconnectToken
+		if result.resync == nil {
+			t.Errorf("Expected player %d to have a resync channel", test.index)
+		}
+		result.resync = nil
 
 		if !reflect.DeepEqual(result, test.expectedPlayer) {
 			t.Errorf("Expected player %v, got \n%v", test.expectedPlayer, result)
 		}
 	}
 }
+
+func TestNewPlayer_ReconnectToken(t *testing.T) {
+	first := NewPlayer(&Canvas{}, 0, NewPlayerChannel(), utils.NewRandom(1))
+	second := NewPlayer(&Canvas{}, 1, NewPlayerChannel(), utils.NewRandom(1))
+	if first.reconnectToken == "" {
+		t.Errorf("Expected player to have a reconnect token")
+	}
+	if first.reconnectToken == second.reconnectToken {
+		t.Errorf("Expected reconnect tokens to be unique, got %s twice", first.reconnectToken)
+	}
+}
+
+func TestPlayer_ReconnectGracePeriod(t *testing.T) {
+	t.Run("Expires without reconnect", func(t *testing.T) {
+		clock := NewManualClock(time.Unix(100, 0))
+		player := &Player{Connected: true, channel: make(chan PlayerMessage, 1)}
+		player.StartReconnectGracePeriod(clock, 10*time.Second)
+		if player.Connected {
+			t.Errorf("Expected player to be disconnected during the grace period")
+		}
+		clock.Advance(9 * time.Second)
+		if len(player.channel) != 0 {
+			t.Errorf("Expected the grace period not to expire early")
+		}
+		clock.Advance(time.Second)
+		select {
+		case message := <-player.channel:
+			if _, ok := message.(PlayerGraceExpiredMessage); !ok {
+				t.Errorf("Expected PlayerGraceExpiredMessage, got %T", message)
+			}
+		default:
+			t.Errorf("Expected grace period to expire")
+		}
+	})
+	t.Run("Stopped by reconnect", func(t *testing.T) {
+		clock := NewManualClock(time.Unix(100, 0))
+		player := &Player{Connected: true, channel: make(chan PlayerMessage, 1)}
+		player.StartReconnectGracePeriod(clock, 10*time.Second)
+		player.StopReconnectGracePeriod()
+		if !player.Connected {
+			t.Errorf("Expected player to be connected after reconnect")
+		}
+		clock.Advance(time.Minute)
+		select {
+		case message := <-player.channel:
+			t.Errorf("Expected no message after reconnect, got %T", message)
+		default:
+		}
+	})
+}
+
+func TestSanitizePlayerName(t *testing.T) {
+	testCases := map[string]string{
+		"  alice  ":                   "alice",
+		"":                            "",
+		"averyveryverylongplayername": "averyveryverylon",
+		"ãéíõúçãéíõúçãéíõúç":          "ãéíõúçãéíõúçãéíõ",
+	}
+	for input, expected := range testCases {
+		result := SanitizePlayerName(input)
+		if result != expected {
+			t.Errorf("SanitizePlayerName(%s) = %s, want %s", input, result, expected)
+		}
+	}
+}
+
+func TestIsResync(t *testing.T) {
+	testCases := []struct {
+		message  string
+		expected bool
+	}{
+		{`{"direction":"resync"}`, true},
+		{`{"direction":"Fire"}`, false},
+		{`not json`, false},
+	}
+	for _, tc := range testCases {
+		if result := IsResync([]byte(tc.message)); result != tc.expected {
+			t.Errorf("IsResync(%s) = %v, want %v", tc.message, result, tc.expected)
+		}
+	}
+}
+
+func TestIsFire(t *testing.T) {
+	testCases := []struct {
+		message  string
+		expected bool
+	}{
+		{`{"direction":"Fire"}`, true},
+		{`{"direction":"ArrowLeft"}`, false},
+		{`{"messageType":"pong"}`, false},
+		{`not json`, false},
+	}
+	for _, tc := range testCases {
+		if result := IsFire([]byte(tc.message)); result != tc.expected {
+			t.Errorf("IsFire(%s) = %v, want %v", tc.message, result, tc.expected)
+		}
+	}
+}
+
+func TestIsPong(t *testing.T) {
+	testCases := []struct {
+		message  string
+		expected bool
+	}{
+		{`{"messageType":"pong"}`, true},
+		{`{"messageType":"ping"}`, false},
+		{`{"direction":"ArrowLeft"}`, false},
+		{`not json`, false},
+	}
+	for _, tc := range testCases {
+		if result := IsPong([]byte(tc.message)); result != tc.expected {
+			t.Errorf("IsPong(%s) = %v, want %v", tc.message, result, tc.expected)
+		}
+	}
+}
+
+func TestPlayer_Heartbeat(t *testing.T) {
+	player := &Player{channel: make(chan PlayerMessage, 1)}
+	paddleChannel := make(chan PaddleMessage, 10)
+	client := ConnectScriptedClient(t, func(ws *websocket.Conn) {
+		go player.Heartbeat(ws, JSONCodec, realClock{}, 10*time.Millisecond)
+		player.ReadInput(ws, paddleChannel, nil, 100*time.Millisecond, 0)
+	})
+
+	heartbeat := Heartbeat{}
+	if err := client.Receive(&heartbeat); err != nil || heartbeat.MessageType != "ping" {
+		t.Fatalf("Expected a ping from the server, got %+v (%v)", heartbeat, err)
+	}
+	client.SendJSON(Heartbeat{MessageType: "pong"})
+
+	//INFO The client stops answering, so the server should give up on it
+	select {
+	case message := <-player.channel:
+		if _, ok := message.(PlayerDisconnectMessage); !ok {
+			t.Errorf("Expected a disconnect message, got %T", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected a silent client to be disconnected")
+	}
+	if len(paddleChannel) != 0 {
+		t.Errorf("Expected pongs not to reach the paddle")
+	}
+}
+
+func TestPlayer_WriteAssignment(t *testing.T) {
+	player := &Player{Index: 2, reconnectToken: "token"}
+	client := ConnectScriptedClient(t, func(ws *websocket.Conn) {
+		if err := player.WriteAssignment(ws, JSONCodec); err != nil {
+			t.Errorf("Error writing the assignment: %v", err)
+		}
+		DiscardInput(ws, func() {})
+	})
+
+	data := []byte{}
+	if err := websocket.Message.Receive(client.ws, &data); err != nil {
+		t.Fatalf("Expected the assignment, got %v", err)
+	}
+	decoded, err := DecodeMessage(JSONCodec, data)
+	assignment, ok := decoded.(*PlayerAssignment)
+	if err != nil || !ok || assignment.Index != 2 || assignment.ReconnectToken != "token" {
+		t.Errorf("Expected the assignment to decode by its message type, got %T %+v %v", decoded, decoded, err)
+	}
+}
+
+func TestPlayer_ReadInput_ClosedByServer(t *testing.T) {
+	player := &Player{channel: make(chan PlayerMessage, 1)}
+	ConnectScriptedClient(t, func(ws *websocket.Conn) {
+		//INFO Without a pong timeout no read deadline would ever end the reader
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			ws.Close()
+		}()
+		player.ReadInput(ws, make(chan PaddleMessage, 1), nil, 0, 0)
+	})
+
+	select {
+	case message := <-player.channel:
+		if _, ok := message.(PlayerDisconnectMessage); !ok {
+			t.Errorf("Expected a disconnect message, got %T", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the reader to stop once the server closed the connection")
+	}
+}
+
+func TestInputLimiter_Reserve(t *testing.T) {
+	limiter := NewInputLimiter(10)
+	now := time.Now()
+
+	waits := []time.Duration{}
+	for i := 0; i < 3; i++ {
+		waits = append(waits, limiter.Reserve(now))
+	}
+	expected := []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond}
+	if !reflect.DeepEqual(waits, expected) {
+		t.Errorf("Expected waits %v, got %v", expected, waits)
+	}
+
+	if wait := limiter.Reserve(now.Add(time.Second)); wait != 0 {
+		t.Errorf("Expected no wait after a quiet second, got %v", wait)
+	}
+	if wait := NewInputLimiter(0).Reserve(now); wait != 0 {
+		t.Errorf("Expected an unlimited limiter never to wait, got %v", wait)
+	}
+}
+
+func TestPlayer_ReadInput_CoalescesInputs(t *testing.T) {
+	player := &Player{channel: make(chan PlayerMessage, 1)}
+	paddleChannel := make(chan PaddleMessage, 100)
+	client := ConnectScriptedClient(t, func(ws *websocket.Conn) {
+		player.ReadInput(ws, paddleChannel, nil, 0, 5)
+	})
+	for i := 0; i < 1000; i++ {
+		direction := "ArrowLeft"
+		if i == 999 {
+			direction = "ArrowRight"
+		}
+		client.Send(direction)
+	}
+	time.Sleep(500 * time.Millisecond)
+	client.Close()
+	<-player.channel
+
+	forwarded := []string{}
+	for len(paddleChannel) > 0 {
+		message := (<-paddleChannel).(PaddleDirectionMessage)
+		forwarded = append(forwarded, string(message.Direction))
+	}
+	if len(forwarded) == 0 || len(forwarded) > 4 {
+		t.Fatalf("Expected a handful of inputs at 5 per second, got %d", len(forwarded))
+	}
+	if !strings.Contains(forwarded[len(forwarded)-1], "ArrowRight") {
+		t.Errorf("Expected the latest input to win, got %s", forwarded[len(forwarded)-1])
+	}
+}
@@ -0,0 +1,360 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+const (
+	powerUpSpawnBall = iota
+	powerUpIncreaseMass
+	powerUpIncreaseVelocity
+	powerUpPhasing
+	powerUpStickyPaddle
+	powerUpShrinkOpponentPaddle
+	powerUpMultiball
+	powerUpSlowMotion
+	powerUpLaser
+	powerUpMagnet
+	powerUpGhost
+	numPowerUpTypes
+)
+
+const multiballArc = math.Pi / 2
+
+type StickyPaddle struct {
+	PaddlePayload *Paddle
+}
+type ResizePaddle struct {
+	PaddlePayload *Paddle
+	Length        int
+	Shrunk        bool
+}
+type SetBallVelocity struct {
+	BallPayload *Ball
+	Vx          int
+	Vy          int
+}
+type SlowBalls struct {
+	OwnerIndex int
+}
+type RestoreBallSpeeds struct {
+	Ids []int
+}
+type ScaleBallSpeed struct {
+	Ball  *Ball
+	Ratio float64
+}
+type BallGhost struct {
+	BallPayload *Ball
+	Duration    time.Duration
+}
+type ToggleMagnet struct {
+	OwnerIndex int
+	Active     bool
+}
+type ApplyMagnets struct{}
+
+// INFO Acceleration toward the owner's paddle, worked out by the game routine since the ball routine can't see the paddles
+type BallPull struct {
+	Ball *Ball
+	Ax   float64
+	Ay   float64
+}
+
+// INFO Test hook applying one power-up to a ball without rolling for it, Kind is one of utils.PowerUpNames
+type internalForcePowerUp struct {
+	BallId int
+	Kind   string
+}
+
+// INFO Power-ups are rolled by ball routines holding the state lock, so their messages are returned for the caller to send once it is released
+func (g *Game) triggerRandomPowerUp(ball *Ball) []GameMessage {
+	weights := [numPowerUpTypes]float64{}
+	for powerUp := range weights {
+		weights[powerUp] = g.config.PowerUpWeights[utils.PowerUpNames[powerUp]]
+	}
+	//INFO Pick among the remaining power-ups so the room stays under its ball cap
+	if !g.canSpawnBall() {
+		weights[powerUpSpawnBall] = 0
+		weights[powerUpMultiball] = 0
+	}
+	if ball.OwnerIndex == NoOwner {
+		for powerUp := range weights {
+			if ownerScoped(powerUp) {
+				weights[powerUp] = 0
+			}
+		}
+	}
+
+	return g.applyPowerUp(pickPowerUp(g.random, weights), ball)
+}
+
+func (g *Game) applyPowerUp(powerUp int, ball *Ball) []GameMessage {
+	playerIndex := ball.OwnerIndex
+	//INFO Nothing is picked when every power-up that could trigger weighs zero
+	if powerUp < 0 || playerIndex == NoOwner && ownerScoped(powerUp) {
+		return nil
+	}
+	g.recordEvent("powerUp", "kind", utils.PowerUpNames[powerUp], "ball", ball.Id, "player", playerIndex)
+	switch powerUp {
+	case powerUpSpawnBall:
+		return []GameMessage{g.spawnBall(ball.X, ball.Y, playerIndex)}
+	case powerUpIncreaseMass:
+		return []GameMessage{IncreaseBallMass{ball, 1}}
+	case powerUpIncreaseVelocity:
+		return []GameMessage{IncreaseBallVelocity{ball, 1.1}}
+	case powerUpPhasing:
+		return []GameMessage{BallPhasing{ball, 1}}
+	case powerUpStickyPaddle:
+		paddle := g.Paddles[playerIndex]
+		if paddle == nil {
+			return nil
+		}
+		return []GameMessage{StickyPaddle{paddle}}
+	case powerUpShrinkOpponentPaddle:
+		return g.shrinkRandomOpponentPaddle(playerIndex)
+	case powerUpMultiball:
+		return g.spawnMultiball(ball)
+	case powerUpSlowMotion:
+		return []GameMessage{SlowBalls{playerIndex}}
+	case powerUpLaser:
+		return []GameMessage{GrantLaserCharges{playerIndex, g.config.PowerUpLaserCharges}}
+	case powerUpMagnet:
+		return []GameMessage{ToggleMagnet{playerIndex, true}}
+	case powerUpGhost:
+		return []GameMessage{BallGhost{ball, g.config.PowerUpGhostDuration}}
+	}
+	return nil
+}
+
+// INFO Power-ups granted to the breaker's slot, an ownerless ball has nobody to grant them to
+func ownerScoped(powerUp int) bool {
+	switch powerUp {
+	case powerUpStickyPaddle, powerUpSlowMotion, powerUpLaser, powerUpMagnet:
+		return true
+	}
+	return false
+}
+
+func (g *Game) forcePowerUp(ballId int, kind string) {
+	powerUp := -1
+	for index, name := range utils.PowerUpNames {
+		if name == kind {
+			powerUp = index
+		}
+	}
+	var ball *Ball
+	for _, candidate := range g.Balls {
+		if candidate.Id == ballId {
+			ball = candidate
+		}
+	}
+	if powerUp < 0 || ball == nil || ball.OwnerIndex == NoOwner && ownerScoped(powerUp) {
+		utils.LogWarn("Cannot force power-up", "kind", kind, "ball", ballId)
+		return
+	}
+	g.post(g.applyPowerUp(powerUp, ball)...)
+}
+
+// INFO Slows the owner's balls down and schedules their original speed to come back, balls already slowed are left alone
+func (g *Game) SlowBalls(ownerIndex int) {
+	ids := []int{}
+	for _, ball := range g.Balls {
+		if ball.OwnerIndex != ownerIndex || ball.slowed {
+			continue
+		}
+		ball.slowed = true
+		ball.send(ScaleBallSpeed{ball, g.config.PowerUpSlowRatio})
+		ids = append(ids, ball.Id)
+	}
+	if len(ids) == 0 {
+		return
+	}
+	g.clock.AfterFunc(g.config.PowerUpSlowDuration, func() {
+		g.channel <- RestoreBallSpeeds{ids}
+	})
+}
+
+// INFO Undoes the slow down on whatever velocity slowed balls have now, balls removed meanwhile are skipped
+func (g *Game) RestoreBallSpeeds(ids []int) {
+	for _, id := range ids {
+		for _, ball := range g.Balls {
+			if ball.Id != id || !ball.slowed {
+				continue
+			}
+			ball.slowed = false
+			ball.send(ScaleBallSpeed{ball, 1 / g.config.PowerUpSlowRatio})
+		}
+	}
+}
+
+// INFO Magnets stack, each pickup keeps the player's magnet on for its own duration
+func (g *Game) ToggleMagnet(ownerIndex int, active bool) {
+	if ownerIndex < 0 || ownerIndex >= len(g.Players) || g.Players[ownerIndex] == nil {
+		return
+	}
+	player := g.Players[ownerIndex]
+	if !active {
+		if player.magnets > 0 {
+			player.magnets--
+		}
+		return
+	}
+	player.magnets++
+	g.clock.AfterFunc(g.config.PowerUpMagnetDuration, func() {
+		g.channel <- ToggleMagnet{ownerIndex, false}
+	})
+}
+
+// INFO Sends every ball on the half of an owner with an active magnet its pull for the tick, one for each substep it moves
+func (g *Game) ApplyMagnets() {
+	for _, ball := range g.Balls {
+		ax, ay, pulled := g.magnetPull(ball)
+		if !pulled {
+			continue
+		}
+		substeps := float64(utils.MaxInt(ball.substeps, 1))
+		ball.send(BallPull{ball, ax * substeps, ay * substeps})
+	}
+}
+
+// INFO A ball is pulled toward its owner's paddle while their magnet is on and the ball is on their half of the canvas
+func (g *Game) magnetPull(ball *Ball) (ax, ay float64, pulled bool) {
+	if ball.Stuck || ball.OwnerIndex < 0 || ball.OwnerIndex >= len(g.Players) {
+		return 0, 0, false
+	}
+	player, paddle := g.Players[ball.OwnerIndex], g.Paddles[ball.OwnerIndex]
+	if player == nil || paddle == nil || player.magnets == 0 {
+		return 0, 0, false
+	}
+	center := float64(utils.CanvasSize) / 2
+	paddleX, paddleY := float64(paddle.X+paddle.Width/2), float64(paddle.Y+paddle.Height/2)
+	//INFO The ball is on the owner's half when it lies on the paddle's side of the canvas center
+	if (float64(ball.X)-center)*(paddleX-center)+(float64(ball.Y)-center)*(paddleY-center) <= 0 {
+		return 0, 0, false
+	}
+	dx, dy := paddleX-float64(ball.X), paddleY-float64(ball.Y)
+	distance := math.Hypot(dx, dy)
+	if distance == 0 {
+		return 0, 0, false
+	}
+	strength := g.config.PowerUpMagnetStrength
+	return dx / distance * strength, dy / distance * strength, true
+}
+
+// INFO Picks a power-up with odds proportional to its weight, -1 when every weight is zero
+func pickPowerUp(random *rand.Rand, weights [numPowerUpTypes]float64) int {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+	if total <= 0 {
+		return -1
+	}
+	roll := random.Float64() * total
+	for powerUp, weight := range weights {
+		if roll < weight {
+			return powerUp
+		}
+		roll -= weight
+	}
+	//INFO Floating point leftovers land on the last power-up that can trigger
+	for powerUp := numPowerUpTypes - 1; powerUp >= 0; powerUp-- {
+		if weights[powerUp] > 0 {
+			return powerUp
+		}
+	}
+	return -1
+}
+
+// INFO Players already owning MaxOwnedBalls get an ownerless ball instead, so nobody can hoard them
+func (g *Game) spawnBall(x, y, ownerIndex int) AddBall {
+	ball := NewBall(
+		NewBallChannel(),
+		x,
+		y,
+		utils.BallSize,
+		utils.CanvasSize,
+		ownerIndex,
+		g.NextBallId(),
+		g.random,
+	)
+	return AddBall{ball, g.random.Intn(2) + 1}
+}
+
+// INFO Spreads the new balls evenly across an arc centered on the breaking ball's direction
+func (g *Game) spawnMultiball(source *Ball) []GameMessage {
+	count := g.config.PowerUpMultiballCount
+	if g.config.MaxBallsPerRoom > 0 && count > g.config.MaxBallsPerRoom-len(g.Balls) {
+		count = g.config.MaxBallsPerRoom - len(g.Balls)
+	}
+	if count <= 0 {
+		return nil
+	}
+
+	messages := []GameMessage{}
+	speed := math.Max(math.Hypot(float64(source.Vx), float64(source.Vy)), utils.MinVelocity)
+	direction := math.Atan2(float64(source.Vy), float64(source.Vx))
+	for i := 0; i < count; i++ {
+		angle := direction
+		if count > 1 {
+			angle += multiballArc * (float64(i)/float64(count-1) - 0.5)
+		}
+		spawn := g.spawnBall(source.X, source.Y, source.OwnerIndex)
+		messages = append(messages, spawn, SetBallVelocity{
+			spawn.BallPayload,
+			int(math.Round(speed * math.Cos(angle))),
+			int(math.Round(speed * math.Sin(angle))),
+		})
+	}
+	return messages
+}
+
+func (g *Game) OwnedBalls(ownerIndex int) int {
+	count := 0
+	for _, ball := range g.Balls {
+		if ball.OwnerIndex == ownerIndex {
+			count++
+		}
+	}
+	return count
+}
+
+func (g *Game) canSpawnBall() bool {
+	return g.config.MaxBallsPerRoom <= 0 || len(g.Balls) < g.config.MaxBallsPerRoom
+}
+
+func (g *Game) shrinkRandomOpponentPaddle(playerIndex int) []GameMessage {
+	opponents := []*Paddle{}
+	for index, paddle := range g.Paddles {
+		player := g.Players[index]
+		if index == playerIndex || paddle == nil || player == nil || !player.Connected {
+			continue
+		}
+		opponents = append(opponents, paddle)
+	}
+	if len(opponents) == 0 {
+		return nil
+	}
+
+	paddle := opponents[g.random.Intn(len(opponents))]
+	//INFO A paddle shrunk again shrinks from and comes back to the length it had before the first shrink
+	length := paddle.Length()
+	if paddle.shrunk {
+		length = paddle.unshrunkLength
+	}
+	paddle.unshrunkLength = length
+	shrunkLength := int(float64(length) * g.config.PowerUpShrinkRatio)
+	//INFO A paddle keeps a single restore timer, shrinking it again restarts the countdown
+	if paddle.shrinkTimer != nil {
+		paddle.shrinkTimer.Stop()
+	}
+	paddle.shrinkTimer = g.clock.AfterFunc(g.config.PowerUpShrinkDuration, func() {
+		g.channel <- ResizePaddle{paddle, length, false}
+	})
+	return []GameMessage{ResizePaddle{paddle, shrunkLength, true}}
+}
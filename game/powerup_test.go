@@ -0,0 +1,424 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestGame_ShrinkRandomOpponentPaddle(t *testing.T) {
+	game := StartGame()
+	clock := NewManualClock(time.Unix(100, 0))
+	game.SetClock(clock)
+	game.config.PowerUpShrinkRatio = 0.5
+	game.config.PowerUpShrinkDuration = time.Second
+	game.channel = make(chan GameMessage, 2)
+	game.Players[0] = &Player{Index: 0, Connected: true}
+	game.Paddles[0] = NewPaddle(NewPaddleChannel(), utils.CanvasSize, 0)
+	game.Players[2] = &Player{Index: 2, Connected: true}
+	game.Paddles[2] = NewPaddle(NewPaddleChannel(), utils.CanvasSize, 2)
+	game.Players[3] = &Player{Index: 3, Connected: false}
+	game.Paddles[3] = NewPaddle(NewPaddleChannel(), utils.CanvasSize, 3)
+	//INFO Rubber banding grew the paddle before the shrink
+	grown := utils.PaddleLength + 40
+	game.Paddles[2].Resize(grown)
+
+	shrink := game.shrinkRandomOpponentPaddle(0)[0].(ResizePaddle)
+	if shrink.PaddlePayload != game.Paddles[2] {
+		t.Errorf("Expected the only connected opponent's paddle to shrink, got paddle %d", shrink.PaddlePayload.Index)
+	}
+	if shrink.Length != grown/2 {
+		t.Errorf("Expected paddle to shrink to %d, got %d", grown/2, shrink.Length)
+	}
+	game.handleGameMessage(shrink)
+
+	clock.Advance(time.Second / 2)
+	again := game.shrinkRandomOpponentPaddle(0)[0].(ResizePaddle)
+	if again.Length != grown/2 {
+		t.Errorf("Expected a shrunk paddle to shrink from its length before the first shrink, got %d", again.Length)
+	}
+
+	//INFO The second shrink restarted the countdown instead of adding a restore of its own
+	clock.Advance(time.Second * 9 / 10)
+	if len(game.channel) != 0 {
+		t.Fatalf("Expected the first shrink's restore to be cancelled by the second shrink")
+	}
+	clock.Advance(time.Second / 10)
+	restore := (<-game.channel).(ResizePaddle)
+	if restore.PaddlePayload != game.Paddles[2] || restore.Length != grown || restore.Shrunk {
+		t.Errorf("Expected paddle 2 to be restored to its length before the shrink %d, got paddle %d with %d", grown, restore.PaddlePayload.Index, restore.Length)
+	}
+	clock.Advance(time.Second)
+	if len(game.channel) != 0 {
+		t.Errorf("Expected a single restore per paddle, got %d more", len(game.channel))
+	}
+}
+
+func TestGame_ShrinkRandomOpponentPaddle_NoOpponent(t *testing.T) {
+	game := StartGame()
+	game.channel = make(chan GameMessage, 2)
+	game.Players[0] = &Player{Index: 0, Connected: true}
+	game.Paddles[0] = NewPaddle(NewPaddleChannel(), utils.CanvasSize, 0)
+
+	if messages := game.shrinkRandomOpponentPaddle(0); len(messages) != 0 {
+		t.Errorf("Expected no paddle to be resized without opponents")
+	}
+}
+
+func TestGame_TriggerRandomPowerUp_MaxBallsPerRoom(t *testing.T) {
+	game := StartGame()
+	game.config.MaxBallsPerRoom = 1
+	ball := NewBall(NewBallChannel(), 100, 100, utils.BallSize, utils.CanvasSize, 0, 1, utils.NewRandom(1))
+	game.Balls = []*Ball{ball}
+
+	for i := 0; i < 100; i++ {
+		for _, message := range game.triggerRandomPowerUp(ball) {
+			if _, ok := message.(AddBall); ok {
+				t.Fatalf("Expected no ball to spawn once the room is at its ball cap")
+			}
+		}
+	}
+}
+
+func TestGame_TriggerRandomPowerUp_NoWeights(t *testing.T) {
+	ball := NewBall(NewBallChannel(), 100, 100, utils.BallSize, utils.CanvasSize, 0, 1, utils.NewRandom(1))
+	testCases := []struct {
+		name            string
+		weights         map[string]float64
+		maxBallsPerRoom int
+	}{
+		{"no weights", map[string]float64{}, 0},
+		{"zero weights", map[string]float64{"stickyPaddle": 0, "ghost": 0}, 0},
+		{"only spawns at the ball cap", map[string]float64{"spawnBall": 1, "multiball": 1}, 1},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			game := StartGame()
+			game.config.PowerUpWeights = testCase.weights
+			game.config.MaxBallsPerRoom = testCase.maxBallsPerRoom
+			game.Balls = []*Ball{ball}
+
+			if messages := game.triggerRandomPowerUp(ball); len(messages) != 0 {
+				t.Errorf("Expected no power-up without weights, got %+v", messages)
+			}
+			if len(game.Events()) != 0 {
+				t.Errorf("Expected no power-up event, got %+v", game.Events())
+			}
+		})
+	}
+}
+
+func TestGame_SpawnMultiball(t *testing.T) {
+	game := StartGame()
+	game.config.PowerUpMultiballCount = 3
+	game.config.MaxBallsPerRoom = 0
+	source := &Ball{X: 100, Y: 200, Vx: 5, Vy: 0, OwnerIndex: 2}
+
+	velocities := [][2]int{}
+	for _, message := range game.spawnMultiball(source) {
+		switch message := message.(type) {
+		case AddBall:
+			if message.BallPayload.X != 100 || message.BallPayload.Y != 200 || message.BallPayload.OwnerIndex != 2 {
+				t.Errorf("Expected ball spawned at the brick and owned by player 2, got %+v", message.BallPayload)
+			}
+		case SetBallVelocity:
+			velocities = append(velocities, [2]int{message.Vx, message.Vy})
+		}
+	}
+	expected := [][2]int{{4, -4}, {5, 0}, {4, 4}}
+	if !reflect.DeepEqual(velocities, expected) {
+		t.Errorf("Expected fan velocities %v, got %v", expected, velocities)
+	}
+}
+
+func TestGame_SpawnMultiball_MaxBallsPerRoom(t *testing.T) {
+	game := StartGame()
+	game.config.PowerUpMultiballCount = 3
+	game.config.MaxBallsPerRoom = 2
+	source := &Ball{X: 100, Y: 200, Vx: 5, Vy: 0}
+	game.Balls = []*Ball{source}
+
+	spawned := 0
+	for _, message := range game.spawnMultiball(source) {
+		if _, ok := message.(AddBall); ok {
+			spawned++
+		}
+	}
+	if spawned != 1 {
+		t.Errorf("Expected only 1 ball to spawn under the cap, got %d", spawned)
+	}
+}
+
+func TestGame_ForcePowerUp(t *testing.T) {
+	game := StartGame()
+	game.config.PowerUpMultiballCount = 2
+	game.config.MaxBallsPerRoom = 0
+	game.channel = make(chan GameMessage, 10)
+	source := &Ball{Id: 7, X: 100, Y: 200, Vx: 0, Vy: 5, OwnerIndex: 1}
+	game.Balls = []*Ball{source}
+
+	game.forcePowerUp(7, "multiball")
+
+	messages := []GameMessage{}
+	for len(messages) < 4 {
+		select {
+		case message := <-game.channel:
+			messages = append(messages, message)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the multiball power-up to spawn 2 balls, got %+v", messages)
+		}
+	}
+	velocities := [][2]int{}
+	for _, message := range messages {
+		switch message := message.(type) {
+		case AddBall:
+			if message.BallPayload.OwnerIndex != 1 {
+				t.Errorf("Expected the spawned ball to belong to player 1, got %d", message.BallPayload.OwnerIndex)
+			}
+		case SetBallVelocity:
+			velocities = append(velocities, [2]int{message.Vx, message.Vy})
+		}
+	}
+	expected := [][2]int{{4, 4}, {-4, 4}}
+	if !reflect.DeepEqual(velocities, expected) {
+		t.Errorf("Expected fan velocities %v, got %v", expected, velocities)
+	}
+}
+
+func TestGame_ForcePowerUp_Unknown(t *testing.T) {
+	game := StartGame()
+	game.channel = make(chan GameMessage, 10)
+	game.Balls = []*Ball{{Id: 7}}
+
+	game.forcePowerUp(7, "teleport")
+	game.forcePowerUp(8, "ghost")
+
+	select {
+	case message := <-game.channel:
+		t.Errorf("Expected nothing to be applied for an unknown power-up or ball, got %+v", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestGame_ForcePowerUp_Ownerless(t *testing.T) {
+	game := StartGame()
+	game.channel = make(chan GameMessage, 10)
+	game.Balls = []*Ball{{Id: 7, OwnerIndex: NoOwner}}
+
+	for _, kind := range []string{"stickyPaddle", "slowMotion", "laser", "magnet"} {
+		game.forcePowerUp(7, kind)
+	}
+
+	select {
+	case message := <-game.channel:
+		t.Errorf("Expected no owner power-up on an ownerless ball, got %+v", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if len(game.Events()) != 0 {
+		t.Errorf("Expected no power-up event, got %+v", game.Events())
+	}
+}
+
+func TestGame_TriggerRandomPowerUp_Ownerless(t *testing.T) {
+	game := StartGame()
+	game.config.PowerUpWeights = map[string]float64{"stickyPaddle": 1, "slowMotion": 1, "laser": 1, "magnet": 1, "ghost": 1}
+	ball := &Ball{Id: 1, X: 100, Y: 100, OwnerIndex: NoOwner}
+	game.Balls = []*Ball{ball}
+
+	for i := 0; i < 50; i++ {
+		for _, message := range game.triggerRandomPowerUp(ball) {
+			if _, ok := message.(BallGhost); !ok {
+				t.Fatalf("Expected only power-ups that need no owner, got %+v", message)
+			}
+		}
+	}
+	if game.applyPowerUp(powerUpStickyPaddle, ball) != nil {
+		t.Errorf("Expected a sticky paddle to be skipped for an ownerless ball")
+	}
+}
+
+func TestGame_ToggleMagnet(t *testing.T) {
+	game := StartGame()
+	game.config.PowerUpMagnetDuration = 10 * time.Millisecond
+	game.channel = make(chan GameMessage, 1)
+	game.Players[0] = &Player{Index: 0}
+
+	game.ToggleMagnet(0, true)
+	if game.Players[0].magnets != 1 {
+		t.Fatalf("Expected the magnet to be active, got %d magnets", game.Players[0].magnets)
+	}
+	select {
+	case message := <-game.channel:
+		if message != (ToggleMagnet{0, false}) {
+			t.Fatalf("Expected the magnet to be switched off, got %+v", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the magnet to expire")
+	}
+	game.ToggleMagnet(0, false)
+	game.ToggleMagnet(0, false)
+	if game.Players[0].magnets != 0 {
+		t.Errorf("Expected no active magnet, got %d", game.Players[0].magnets)
+	}
+}
+
+func TestGame_ApplyMagnets(t *testing.T) {
+	game := StartGame()
+	game.config.PowerUpMagnetStrength = 1
+	game.Players[0] = &Player{Index: 0, magnets: 1}
+	game.Paddles[0] = NewPaddle(nil, utils.CanvasSize, 0)
+	paddleY := game.Paddles[0].Y + game.Paddles[0].Height/2
+	pull := func(ball *Ball) {
+		game.Balls = []*Ball{ball}
+		game.ApplyMagnets()
+		select {
+		case message := <-ball.Channel:
+			game.handleBallMessage(message)
+		default:
+		}
+	}
+
+	near := &Ball{X: utils.CanvasSize * 3 / 4, Y: paddleY, OwnerIndex: 0, maxSpeed: 10, Channel: NewBallChannel()}
+	pull(near)
+	if near.Vx != 1 || near.Vy != 0 {
+		t.Errorf("Expected a ball on the owner's half to be pulled toward the paddle, got (%d, %d)", near.Vx, near.Vy)
+	}
+
+	substepped := &Ball{X: utils.CanvasSize * 3 / 4, Y: paddleY, OwnerIndex: 0, maxSpeed: 10, substeps: 3, Channel: NewBallChannel()}
+	pull(substepped)
+	if substepped.Vx != 3 {
+		t.Errorf("Expected a ball moving in 3 substeps to be pulled for each, got %d", substepped.Vx)
+	}
+
+	far := &Ball{X: utils.CanvasSize / 4, Y: paddleY, OwnerIndex: 0, maxSpeed: 10, Channel: NewBallChannel()}
+	pull(far)
+	if far.Vx != 0 || far.Vy != 0 {
+		t.Errorf("Expected a ball on the other half to be left alone, got (%d, %d)", far.Vx, far.Vy)
+	}
+
+	fast := &Ball{X: utils.CanvasSize * 3 / 4, Y: paddleY, Vx: 10, OwnerIndex: 0, maxSpeed: 10, Channel: NewBallChannel()}
+	pull(fast)
+	if fast.Vx != 10 {
+		t.Errorf("Expected the pull to stay under the max speed, got %d", fast.Vx)
+	}
+
+	game.Players[0].magnets = 0
+	idle := &Ball{X: utils.CanvasSize * 3 / 4, Y: paddleY, OwnerIndex: 0, maxSpeed: 10, Channel: NewBallChannel()}
+	pull(idle)
+	if idle.Vx != 0 {
+		t.Errorf("Expected no pull without an active magnet, got %d", idle.Vx)
+	}
+}
+
+func TestGame_SlowBalls(t *testing.T) {
+	game := StartGame()
+	clock := NewManualClock(time.Unix(100, 0))
+	game.SetClock(clock)
+	game.config.PowerUpSlowRatio = 0.25
+	game.config.PowerUpSlowDuration = time.Second
+	game.channel = make(chan GameMessage, 1)
+	owned := &Ball{Id: 1, OwnerIndex: 0, Vx: 8, Vy: -1, Channel: NewBallChannel()}
+	other := &Ball{Id: 2, OwnerIndex: 1, Vx: 4, Vy: -6, Channel: NewBallChannel()}
+	removed := &Ball{Id: 3, OwnerIndex: 0, Vx: 2, Vy: 2, Channel: NewBallChannel()}
+	game.Balls = []*Ball{owned, other, removed}
+
+	game.SlowBalls(0)
+	handleScale := func(ball *Ball) {
+		select {
+		case message := <-ball.Channel:
+			game.handleBallMessage(message)
+		default:
+			t.Fatalf("Expected ball %d to be sent a speed scale", ball.Id)
+		}
+	}
+	handleScale(owned)
+
+	if owned.Vx != 2 || owned.Vy != -1 {
+		t.Errorf("Expected owned ball to be slowed to (2, -1) keeping a step on each axis, got (%d, %d)", owned.Vx, owned.Vy)
+	}
+	if len(other.Channel) != 0 {
+		t.Errorf("Expected other player's ball to keep its velocity")
+	}
+
+	clock.Advance(time.Second)
+	restore := (<-game.channel).(RestoreBallSpeeds)
+	owned.Vx = -owned.Vx
+	game.Balls = []*Ball{owned, other}
+	game.RestoreBallSpeeds(restore.Ids)
+	handleScale(owned)
+
+	if owned.Vx != -8 || owned.Vy != -4 {
+		t.Errorf("Expected owned ball to be sped back up by the inverse ratio in its new direction (-8, -4), got (%d, %d)", owned.Vx, owned.Vy)
+	}
+	if owned.slowed {
+		t.Errorf("Expected owned ball to no longer be marked as slowed")
+	}
+}
+
+func TestGame_SpawnBall_MaxOwnedBalls(t *testing.T) {
+	game := StartGame()
+	game.config.MaxOwnedBalls = 2
+	game.config.MaxBallsPerRoom = 0
+	go game.ReadGameChannel()
+
+	for i := 0; i < 20; i++ {
+		game.mutex.Lock()
+		spawn := game.spawnBall(100, 100, 0)
+		game.mutex.Unlock()
+		game.channel <- spawn
+	}
+
+	snapshot, ok := game.RequestSnapshot(time.Second)
+	if !ok {
+		t.Fatalf("Expected a snapshot from the game")
+	}
+	owned, ownerless := 0, 0
+	for _, ball := range snapshot.Balls {
+		switch ball.OwnerIndex {
+		case 0:
+			owned++
+		case NoOwner:
+			ownerless++
+		}
+	}
+	if owned != 2 {
+		t.Errorf("Expected player 0 to own exactly 2 balls, got %d", owned)
+	}
+	if ownerless != 18 {
+		t.Errorf("Expected the other 18 balls to spawn ownerless, got %d", ownerless)
+	}
+}
+
+func TestPowerUpNames(t *testing.T) {
+	if len(utils.PowerUpNames) != numPowerUpTypes {
+		t.Errorf("Expected a name for each of the %d power-ups, got %d", numPowerUpTypes, len(utils.PowerUpNames))
+	}
+}
+
+func TestPickPowerUp_Weights(t *testing.T) {
+	random := utils.NewRandom(1)
+	weights := [numPowerUpTypes]float64{}
+	weights[powerUpPhasing] = 1
+	weights[powerUpLaser] = 3
+
+	counts := [numPowerUpTypes]int{}
+	for i := 0; i < 10000; i++ {
+		counts[pickPowerUp(random, weights)]++
+	}
+
+	if counts[powerUpPhasing]+counts[powerUpLaser] != 10000 {
+		t.Errorf("Expected only power-ups with a weight to be picked, got %v", counts)
+	}
+	ratio := float64(counts[powerUpLaser]) / float64(counts[powerUpPhasing])
+	if ratio < 2.7 || ratio > 3.3 {
+		t.Errorf("Expected lasers about 3 times as often as phasing, got a ratio of %f", ratio)
+	}
+}
+
+func TestPickPowerUp_NoWeights(t *testing.T) {
+	if powerUp := pickPowerUp(utils.NewRandom(1), [numPowerUpTypes]float64{}); powerUp != -1 {
+		t.Errorf("Expected no power-up without weights, got %d", powerUp)
+	}
+}
@@ -0,0 +1,129 @@
+package game
+
+import (
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+	"golang.org/x/net/websocket"
+)
+
+type EnqueuePlayer struct {
+	Ws          *websocket.Conn
+	Codec       Codec
+	Compression Compression
+	PlayerName  string
+	ClientId    string
+	Close       func()
+	//INFO Latest position waiting to be written while the player is queued, closed once it leaves the queue
+	statuses chan StatusMessage
+}
+type SlotFreed struct{}
+
+// INFO Sent by a queued player's status writer once its client can't take writes anymore
+type DropQueued struct {
+	Ws *websocket.Conn
+}
+
+// INFO Players waiting for a slot, only touched by the game routine
+type WaitingQueue []EnqueuePlayer
+
+// INFO Starts the player right away when a slot is open, otherwise parks it at the back of the queue
+func (game *Game) Enqueue(waiting EnqueuePlayer) {
+	if game.rejectDuplicateClient(waiting) {
+		return
+	}
+	if index := game.GetNextIndex(); index >= 0 && len(game.queue) == 0 {
+		game.join(index, waiting)
+		return
+	}
+	if game.config.MaxQueueLength > 0 && len(game.queue) >= game.config.MaxQueueLength {
+		game.reject(waiting, "server at capacity")
+		return
+	}
+	waiting.statuses = make(chan StatusMessage, 1)
+	go game.writeQueueStatuses(waiting)
+	game.queue = append(game.queue, waiting)
+	game.notifyQueue(len(game.queue) - 1)
+}
+
+func (game *Game) Dequeue() {
+	index := game.GetNextIndex()
+	if len(game.queue) == 0 || index < 0 {
+		return
+	}
+	next := game.queue[0]
+	game.queue = game.queue[1:]
+	close(next.statuses)
+	if !game.rejectDuplicateClient(next) {
+		game.join(index, next)
+	}
+	game.notifyQueue(0)
+}
+
+// INFO Removes a queued player whose client stopped taking writes and moves the ones behind it up
+func (game *Game) DropQueued(ws *websocket.Conn) {
+	for index, waiting := range game.queue {
+		if waiting.Ws != ws {
+			continue
+		}
+		game.queue = append(game.queue[:index:index], game.queue[index+1:]...)
+		close(waiting.statuses)
+		game.notifyQueue(index)
+		return
+	}
+}
+
+// INFO One client holds at most one paddle, a returning player has to use its reconnect token instead
+func (game *Game) rejectDuplicateClient(waiting EnqueuePlayer) bool {
+	if !game.HasClient(waiting.ClientId) {
+		return false
+	}
+	game.reject(waiting, "client already playing")
+	return true
+}
+
+// INFO Queues the current position of every waiting player from start on, a position not written yet is replaced by the newer one
+func (game *Game) notifyQueue(start int) {
+	for index, waiting := range game.queue[start:] {
+		select {
+		case <-waiting.statuses:
+		default:
+		}
+		waiting.statuses <- StatusMessage{MessageType: "queued", Position: start + index + 1}
+	}
+}
+
+// INFO Tells the client why it can't play and closes it, off the game routine so a stalled client can't hold up the game
+func (game *Game) reject(waiting EnqueuePlayer, reason string) {
+	go func() {
+		err := game.writeStatus(waiting.Ws, waiting.Codec, StatusMessage{MessageType: "rejected", Reason: reason})
+		if err != nil {
+			utils.LogError("Error writing rejected status to client", "reason", reason, "err", err)
+		}
+		waiting.Close()
+	}()
+}
+
+// INFO Writes a queued player's positions in order until it leaves the queue, a client that can't take them is closed and dropped
+func (game *Game) writeQueueStatuses(waiting EnqueuePlayer) {
+	for status := range waiting.statuses {
+		err := game.writeStatus(waiting.Ws, waiting.Codec, status)
+		if err != nil {
+			utils.LogInfo("Dropping queued player", "err", err)
+			waiting.Close()
+			game.channel <- DropQueued{Ws: waiting.Ws}
+			return
+		}
+	}
+}
+
+// INFO Status writes get WriteTimeout like frames do, the deadline is cleared afterwards for whoever writes next
+func (game *Game) writeStatus(ws *websocket.Conn, codec Codec, status StatusMessage) error {
+	if game.config.WriteTimeout > 0 {
+		if err := ws.SetWriteDeadline(time.Now().Add(game.config.WriteTimeout)); err != nil {
+			return err
+		}
+		defer ws.SetWriteDeadline(time.Time{})
+	}
+	return WriteStatus(ws, codec, status)
+}
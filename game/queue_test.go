@@ -0,0 +1,183 @@
+package game
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func newTestConnection(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	serverConnections := make(chan *websocket.Conn)
+	done := make(chan struct{})
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		serverConnections <- ws
+		<-done
+	}))
+	client, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	if err != nil {
+		t.Fatalf("Error dialing test server: %v", err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		close(done)
+		server.Close()
+	})
+	return <-serverConnections, client
+}
+
+// INFO A websocket whose peer answers the handshake and then never reads, so every write blocks until its deadline
+func newStalledConnection(t *testing.T) *websocket.Conn {
+	local, remote := net.Pipe()
+	t.Cleanup(func() {
+		local.Close()
+		remote.Close()
+	})
+	go func() {
+		request, err := http.ReadRequest(bufio.NewReader(remote))
+		if err != nil {
+			return
+		}
+		accept := sha1.Sum([]byte(request.Header.Get("Sec-Websocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		remote.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " +
+			base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n"))
+	}()
+	config, err := websocket.NewConfig("ws://localhost/", "http://localhost/")
+	if err != nil {
+		t.Fatalf("Error building the websocket config: %v", err)
+	}
+	ws, err := websocket.NewClient(config, local)
+	if err != nil {
+		t.Fatalf("Error handshaking over the pipe: %v", err)
+	}
+	return ws
+}
+
+func receiveStatus(t *testing.T, client *websocket.Conn) StatusMessage {
+	status := StatusMessage{}
+	if err := websocket.JSON.Receive(client, &status); err != nil {
+		t.Fatalf("Error receiving status: %v", err)
+	}
+	return status
+}
+
+func TestGame_Enqueue(t *testing.T) {
+	game := StartGame()
+	game.config.MaxQueueLength = 2
+	game.channel = make(chan GameMessage, 10)
+	for index := range game.Players {
+		game.Players[index] = &Player{Index: index, Connected: true}
+	}
+
+	clients := []*websocket.Conn{}
+	for i := 0; i < 3; i++ {
+		serverWs, client := newTestConnection(t)
+		clients = append(clients, client)
+		game.Enqueue(EnqueuePlayer{Ws: serverWs, Codec: JSONCodec, Close: func() { serverWs.Close() }})
+	}
+
+	for index, client := range clients[:2] {
+		status := receiveStatus(t, client)
+		if status.MessageType != "queued" || status.Position != index+1 {
+			t.Errorf("Expected client %d to be queued at position %d, got %+v", index, index+1, status)
+		}
+	}
+	status := receiveStatus(t, clients[2])
+	if status.MessageType != "rejected" || status.Reason != "server at capacity" {
+		t.Errorf("Expected the client beyond the queue limit to be rejected, got %+v", status)
+	}
+	if len(game.queue) != 2 {
+		t.Fatalf("Expected 2 queued players, got %d", len(game.queue))
+	}
+
+	game.Dequeue()
+	if len(game.queue) != 2 {
+		t.Errorf("Expected nobody to leave the queue while the game is full, got %d queued", len(game.queue))
+	}
+
+	game.Players[1] = nil
+	game.Dequeue()
+	if len(game.queue) != 1 {
+		t.Fatalf("Expected the first queued player to take the free slot, got %d queued", len(game.queue))
+	}
+	status = receiveStatus(t, clients[1])
+	if status.MessageType != "queued" || status.Position != 1 {
+		t.Errorf("Expected the remaining client to move up to position 1, got %+v", status)
+	}
+}
+
+func TestGame_Enqueue_DuplicateClient(t *testing.T) {
+	game := StartGame()
+	go game.ReadGameChannel()
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	handler := func(ws *websocket.Conn) {
+		game.LifeCycle(ws, JSONCodec, NoCompression, "bot", "same-client", func() { ws.Close() })
+		<-done
+	}
+	//INFO Both connect before either player is added, the slot handed to the first one already holds its client
+	clients := []*ScriptedClient{ConnectScriptedClient(t, handler), ConnectScriptedClient(t, handler)}
+
+	rejected := 0
+	for _, client := range clients {
+		status := StatusMessage{}
+		if err := client.Receive(&status); err != nil {
+			t.Fatalf("Error receiving the first message: %v", err)
+		}
+		if status.MessageType == "rejected" {
+			if status.Reason != "client already playing" {
+				t.Errorf("Expected the duplicate client to be rejected as already playing, got %+v", status)
+			}
+			rejected++
+		}
+	}
+	if rejected != 1 {
+		t.Errorf("Expected exactly one of the two connections from the same client to be rejected, got %d", rejected)
+	}
+}
+
+func TestGame_Enqueue_StalledClient(t *testing.T) {
+	game := StartGame()
+	game.config.WriteTimeout = 20 * time.Millisecond
+	game.channel = make(chan GameMessage, 10)
+	for index := range game.Players {
+		game.Players[index] = &Player{Index: index, Connected: true}
+	}
+	stalled := newStalledConnection(t)
+	closed := make(chan struct{})
+
+	enqueued := make(chan struct{})
+	go func() {
+		game.mutex.Lock()
+		defer game.mutex.Unlock()
+		game.Enqueue(EnqueuePlayer{Ws: stalled, Codec: JSONCodec, Close: func() { close(closed) }})
+		close(enqueued)
+	}()
+	select {
+	case <-enqueued:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected a client that doesn't read not to hold up the game routine")
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the stalled client to be closed once its write timed out")
+	}
+	message := <-game.channel
+	drop, ok := message.(DropQueued)
+	if !ok || drop.Ws != stalled {
+		t.Fatalf("Expected the stalled client to be dropped from the queue, got %#v", message)
+	}
+	game.DropQueued(drop.Ws)
+	if len(game.queue) != 0 {
+		t.Errorf("Expected the queue to be empty, got %d queued", len(game.queue))
+	}
+}
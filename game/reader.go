@@ -1,8 +1,6 @@
 package game
 
 import (
-	"fmt"
-	"math/rand"
 	"time"
 
 	"github.com/lguibr/pongo/utils"
@@ -12,75 +10,108 @@ func (g *Game) ReadBallChannel(ownerIndex int, ball *Ball) {
 	ballChannel := ball.Channel
 
 	for {
-		message, ok := <-ballChannel
-
-		if !ok {
-
+		select {
+		case <-ball.done:
 			return
-		}
+		case message, ok := <-ballChannel:
+			if !ok {
 
-		switch payload := message.(type) {
-		case BallPositionMessage:
-
-			ball := payload.Ball
-			ball.CollidePaddles(g.Paddles)
-			ball.CollideCells(g.Canvas.Grid, g.Canvas.CellSize)
-			ball.CollideWalls()
-		case WallCollisionMessage:
-			ball := payload.Ball
-			index := payload.Index
-			if index == ball.OwnerIndex || g.Players[index] == nil {
-				continue
-			}
-			g.Players[index].channel <- PlayerScore{-1}
-			if g.Players[ball.OwnerIndex] != nil {
-				g.Players[ball.OwnerIndex].channel <- PlayerScore{1}
-			}
-		case BreakBrickMessage:
-			level := payload.Level
-			ball := payload.BallPayload
-			playerIndex := ball.OwnerIndex
-			if g.Players[playerIndex] == nil {
-				break
+				return
 			}
-			g.Players[playerIndex].channel <- PlayerScore{level}
-			random := rand.Intn(4)
-			if random == 0 {
-				g.channel <- AddBall{
-					NewBall(
-						NewBallChannel(),
-						ball.X,
-						ball.Y,
-						utils.BallSize,
-						utils.CanvasSize,
-						playerIndex,
-						time.Now().Nanosecond(),
-					),
-					rand.Intn(2) + 1,
-				}
-			} else if random == 1 {
-				g.channel <- IncreaseBallMass{ball, 1}
-			} else if random == 2 {
-				g.channel <- IncreaseBallVelocity{ball, 1.1}
-			} else {
-				g.channel <- BallPhasing{ball, 1}
-			}
-		default:
-			continue
+			g.mutex.Lock()
+			sends := g.handleBallMessage(message)
+			g.mutex.Unlock()
+			sends.send()
 		}
+	}
+}
 
+func (g *Game) handleBallMessage(message BallMessage) pendingSends {
+	sends := pendingSends{}
+	switch payload := message.(type) {
+	case BallPositionMessage:
+
+		ball := payload.Ball
+		//INFO A ball held by a sticky paddle just follows it until launched
+		if ball.Stuck {
+			ball.substepHandled()
+			return sends
+		}
+		start := time.Now()
+		owner := ball.OwnerIndex
+		ball.CollidePaddles(g.Paddles)
+		if ball.OwnerIndex != owner {
+			sends.toGame(g, BallOwnerChanged{ball})
+		}
+		ball.CollideCells(g.Canvas.Grid, g.Canvas.CellSize)
+		ball.CollideWalls()
+		if ball.Wedged(g.config.StuckBallTicks * utils.MaxInt(ball.substeps, 1)) {
+			utils.LogDebug("Nudging wedged ball", "ball", ball.Id, "x", ball.X, "y", ball.Y)
+			ball.Nudge(g.random)
+			sends.toGame(g, BallPhasing{ball, 1})
+		}
+		g.recordTick(start)
+		if cues := ball.takeCues(); len(cues) > 0 {
+			g.cues.Publish(time.Now(), cues...)
+		}
+		ball.substepHandled()
+	case WallCollisionMessage:
+		ball := payload.Ball
+		index := payload.Index
+		//INFO Only the first wall reached after a paddle hit counts as a direct hit
+		direct := ball.wallHitsSinceOwnerChange == 0
+		ball.wallHitsSinceOwnerChange++
+		//INFO Co-op players only score by breaking bricks together
+		if g.config.CoopMode {
+			return sends
+		}
+		if index == ball.OwnerIndex || g.Players[index] == nil || !ball.InGoal(index, g.config.GoalWidthRatio) {
+			return sends
+		}
+		sends.toPlayer(g.Players[index], PlayerScore{-1})
+		if g.config.ScoreOnlyDirectHits && !direct {
+			return sends
+		}
+		if ball.OwnerIndex != NoOwner && g.Players[ball.OwnerIndex] != nil {
+			sends.toPlayer(g.Players[ball.OwnerIndex], PlayerScore{1})
+		}
+	case BallPull:
+		//INFO The ball may have stuck to a paddle since the pull was worked out
+		if !payload.Ball.Stuck {
+			payload.Ball.accelerate(payload.Ax, payload.Ay)
+		}
+	case ScaleBallSpeed:
+		payload.Ball.ScaleVelocity(payload.Ratio)
+	case BallImpulse:
+		payload.Ball.Vx += payload.Vx
+		payload.Ball.Vy += payload.Vy
+	case BreakBrickMessage:
+		level := payload.Level
+		ball := payload.BallPayload
+		playerIndex := ball.OwnerIndex
+		if playerIndex != NoOwner && g.Players[playerIndex] != nil {
+			sends.toPlayer(g.Players[playerIndex], PlayerScore{level})
+			sends.toGame(g, g.triggerRandomPowerUp(ball)...)
+		}
+		if !g.Canvas.Grid.HasBricks() {
+			sends.toGame(g, g.clearedGridMessage())
+		}
 	}
+	return sends
 }
 
 func (playerPaddle *Paddle) ReadPaddleChannel(paddleChannel chan PaddleMessage) {
 	for message := range paddleChannel {
 		switch message := message.(type) {
 		case PaddleDirectionMessage:
-			direction := message.Direction
-			_, err := playerPaddle.SetDirection(direction)
-			if err != nil {
-				fmt.Println("Error setting direction :", err)
-			}
+			withState(playerPaddle.state, func() {
+				_, err := playerPaddle.SetDirection(message.Direction, message.Lag)
+				if err != nil {
+					utils.LogWarn("Error setting direction", "paddle", playerPaddle.Index, "err", err)
+					return
+				}
+				playerPaddle.LaunchStuckBalls()
+			})
 		default:
 			continue
 		}
@@ -95,47 +126,177 @@ func (g *Game) ReadPlayerChannel(
 	callback func(),
 ) {
 	for message := range playerChannel {
-		switch payload := message.(type) {
-		case PlayerConnectMessage:
-			player := message.(PlayerConnectMessage).PlayerPayload
-			g.channel <- AddBall{ball, 0}
-			g.AddPlayer(index, player, paddle)
-		case PlayerDisconnectMessage:
+		g.mutex.Lock()
+		sends := g.handlePlayerMessage(index, message, paddle, ball, &callback)
+		g.mutex.Unlock()
+		sends.send()
+	}
+}
+
+func (g *Game) handlePlayerMessage(index int, message PlayerMessage, paddle *Paddle, ball *Ball, callback *func()) pendingSends {
+	sends := pendingSends{}
+	switch payload := message.(type) {
+	case PlayerConnectMessage:
+		player := message.(PlayerConnectMessage).PlayerPayload
+		player.Connected = true
+		player.touchInput(time.Now())
+		g.AddPlayer(index, player, paddle)
+		sends.toGame(g, StartWhenReady{ball})
+	case PlayerDisconnectMessage:
+		(*callback)()
+		player := g.Players[index]
+		if player == nil || !player.Connected {
+			return sends
+		}
+		if g.config.ReconnectGracePeriod <= 0 {
 			g.RemovePlayer(index)
-			callback()
-		case PlayerScore:
-			score := payload.Score
-			g.Players[index].Score += score
-		default:
-			continue
+			return sends
+		}
+		//INFO Keep the slot, paddle and balls reserved until the player reconnects or the grace period expires
+		paddle.Direction = ""
+		g.recordEvent("playerDisconnected", "player", index)
+		player.StartReconnectGracePeriod(g.clock, g.config.ReconnectGracePeriod)
+	case PlayerReconnectMessage:
+		player := g.Players[index]
+		if player == nil {
+			payload.Close()
+			return sends
+		}
+		*callback = payload.Close
+		player.touchInput(time.Now())
+		g.recordEvent("playerReconnected", "player", index)
+		player.StopReconnectGracePeriod()
+	case PlayerGraceExpiredMessage:
+		player := g.Players[index]
+		if player == nil || player.Connected {
+			return sends
 		}
+		g.RemovePlayer(index)
+	case PlayerScore:
+		sends.toGame(g, AddPlayerScore{index, payload.Score})
+	case PlayerFireMessage:
+		sends.toGame(g, LaserFired{index})
 	}
+	return sends
 }
 
 func (g *Game) ReadGameChannel() {
 	for message := range g.channel {
-		switch message := message.(type) {
-		case AddBall:
-			ball := message.BallPayload
-			expire := message.ExpireIn
-			g.AddBall(ball, expire)
-		case RemoveBall:
-			id := message.Id
-			g.RemoveBall(id)
-		case IncreaseBallVelocity:
-			ball := message.BallPayload
-			ratio := message.Ratio
-			ball.IncreaseVelocity(ratio)
-		case IncreaseBallMass:
-			ball := message.BallPayload
-			additional := message.Additional
-			ball.IncreaseMass(additional)
-		case BallPhasing:
-			ball := message.BallPayload
-			expireIn := message.ExpireIn
-			ball.SetBallPhasing(expireIn)
-		default:
-			continue
+		g.mutex.Lock()
+		g.handleGameMessage(message)
+		g.mutex.Unlock()
+	}
+}
+
+func (g *Game) handleGameMessage(message GameMessage) {
+	switch message := message.(type) {
+	case AddBall:
+		ball := message.BallPayload
+		expire := message.ExpireIn
+		//INFO Power-up balls queued before the cap was reached are dropped, permanent player balls always join
+		if expire != 0 && !g.canSpawnBall() {
+			return
+		}
+		//INFO Ownership is only checked as power-up balls join, several can be queued at once
+		if expire != 0 && ball.OwnerIndex != NoOwner && g.config.MaxOwnedBalls > 0 && g.OwnedBalls(ball.OwnerIndex) >= g.config.MaxOwnedBalls {
+			ball.OwnerIndex = NoOwner
+		}
+		g.AddBall(ball, expire)
+	case RemoveBall:
+		id := message.Id
+		g.RemoveBall(id)
+	case ExpirePermanentBall:
+		g.ExpirePermanentBall(message.Id)
+	case StartWhenReady:
+		g.StartWhenReady(message.BallPayload)
+	case AddPlayerScore:
+		g.AddPlayerScore(message.PlayerIndex, message.Score)
+	case ToggleScoreMultiplier:
+		g.ToggleScoreMultiplier(message.Active)
+	case CheckIdlePlayers:
+		g.CheckIdlePlayers(time.Now())
+	case CountdownTick:
+		g.CountdownTick(message.SecondsRemaining)
+	case TransferBalls:
+		g.TransferBalls(message.FromIndex)
+	case IncreaseBallVelocity:
+		ball := message.BallPayload
+		ratio := message.Ratio
+		ball.IncreaseVelocity(ratio)
+	case IncreaseBallMass:
+		ball := message.BallPayload
+		additional := message.Additional
+		ball.IncreaseMass(additional)
+	case SetBallVelocity:
+		ball := message.BallPayload
+		ball.Vx = message.Vx
+		ball.Vy = message.Vy
+	case GrantLaserCharges:
+		player := g.Players[message.PlayerIndex]
+		if player != nil {
+			player.LaserCharges += message.Charges
+		}
+	case LaserFired:
+		g.FireLaser(message.PlayerIndex)
+	case LaserMoved:
+		g.MoveLaser(message.LaserPayload)
+	case LaserRemoved:
+		g.RemoveLaser(message.Id)
+	case SlowBalls:
+		g.SlowBalls(message.OwnerIndex)
+	case RestoreBallSpeeds:
+		g.RestoreBallSpeeds(message.Ids)
+	case ToggleMagnet:
+		g.ToggleMagnet(message.OwnerIndex, message.Active)
+	case internalForcePowerUp:
+		g.forcePowerUp(message.BallId, message.Kind)
+	case BallGhost:
+		message.BallPayload.SetBallGhost(g.clock, message.Duration)
+	case BallOwnerChanged:
+		g.tintBall(message.BallPayload)
+	case BallPhasing:
+		ball := message.BallPayload
+		expireIn := message.ExpireIn
+		ball.SetBallPhasing(g.clock, expireIn)
+	case StickyPaddle:
+		paddle := message.PaddlePayload
+		paddle.Sticky = true
+	case ResizePaddle:
+		paddle := message.PaddlePayload
+		paddle.Resize(message.Length)
+		paddle.shrunk = message.Shrunk
+	case ResizePaddlesByScore:
+		g.ResizePaddlesByScore()
+	case CollideBalls:
+		g.CollideBalls()
+	case ApplyMagnets:
+		g.ApplyMagnets()
+	case ResetGame:
+		g.Reset()
+	case EndGame:
+		if message.Reason == timeLimitReason && g.StartSuddenDeath() {
+			return
 		}
+		g.EndGame(message.Reason)
+	case CheckSuddenDeath:
+		g.CheckSuddenDeath()
+	case RestartGame:
+		g.RestartGame()
+	case EnqueuePlayer:
+		g.Enqueue(message)
+	case SlotFreed:
+		g.Dequeue()
+	case DropQueued:
+		g.DropQueued(message.Ws)
+	case NextWave:
+		g.NextWave()
+	case GetSnapshot:
+		message.Reply <- g.Snapshot()
+	case GetMetrics:
+		message.Reply <- g.Metrics()
+	case GetHealth:
+		message.Reply <- g.Health()
+	case GetSummary:
+		message.Reply <- g.Summary()
 	}
 }
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func wallHitScores(t *testing.T, scoreOnlyDirectHits bool) []int {
+	game := StartGame()
+	game.config.ScoreOnlyDirectHits = scoreOnlyDirectHits
+	owner := &Player{Index: 0, channel: make(chan PlayerMessage, 10)}
+	conceder := &Player{Index: 1, channel: make(chan PlayerMessage, 10)}
+	game.Players[0], game.Players[1] = owner, conceder
+	ball := &Ball{Id: 1, OwnerIndex: 0, Channel: make(chan BallMessage, 2)}
+
+	done := make(chan struct{})
+	go func() {
+		game.ReadBallChannel(0, ball)
+		close(done)
+	}()
+	ball.Channel <- WallCollisionMessage{Index: 2, Ball: ball}
+	ball.Channel <- WallCollisionMessage{Index: 1, Ball: ball}
+	close(ball.Channel)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the ball reader to stop once its channel is closed")
+	}
+
+	if len(conceder.channel) != 1 {
+		t.Fatalf("Expected the conceder to lose a point, got %d score messages", len(conceder.channel))
+	}
+	scores := []int{}
+	for len(owner.channel) > 0 {
+		scores = append(scores, (<-owner.channel).(PlayerScore).Score)
+	}
+	return scores
+}
+
+func TestGame_ReadBallChannel_WallHitScores(t *testing.T) {
+	if scores := wallHitScores(t, false); len(scores) != 1 || scores[0] != 1 {
+		t.Errorf("Expected the owner to score a bounced concession, got %v", scores)
+	}
+}
+
+func TestGame_ReadBallChannel_ScoreOnlyDirectHits(t *testing.T) {
+	if scores := wallHitScores(t, true); len(scores) != 0 {
+		t.Errorf("Expected no points for a concession after another wall, got %v", scores)
+	}
+}
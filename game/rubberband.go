@@ -0,0 +1,79 @@
+package game
+
+import (
+	"context"
+	"math"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+type ResizePaddlesByScore struct{}
+
+// INFO Asks the game to resize the paddles by score on every scoreboard interval while dynamic paddle size is on
+func (game *Game) RubberBand(ctx context.Context) {
+	if !game.config.DynamicPaddleSize || game.config.ScoreboardInterval <= 0 {
+		return
+	}
+	ticker := game.clock.NewTicker(game.config.ScoreboardInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case game.channel <- ResizePaddlesByScore{}:
+		}
+	}
+}
+
+func (game *Game) ResizePaddlesByScore() {
+	scores := map[int]int{}
+	for index, player := range game.Players {
+		if player != nil && player.Connected && game.Paddles[index] != nil {
+			scores[index] = player.Score
+		}
+	}
+	for index, length := range PaddleLengthsByScore(scores, game.config.MinPaddleLength, game.config.MaxPaddleLength) {
+		paddle := game.Paddles[index]
+		//INFO A shrink power-up wins over rubber banding until it wears off
+		if paddle.shrunk {
+			continue
+		}
+		paddle.Resize(length)
+	}
+}
+
+// INFO Players at the average score keep the default length, the furthest behind get maxLength and the furthest ahead minLength
+func PaddleLengthsByScore(scores map[int]int, minLength, maxLength int) map[int]int {
+	lengths := map[int]int{}
+	if len(scores) == 0 {
+		return lengths
+	}
+	mean := 0.0
+	for _, score := range scores {
+		mean += float64(score)
+	}
+	mean /= float64(len(scores))
+	spread := 0.0
+	for _, score := range scores {
+		spread = math.Max(spread, math.Abs(float64(score)-mean))
+	}
+
+	for index, score := range scores {
+		length := utils.PaddleLength
+		if spread > 0 {
+			behind := (mean - float64(score)) / spread
+			if behind > 0 {
+				length += int(math.Round(behind * float64(maxLength-utils.PaddleLength)))
+			} else {
+				length += int(math.Round(behind * float64(utils.PaddleLength-minLength)))
+			}
+		}
+		lengths[index] = utils.MaxInt(minLength, utils.MinInt(length, maxLength))
+	}
+	return lengths
+}
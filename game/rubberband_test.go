@@ -0,0 +1,53 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestPaddleLengthsByScore(t *testing.T) {
+	minLength, maxLength := utils.PaddleLength/2, utils.PaddleLength*2
+	lengths := PaddleLengthsByScore(map[int]int{0: 100, 1: 0, 2: 50, 3: 50}, minLength, maxLength)
+
+	if lengths[0] >= utils.PaddleLength || lengths[0] < minLength {
+		t.Errorf("Expected the leader's paddle to shrink, got %d", lengths[0])
+	}
+	if lengths[1] != maxLength {
+		t.Errorf("Expected the furthest behind player to get the longest paddle %d, got %d", maxLength, lengths[1])
+	}
+	if lengths[2] != utils.PaddleLength || lengths[3] != utils.PaddleLength {
+		t.Errorf("Expected average players to keep the default length, got %d and %d", lengths[2], lengths[3])
+	}
+
+	tied := PaddleLengthsByScore(map[int]int{0: 10, 2: 10}, minLength, maxLength)
+	if tied[0] != utils.PaddleLength || tied[2] != utils.PaddleLength {
+		t.Errorf("Expected tied players to keep the default length, got %v", tied)
+	}
+}
+
+func TestGame_ResizePaddlesByScore(t *testing.T) {
+	game := StartGame()
+	game.config.MinPaddleLength = utils.PaddleLength / 2
+	game.config.MaxPaddleLength = utils.PaddleLength * 2
+	for _, index := range []int{0, 1, 2} {
+		game.Players[index] = &Player{Index: index, Connected: true}
+		game.Paddles[index] = NewPaddle(NewPaddleChannel(), utils.CanvasSize, index)
+	}
+	game.Players[0].Score = 0
+	game.Players[1].Score = 100
+	game.Players[2].Score = 100
+	game.Paddles[2].shrunk = true
+
+	game.ResizePaddlesByScore()
+
+	if game.Paddles[0].Length() <= utils.PaddleLength {
+		t.Errorf("Expected the losing player's paddle to grow, got %d", game.Paddles[0].Length())
+	}
+	if game.Paddles[1].Length() >= utils.PaddleLength {
+		t.Errorf("Expected the leading player's paddle to shrink, got %d", game.Paddles[1].Length())
+	}
+	if game.Paddles[2].Length() != utils.PaddleLength {
+		t.Errorf("Expected a paddle shrunk by a power-up to be left alone, got %d", game.Paddles[2].Length())
+	}
+}
@@ -0,0 +1,99 @@
+package game
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// INFO Drives a websocket client from tests, game state frames are decoded and every other message is skipped
+type ScriptedClient struct {
+	t  *testing.T
+	ws *websocket.Conn
+}
+
+func ConnectScriptedClient(t *testing.T, handler websocket.Handler) *ScriptedClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := &ScriptedClient{t: t}
+	client.Connect(server.URL)
+	return client
+}
+
+func (client *ScriptedClient) Connect(serverURL string) {
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(serverURL, "http"), "", serverURL)
+	if err != nil {
+		client.t.Fatalf("Error dialing test server: %v", err)
+	}
+	client.ws = ws
+	client.t.Cleanup(client.Close)
+}
+
+func (client *ScriptedClient) Send(direction string) {
+	if err := websocket.JSON.Send(client.ws, Direction{Direction: direction}); err != nil {
+		client.t.Fatalf("Error sending %s: %v", direction, err)
+	}
+}
+
+func (client *ScriptedClient) SendJSON(message interface{}) {
+	if err := websocket.JSON.Send(client.ws, message); err != nil {
+		client.t.Fatalf("Error sending %+v: %v", message, err)
+	}
+}
+
+func (client *ScriptedClient) Receive(message interface{}) error {
+	return websocket.JSON.Receive(client.ws, message)
+}
+
+// INFO Reads frames until a game state satisfies the condition, failing the test once the timeout is over
+func (client *ScriptedClient) WaitFor(condition func(*Game) bool, timeout time.Duration) *Game {
+	deadline := time.Now().Add(timeout)
+	if err := client.ws.SetReadDeadline(deadline); err != nil {
+		client.t.Fatalf("Error setting read deadline: %v", err)
+	}
+	defer func() { _ = client.ws.SetReadDeadline(time.Time{}) }()
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(client.ws, &data); err != nil {
+			client.t.Fatalf("Expected a game state matching the condition within %v: %v", timeout, err)
+		}
+		message, err := DecodeMessage(JSONCodec, data)
+		state, ok := message.(*Game)
+		if err != nil || !ok || state.Canvas == nil {
+			continue
+		}
+		if condition(state) {
+			return state
+		}
+	}
+}
+
+func (client *ScriptedClient) Close() {
+	if client.ws != nil {
+		client.ws.Close()
+	}
+}
+
+func TestScriptedClient_PlaysTheGame(t *testing.T) {
+	game := StartGame()
+	go game.ReadGameChannel()
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	client := ConnectScriptedClient(t, func(ws *websocket.Conn) {
+		game.LifeCycle(ws, JSONCodec, NoCompression, "bot", "", func() {})
+		<-done
+	})
+
+	joined := client.WaitFor(func(state *Game) bool {
+		return state.Players[0] != nil && state.Paddles[0] != nil && len(state.Balls) == 1
+	}, 2*time.Second)
+	start := joined.Paddles[0].Y
+
+	client.Send("ArrowRight")
+	client.WaitFor(func(state *Game) bool {
+		return state.Paddles[0] != nil && state.Paddles[0].Y != start
+	}, 2*time.Second)
+}
@@ -0,0 +1,95 @@
+package game
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+	"golang.org/x/net/websocket"
+)
+
+// INFO Lets a client estimate its clock offset, frameSeq is the latest frame broadcast when the time was taken
+type ServerTime struct {
+	MessageType string `json:"messageType"`
+	UnixNanos   int64  `json:"unixNanos"`
+	FrameSeq    int64  `json:"frameSeq"`
+}
+
+// INFO Estimates how late a connection's inputs reach the server from the ServerTime messages the client echoes back
+type ClockSync struct {
+	clock Clock
+	//INFO Unix nanoseconds of the latest ServerTime sent, only that one is accepted back so a client can't make up older samples
+	pending int64
+	//INFO Smoothed one-way lag in nanoseconds, zero until the first echo
+	lag int64
+}
+
+func NewClockSync(clock Clock) *ClockSync {
+	return &ClockSync{clock: clock}
+}
+
+// INFO Half the round trip of an echoed ServerTime is one lag sample, each moves the estimate an eighth of the way so a single slow sample barely counts
+func (clockSync *ClockSync) Echo(unixNanos int64) bool {
+	if clockSync == nil || unixNanos == 0 || !atomic.CompareAndSwapInt64(&clockSync.pending, unixNanos, 0) {
+		return false
+	}
+	sample := int64(clockSync.clock.Now().Sub(time.Unix(0, unixNanos)) / 2)
+	if sample < 0 {
+		return false
+	}
+	lag := atomic.LoadInt64(&clockSync.lag)
+	if lag > 0 {
+		sample = lag + (sample-lag)/8
+	}
+	atomic.StoreInt64(&clockSync.lag, sample)
+	return true
+}
+
+func (clockSync *ClockSync) Lag() time.Duration {
+	if clockSync == nil {
+		return 0
+	}
+	return time.Duration(atomic.LoadInt64(&clockSync.lag))
+}
+
+// INFO Clients echo each ServerTime back unchanged so the server can measure their lag
+func ServerTimeEcho(message []byte) (int64, bool) {
+	serverTime := ServerTime{}
+	err := json.Unmarshal(message, &serverTime)
+	return serverTime.UnixNanos, err == nil && serverTime.MessageType == "serverTime"
+}
+
+// INFO clockSync is nil for connections whose inputs aren't compensated, like spectators
+func (game *Game) WriteServerTime(ws *websocket.Conn, codec Codec, clockSync *ClockSync) error {
+	serverTime := ServerTime{MessageType: "serverTime", UnixNanos: game.clock.Now().UnixNano(), FrameSeq: game.frames.latestSeq()}
+	data, err := codec.Marshal(serverTime)
+	if err != nil {
+		return err
+	}
+	if clockSync != nil {
+		atomic.StoreInt64(&clockSync.pending, serverTime.UnixNanos)
+	}
+	_, err = ws.Write(data)
+	return err
+}
+
+// INFO Sends the server time right away and then every interval until the connection stops accepting writes, clocks drift so one sample isn't enough
+func (game *Game) SyncServerTime(ws *websocket.Conn, codec Codec, interval time.Duration, clockSync *ClockSync) {
+	err := game.WriteServerTime(ws, codec, clockSync)
+	if err != nil {
+		utils.LogDebug("Error writing server time to client", "err", err)
+		return
+	}
+	if interval <= 0 {
+		return
+	}
+	ticker := game.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C() {
+		err := game.WriteServerTime(ws, codec, clockSync)
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestGame_SyncServerTime(t *testing.T) {
+	game := StartGame()
+	clock := NewManualClock(time.Unix(100, 0))
+	game.SetClock(clock)
+	game.frames.seq = 7
+	closeConnection := make(chan struct{})
+	closed := make(chan struct{})
+	exited := make(chan struct{})
+	clockSync := NewClockSync(clock)
+	client := ConnectScriptedClient(t, func(ws *websocket.Conn) {
+		go func() {
+			game.SyncServerTime(ws, JSONCodec, time.Second, clockSync)
+			close(exited)
+		}()
+		<-closeConnection
+		ws.Close()
+		close(closed)
+	})
+
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			advanceOnceTicking(t, clock, time.Second)
+		}
+		serverTime := ServerTime{}
+		err := client.Receive(&serverTime)
+		if err != nil {
+			t.Fatalf("Expected the server time, got %v", err)
+		}
+		if serverTime.MessageType != "serverTime" || serverTime.FrameSeq != 7 {
+			t.Errorf("Expected a server time stamped with the latest frame, got %+v", serverTime)
+		}
+		if serverTime.UnixNanos != clock.Now().UnixNano() {
+			t.Errorf("Expected the game clock's time %d, got %d", clock.Now().UnixNano(), serverTime.UnixNanos)
+		}
+		if !clockSync.Echo(serverTime.UnixNanos) {
+			t.Errorf("Expected the server time sent to be accepted back")
+		}
+	}
+
+	close(closeConnection)
+	<-closed
+	clock.Advance(time.Second)
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Errorf("Expected the sync to stop once the connection closed")
+	}
+}
+
+// INFO Waits for the sync loop to create its ticker before advancing, a tick sent before that would be lost
+func advanceOnceTicking(t *testing.T, clock *ManualClock, duration time.Duration) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		clock.mutex.Lock()
+		ticking := len(clock.tickers) > 0
+		clock.mutex.Unlock()
+		if ticking {
+			clock.Advance(duration)
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the sync to start ticking")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClockSync_Echo(t *testing.T) {
+	clock := NewManualClock(time.Unix(100, 0))
+	clockSync := NewClockSync(clock)
+	sent := clock.Now().UnixNano()
+	clockSync.pending = sent
+	clock.Advance(100 * time.Millisecond)
+
+	if clockSync.Echo(sent - 1) {
+		t.Errorf("Expected a made up sample to be ignored")
+	}
+	if !clockSync.Echo(sent) || clockSync.Lag() != 50*time.Millisecond {
+		t.Fatalf("Expected half the round trip as the first lag, got %v", clockSync.Lag())
+	}
+	if clockSync.Echo(sent) {
+		t.Errorf("Expected a sample to be accepted only once")
+	}
+
+	clockSync.pending = clock.Now().UnixNano()
+	clockSync.Echo(clockSync.pending)
+	if expected := 50*time.Millisecond - 50*time.Millisecond/8; clockSync.Lag() != expected {
+		t.Errorf("Expected a fast sample to move the lag an eighth of the way to %v, got %v", expected, clockSync.Lag())
+	}
+
+	var missing *ClockSync
+	if missing.Echo(sent) || missing.Lag() != 0 {
+		t.Errorf("Expected connections without a clock sync to have no lag")
+	}
+}
+
+func TestServerTimeEcho(t *testing.T) {
+	unixNanos, ok := ServerTimeEcho([]byte(`{"messageType":"serverTime","unixNanos":42,"frameSeq":7}`))
+	if !ok || unixNanos != 42 {
+		t.Errorf("Expected the echoed server time, got %d %v", unixNanos, ok)
+	}
+	if _, ok := ServerTimeEcho([]byte(`{"direction":"ArrowLeft"}`)); ok {
+		t.Errorf("Expected a direction not to be taken for an echo")
+	}
+}
@@ -0,0 +1,180 @@
+package game
+
+import (
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+type GetSnapshot struct {
+	Reply chan GameSnapshot
+}
+
+type PlayerSnapshot struct {
+	Index        int    `json:"index"`
+	Id           string `json:"id"`
+	Color        [3]int `json:"color"`
+	Score        int    `json:"score"`
+	Connected    bool   `json:"connected"`
+	LaserCharges int    `json:"laserCharges"`
+}
+
+type GameSnapshot struct {
+	Players         []PlayerSnapshot `json:"players"`
+	Paddles         []Paddle         `json:"paddles"`
+	Balls           []Ball           `json:"balls"`
+	Lasers          []Laser          `json:"lasers"`
+	Grid            Grid             `json:"grid"`
+	GameOver        *GameOverMessage `json:"gameOver,omitempty"`
+	Wave            int              `json:"wave"`
+	Waiting         bool             `json:"waiting"`
+	Countdown       int              `json:"countdown,omitempty"`
+	ScoreMultiplier int              `json:"scoreMultiplier"`
+	TeamScore       int64            `json:"teamScore"`
+	SuddenDeath     bool             `json:"suddenDeath"`
+}
+
+func (game *Game) Snapshot() GameSnapshot {
+	snapshot := GameSnapshot{
+		Players: []PlayerSnapshot{},
+		Paddles: []Paddle{},
+		Balls:   []Ball{},
+		Lasers:  []Laser{},
+	}
+	for _, player := range game.Players {
+		if player == nil {
+			continue
+		}
+		snapshot.Players = append(snapshot.Players, PlayerSnapshot{
+			Index:        player.Index,
+			Id:           player.Id,
+			Color:        player.Color,
+			Score:        player.Score,
+			Connected:    player.Connected,
+			LaserCharges: player.LaserCharges,
+		})
+	}
+	for _, paddle := range game.Paddles {
+		if paddle == nil {
+			continue
+		}
+		snapshot.Paddles = append(snapshot.Paddles, *paddle)
+	}
+	for _, ball := range game.Balls {
+		snapshot.Balls = append(snapshot.Balls, *ball)
+	}
+	for _, laser := range game.Lasers {
+		snapshot.Lasers = append(snapshot.Lasers, *laser)
+	}
+	if game.Canvas != nil {
+		snapshot.Grid = game.Canvas.Grid.Copy()
+	}
+	snapshot.Wave = game.Wave
+	snapshot.Waiting = game.Waiting
+	snapshot.Countdown = game.Countdown
+	snapshot.ScoreMultiplier = game.ScoreMultiplier
+	snapshot.TeamScore = atomic.LoadInt64(&game.TeamScore)
+	snapshot.SuddenDeath = game.SuddenDeath
+	if game.GameOver != nil {
+		gameOver := *game.GameOver
+		snapshot.GameOver = &gameOver
+	}
+	return snapshot
+}
+
+// INFO Asks the game routine for a snapshot so it is built between state changes
+func (game *Game) RequestSnapshot(timeout time.Duration) (GameSnapshot, bool) {
+	reply := make(chan GameSnapshot, 1)
+	select {
+	case game.channel <- GetSnapshot{Reply: reply}:
+	case <-time.After(timeout):
+		return GameSnapshot{}, false
+	}
+	select {
+	case snapshot := <-reply:
+		return snapshot, true
+	case <-time.After(timeout):
+		return GameSnapshot{}, false
+	}
+}
+
+// INFO Reports whether anything moved further than epsilon or changed at all since the previous snapshot
+func (snapshot GameSnapshot) Differs(previous GameSnapshot, epsilon int) bool {
+	movedBeyond := func(current, last int) bool {
+		return utils.Abs(current-last) > epsilon
+	}
+
+	//INFO Score changes alone are left to the periodic scoreboard
+	withoutScores := func(players []PlayerSnapshot) []PlayerSnapshot {
+		stripped := make([]PlayerSnapshot, len(players))
+		for i, player := range players {
+			player.Score = 0
+			stripped[i] = player
+		}
+		return stripped
+	}
+	if !reflect.DeepEqual(withoutScores(snapshot.Players), withoutScores(previous.Players)) || !reflect.DeepEqual(snapshot.GameOver, previous.GameOver) || snapshot.Wave != previous.Wave || snapshot.Waiting != previous.Waiting || snapshot.Countdown != previous.Countdown || snapshot.ScoreMultiplier != previous.ScoreMultiplier || snapshot.SuddenDeath != previous.SuddenDeath {
+		return true
+	}
+
+	if len(snapshot.Paddles) != len(previous.Paddles) {
+		return true
+	}
+	for i, paddle := range snapshot.Paddles {
+		last := previous.Paddles[i]
+		if paddle.Index != last.Index || paddle.Width != last.Width || paddle.Height != last.Height || paddle.Dashing != last.Dashing {
+			return true
+		}
+		if movedBeyond(paddle.X, last.X) || movedBeyond(paddle.Y, last.Y) {
+			return true
+		}
+	}
+
+	if len(snapshot.Balls) != len(previous.Balls) {
+		return true
+	}
+	previousBalls := make(map[int]Ball, len(previous.Balls))
+	for _, ball := range previous.Balls {
+		previousBalls[ball.Id] = ball
+	}
+	for _, ball := range snapshot.Balls {
+		last, ok := previousBalls[ball.Id]
+		if !ok {
+			return true
+		}
+		if ball.OwnerIndex != last.OwnerIndex || ball.Radius != last.Radius || ball.Mass != last.Mass || ball.Phasing != last.Phasing || ball.Ghost != last.Ghost || ball.Layer != last.Layer {
+			return true
+		}
+		if movedBeyond(ball.X, last.X) || movedBeyond(ball.Y, last.Y) || movedBeyond(ball.Vx, last.Vx) || movedBeyond(ball.Vy, last.Vy) {
+			return true
+		}
+	}
+
+	if len(snapshot.Lasers) != len(previous.Lasers) {
+		return true
+	}
+	for i, laser := range snapshot.Lasers {
+		last := previous.Lasers[i]
+		if laser.Id != last.Id || movedBeyond(laser.X, last.X) || movedBeyond(laser.Y, last.Y) {
+			return true
+		}
+	}
+
+	return !snapshot.Grid.Compare(previous.Grid)
+}
+
+type Scoreboard struct {
+	MessageType string `json:"messageType"`
+	Scores      [4]int `json:"scores"`
+	TeamScore   int64  `json:"teamScore,omitempty"`
+}
+
+func (snapshot GameSnapshot) Scoreboard() Scoreboard {
+	scoreboard := Scoreboard{MessageType: "scoreboard", TeamScore: snapshot.TeamScore}
+	for _, player := range snapshot.Players {
+		scoreboard.Scores[player.Index] = player.Score
+	}
+	return scoreboard
+}
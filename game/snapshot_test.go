@@ -0,0 +1,104 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGame_Snapshot(t *testing.T) {
+	game := StartGame()
+	game.Players[1] = &Player{Index: 1, Id: "player1", Score: 42, Connected: true}
+	game.Paddles[1] = &Paddle{Index: 1, X: 10, Y: 20}
+	game.Balls = []*Ball{{Id: 7, X: 30, Y: 40, OwnerIndex: 1}}
+
+	snapshot := game.Snapshot()
+
+	if len(snapshot.Players) != 1 || snapshot.Players[0].Score != 42 || !snapshot.Players[0].Connected {
+		t.Errorf("Expected one connected player with score 42, got %v", snapshot.Players)
+	}
+	if len(snapshot.Paddles) != 1 || snapshot.Paddles[0].X != 10 {
+		t.Errorf("Expected one paddle at x 10, got %v", snapshot.Paddles)
+	}
+	if len(snapshot.Balls) != 1 || snapshot.Balls[0].Id != 7 {
+		t.Errorf("Expected one ball with id 7, got %v", snapshot.Balls)
+	}
+	if !snapshot.Grid.Compare(game.Canvas.Grid) {
+		t.Errorf("Expected snapshot grid to match the game grid")
+	}
+
+	game.Balls[0].X = 100
+	if snapshot.Balls[0].X != 30 {
+		t.Errorf("Expected snapshot to be unaffected by later ball moves, got x %d", snapshot.Balls[0].X)
+	}
+}
+
+func TestGame_RequestSnapshot(t *testing.T) {
+	game := StartGame()
+
+	_, ok := game.RequestSnapshot(10 * time.Millisecond)
+	if ok {
+		t.Errorf("Expected snapshot request to time out without a game routine")
+	}
+
+	go game.ReadGameChannel()
+	snapshot, ok := game.RequestSnapshot(time.Second)
+	if !ok {
+		t.Fatalf("Expected snapshot request to be answered by the game routine")
+	}
+	if len(snapshot.Grid) != len(game.Canvas.Grid) {
+		t.Errorf("Expected snapshot grid of size %d, got %d", len(game.Canvas.Grid), len(snapshot.Grid))
+	}
+}
+
+func TestGameSnapshot_Differs(t *testing.T) {
+	base := func() GameSnapshot {
+		return GameSnapshot{
+			Players: []PlayerSnapshot{{Index: 0, Score: 100, Connected: true}},
+			Paddles: []Paddle{{Index: 0, X: 10, Y: 20, Width: 5, Height: 30}},
+			Balls:   []Ball{{Id: 1, X: 50, Y: 50, Vx: 2, Vy: 3, Radius: 4, Mass: 1}},
+			Grid:    NewGrid(6),
+		}
+	}
+
+	testCases := []struct {
+		name    string
+		change  func(snapshot *GameSnapshot)
+		epsilon int
+		differs bool
+	}{
+		{"Idle room", func(snapshot *GameSnapshot) {}, 0, false},
+		{"Paddle moved", func(snapshot *GameSnapshot) { snapshot.Paddles[0].X++ }, 0, true},
+		{"Paddle moved within epsilon", func(snapshot *GameSnapshot) { snapshot.Paddles[0].X += 2 }, 2, false},
+		{"Ball moved beyond epsilon", func(snapshot *GameSnapshot) { snapshot.Balls[0].Y += 3 }, 2, true},
+		{"Ball velocity changed", func(snapshot *GameSnapshot) { snapshot.Balls[0].Vx = -2 }, 2, true},
+		{"Ball phasing", func(snapshot *GameSnapshot) { snapshot.Balls[0].Phasing = true }, 2, true},
+		{"Ball replaced", func(snapshot *GameSnapshot) { snapshot.Balls[0].Id = 2 }, 0, true},
+		{"Ball removed", func(snapshot *GameSnapshot) { snapshot.Balls = []Ball{} }, 0, true},
+		{"Score changed", func(snapshot *GameSnapshot) { snapshot.Players[0].Score++ }, 2, false},
+		{"Player disconnected", func(snapshot *GameSnapshot) { snapshot.Players[0].Connected = false }, 2, true},
+		{"Brick damaged", func(snapshot *GameSnapshot) { snapshot.Grid[0][0].Data.Life = 1 }, 2, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			previous := base()
+			current := base()
+			tc.change(&current)
+			result := current.Differs(previous, tc.epsilon)
+			if result != tc.differs {
+				t.Errorf("Expected Differs to be %v, got %v", tc.differs, result)
+			}
+		})
+	}
+}
+
+func TestGameSnapshot_Scoreboard(t *testing.T) {
+	snapshot := GameSnapshot{Players: []PlayerSnapshot{{Index: 1, Score: 90}, {Index: 3, Score: 120}}}
+
+	scoreboard := snapshot.Scoreboard()
+
+	expected := Scoreboard{MessageType: "scoreboard", Scores: [4]int{0, 90, 0, 120}}
+	if scoreboard != expected {
+		t.Errorf("Expected scoreboard %+v, got %+v", expected, scoreboard)
+	}
+}
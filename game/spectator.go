@@ -0,0 +1,43 @@
+package game
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/lguibr/pongo/utils"
+	"golang.org/x/net/websocket"
+)
+
+func (game *Game) Spectate(ws *websocket.Conn, codec Codec, compression Compression, close func()) {
+	//INFO Spectators only receive the game state, they never take a player slot
+	ws.PayloadType = codec.PayloadType()
+	atomic.AddInt64(&game.spectators, 1)
+	go game.SyncServerTime(ws, codec, game.config.ServerTimeInterval, nil)
+	go game.WriteGameState(ws, codec, compression)
+	go DiscardInput(ws, func() {
+		atomic.AddInt64(&game.spectators, -1)
+		close()
+	})
+}
+
+// INFO Streams the game to a writer that can't send input, counted as a spectator until the stream fails
+func (game *Game) SpectateStream(w io.Writer, codec Codec, compression Compression) {
+	atomic.AddInt64(&game.spectators, 1)
+	defer atomic.AddInt64(&game.spectators, -1)
+	game.WriteGameState(w, codec, compression)
+}
+
+func DiscardInput(ws *websocket.Conn, close func()) {
+	defer close()
+
+	buffer := make([]byte, 1024)
+	for {
+		_, err := ws.Read(buffer)
+		if err != nil {
+			if err != io.EOF {
+				utils.LogWarn("Error reading from spectator", "err", err)
+			}
+			return
+		}
+	}
+}
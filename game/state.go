@@ -0,0 +1,45 @@
+package game
+
+import "sync"
+
+// INFO Runs update holding the game state lock, balls and paddles built outside a game have none and just run it
+func withState(state sync.Locker, update func()) {
+	if state != nil {
+		state.Lock()
+		defer state.Unlock()
+	}
+	update()
+}
+
+// INFO Sends messages to the game routine in order without waiting for it, for callers holding the state lock the game routine needs to handle them
+func (game *Game) post(messages ...GameMessage) {
+	if len(messages) == 0 {
+		return
+	}
+	go func() {
+		for _, message := range messages {
+			game.channel <- message
+		}
+	}()
+}
+
+// INFO Messages for other routines decided while holding the state lock, they are sent once it is released since those routines need it too
+type pendingSends []func()
+
+func (sends *pendingSends) toGame(game *Game, messages ...GameMessage) {
+	*sends = append(*sends, func() {
+		for _, message := range messages {
+			game.channel <- message
+		}
+	})
+}
+
+func (sends *pendingSends) toPlayer(player *Player, message PlayerMessage) {
+	*sends = append(*sends, func() { player.channel <- message })
+}
+
+func (sends pendingSends) send() {
+	for _, send := range sends {
+		send()
+	}
+}
@@ -0,0 +1,69 @@
+package game
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithState(t *testing.T) {
+	ran := false
+	withState(nil, func() { ran = true })
+	if !ran {
+		t.Errorf("Expected the update to run without a lock")
+	}
+
+	mutex := &sync.Mutex{}
+	withState(mutex, func() {
+		if mutex.TryLock() {
+			t.Errorf("Expected the lock to be held during the update")
+		}
+	})
+	if !mutex.TryLock() {
+		t.Errorf("Expected the lock to be released after the update")
+	}
+}
+
+func TestGame_Post(t *testing.T) {
+	game := StartGame()
+
+	game.mutex.Lock()
+	game.post(RemoveBall{Id: 1}, RemoveBall{Id: 2}, SlotFreed{})
+	game.mutex.Unlock()
+
+	received := []GameMessage{}
+	for len(received) < 3 {
+		select {
+		case message := <-game.channel:
+			received = append(received, message)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the posted messages to arrive, got %+v", received)
+		}
+	}
+	expected := []GameMessage{RemoveBall{Id: 1}, RemoveBall{Id: 2}, SlotFreed{}}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected the messages in the order they were posted %+v, got %+v", expected, received)
+	}
+}
+
+func TestPendingSends(t *testing.T) {
+	game := StartGame()
+	game.channel = make(chan GameMessage, 2)
+	player := &Player{channel: make(chan PlayerMessage, 1)}
+
+	sends := pendingSends{}
+	sends.toPlayer(player, PlayerScore{2})
+	sends.toGame(game, CheckSuddenDeath{}, SlotFreed{})
+	if len(player.channel) != 0 || len(game.channel) != 0 {
+		t.Fatalf("Expected nothing to be sent before the sends are flushed")
+	}
+
+	sends.send()
+	if message := <-player.channel; message != (PlayerScore{2}) {
+		t.Errorf("Expected the player to get its score, got %+v", message)
+	}
+	if first, second := <-game.channel, <-game.channel; first != (CheckSuddenDeath{}) || second != (SlotFreed{}) {
+		t.Errorf("Expected the game messages in order, got %+v and %+v", first, second)
+	}
+}
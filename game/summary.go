@@ -0,0 +1,66 @@
+package game
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type GetSummary struct {
+	Reply chan GameSummary
+}
+
+type PlayerSummary struct {
+	Index     int    `json:"index"`
+	Name      string `json:"name"`
+	Color     [3]int `json:"color"`
+	Connected bool   `json:"connected"`
+	Afk       bool   `json:"afk"`
+}
+
+// INFO What a lobby needs to show a game without streaming it: who plays, who watches and whether a new player fits
+type GameSummary struct {
+	Players    []PlayerSummary `json:"players"`
+	MaxPlayers int             `json:"maxPlayers"`
+	Spectators int             `json:"spectators"`
+	InProgress bool            `json:"inProgress"`
+	Joinable   bool            `json:"joinable"`
+}
+
+func (game *Game) Summary() GameSummary {
+	summary := GameSummary{
+		Players:    []PlayerSummary{},
+		MaxPlayers: game.MaxPlayers(),
+		Spectators: int(atomic.LoadInt64(&game.spectators)),
+		//INFO Players reserving their slot during the reconnect grace period still count, their slot isn't free
+		Joinable: game.GetNextIndex() >= 0,
+	}
+	for _, player := range game.Players {
+		if player == nil {
+			continue
+		}
+		summary.Players = append(summary.Players, PlayerSummary{
+			Index:     player.Index,
+			Name:      player.Name,
+			Color:     player.Color,
+			Connected: player.Connected,
+			Afk:       player.Afk,
+		})
+	}
+	summary.InProgress = len(summary.Players) > 0 && !game.Waiting && game.GameOver == nil
+	return summary
+}
+
+func (game *Game) RequestSummary(timeout time.Duration) (GameSummary, bool) {
+	reply := make(chan GameSummary, 1)
+	select {
+	case game.channel <- GetSummary{Reply: reply}:
+	case <-time.After(timeout):
+		return GameSummary{}, false
+	}
+	select {
+	case summary := <-reply:
+		return summary, true
+	case <-time.After(timeout):
+		return GameSummary{}, false
+	}
+}
@@ -0,0 +1,51 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestGame_Summary(t *testing.T) {
+	config := utils.DefaultConfig()
+	config.PlayerCount = 2
+	game := StartGameWithConfig(config)
+	game.spectators = 3
+
+	summary := game.Summary()
+	if summary.InProgress || !summary.Joinable || summary.MaxPlayers != 2 || summary.Spectators != 3 || len(summary.Players) != 0 {
+		t.Errorf("Expected an empty joinable game, got %+v", summary)
+	}
+
+	game.Players[0] = &Player{Index: 0, Name: "ada", Color: [3]int{1, 2, 3}, Connected: true}
+	game.Players[2] = &Player{Index: 2, Name: "bob", Color: [3]int{4, 5, 6}, Afk: true}
+	summary = game.Summary()
+	expected := []PlayerSummary{
+		{Index: 0, Name: "ada", Color: [3]int{1, 2, 3}, Connected: true},
+		{Index: 2, Name: "bob", Color: [3]int{4, 5, 6}, Afk: true},
+	}
+	if !reflect.DeepEqual(summary.Players, expected) {
+		t.Errorf("Expected the players %+v, got %+v", expected, summary.Players)
+	}
+	if !summary.InProgress {
+		t.Errorf("Expected a game with players to be in progress")
+	}
+	if summary.Joinable {
+		t.Errorf("Expected a game with every slot taken not to be joinable")
+	}
+}
+
+func TestGame_RequestSummary(t *testing.T) {
+	game := StartGame()
+	go game.ReadGameChannel()
+
+	summary, ok := game.RequestSummary(time.Second)
+	if !ok {
+		t.Fatalf("Expected the game to answer")
+	}
+	if summary.MaxPlayers != 4 {
+		t.Errorf("Expected four slots, got %d", summary.MaxPlayers)
+	}
+}
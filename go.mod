@@ -4,10 +4,12 @@ go 1.19
 
 require (
 	github.com/lguibr/asciiring v0.0.0-20230807134012-b571572dd6ee
+	github.com/vmihailenco/msgpack/v5 v5.4.1
 	golang.org/x/net v0.14.0
 )
 
 require (
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	golang.org/x/image v0.11.0 // indirect
 	golang.org/x/sys v0.11.0 // indirect
 )
@@ -1,24 +1,104 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/lguibr/pongo/game"
 	"github.com/lguibr/pongo/server"
+	"github.com/lguibr/pongo/utils"
 	"golang.org/x/net/websocket"
 )
 
 var port = ":3001"
 
+const shutdownTimeout = 10 * time.Second
+
 func main() {
-	g := game.StartGame()
-	go g.ReadGameChannel()
+	config := utils.DefaultConfig()
+	path := os.Getenv("PONGO_CONFIG")
+	if path != "" {
+		loaded, err := utils.LoadConfig(path)
+		if err != nil {
+			panic(err)
+		}
+		config = loaded
+	}
+	err := config.Validate()
+	if err != nil {
+		panic("invalid config: " + err.Error())
+	}
+	if config.MapFile != "" {
+		_, err := game.LoadMap(config.MapFile, utils.GridSize)
+		if err != nil {
+			panic("invalid map: " + err.Error())
+		}
+	}
+	logLevel, _ := utils.ParseLogLevel(config.LogLevel)
+	utils.SetLogLevel(logLevel)
+	if path != "" {
+		utils.LogInfo("Loaded config", "path", path)
+	}
+
+	leaderboard := game.NewLeaderboard()
+	go leaderboard.ReadLeaderboardChannel()
+
+	//INFO A main game that stops answering health checks is replaced by a fresh one with the same config
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	mainGame := server.NewMainGame(watchCtx, config, leaderboard)
 
 	websocketServer := server.New()
-	fmt.Println("Server started on port", port)
-	http.HandleFunc("/", websocketServer.HandleGetSit(g))
-	http.Handle("/subscribe", websocket.Handler(websocketServer.HandleSubscribe(g)))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", websocketServer.HandleGetSit(mainGame))
+	mux.HandleFunc("/state", websocketServer.HandleGetState(mainGame))
+	mux.Handle("/stream", websocketServer.LimitConnections(config.MaxConnections, http.HandlerFunc(websocketServer.HandleStream(mainGame))))
+	mux.HandleFunc("/health", websocketServer.HandleGetHealth(mainGame))
+	mux.HandleFunc("/metrics", websocketServer.HandleGetMetrics(mainGame))
+	mux.HandleFunc("/metrics/prometheus", websocketServer.HandleGetPrometheus(mainGame))
+	mux.HandleFunc("/admin/", websocketServer.HandleAdminAction(mainGame, config.AdminToken))
+	mux.HandleFunc("/rooms", websocketServer.HandleCreateRoom(config, leaderboard))
+	mux.HandleFunc("/rooms/", websocketServer.HandleListRooms())
+	mux.HandleFunc("/events", websocketServer.HandleRoomEvents(mainGame))
+	mux.HandleFunc("/maps/validate", websocketServer.HandleValidateMap())
+	mux.HandleFunc("/leaderboard", websocketServer.HandleGetLeaderboard(leaderboard))
+	mux.Handle("/subscribe", websocketServer.LimitConnections(config.MaxConnections, websocket.Server{
+		Handler:   websocketServer.HandleSubscribe(mainGame),
+		Handshake: server.CheckOrigin(config.AllowedOrigins),
+	}))
+
+	httpServer := &http.Server{Addr: port, Handler: mux}
+	httpServer.RegisterOnShutdown(websocketServer.CloseStreams)
+	go func() {
+		utils.LogInfo("Server started", "port", port)
+		err := httpServer.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			panic(err)
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	sig := <-signals
+	utils.LogInfo("Shutting down server", "signal", sig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-	panic(http.ListenAndServe(port, nil))
+	//INFO Stop accepting connections, let players see the game over and then drop the websockets
+	err = httpServer.Shutdown(ctx)
+	if err != nil {
+		utils.LogError("Error shutting down http server", "err", err)
+	}
+	if !mainGame.Get().Shutdown(ctx) {
+		utils.LogError("Game did not acknowledge the shutdown in time")
+	}
+	websocketServer.ShutdownRooms(ctx)
+	websocketServer.CloseConnections()
+	utils.LogInfo("Server stopped")
 }
@@ -1,33 +1,241 @@
 package server
 
 import (
-	"fmt"
+	"crypto/subtle"
+	"encoding/json"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/lguibr/pongo/game"
+	"github.com/lguibr/pongo/utils"
 
 	"golang.org/x/net/websocket"
 )
 
-func (s *Server) HandleSubscribe(g *game.Game) func(ws *websocket.Conn) {
+// INFO A map is a few hundred cells, anything far bigger is not one
+const maxMapBytes = 1 << 20
+
+func (s *Server) HandleSubscribe(mainGame *MainGame) func(ws *websocket.Conn) {
 	return func(ws *websocket.Conn) {
 		//INFO Open WebSocket connection
 		s.OpenConnection(ws)
-		//INFO Start Game lifecycle
-		go g.LifeCycle(ws, func() { s.CloseConnection(ws) })
+		close := func() { s.CloseConnection(ws) }
+		query := ws.Request().URL.Query()
+		codec := game.CodecFromString(query.Get("codec"))
+		compression := game.CompressionFromString(query.Get("compress"))
+		reconnectToken := query.Get("token")
+		//INFO Private rooms are joined through the room in their join link, everyone else plays the main game
+		g, ok := s.requestedGame(mainGame, query)
+		if !ok {
+			err := game.WriteStatus(ws, codec, game.StatusMessage{MessageType: "rejected", Reason: "unknown room"})
+			if err != nil {
+				utils.LogError("Error writing unknown room status to client", "err", err)
+			}
+			close()
+			return
+		}
+		if query.Get("spectate") == "true" {
+			//INFO Spectators watch the game without a paddle
+			go g.Spectate(ws, codec, compression, close)
+		} else if reconnectToken == "" || !g.Reconnect(ws, reconnectToken, codec, compression, close) {
+			//INFO Rebind a returning player to its reserved slot, otherwise start a new Game lifecycle
+			go g.LifeCycle(ws, codec, compression, query.Get("playerName"), query.Get("clientId"), close)
+		}
 		//INFO Keep WebSocket connection open
 		s.KeepConnection(ws)
 	}
 }
 
-func (s *Server) HandleGetSit(g *game.Game) func(w http.ResponseWriter, r *http.Request) {
+// INFO Streams the main game or the room named by ?room= to a spectator as server sent events, for tools that can't use websockets
+func (s *Server) HandleStream(mainGame *MainGame) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g, ok := s.requestedGame(mainGame, r.URL.Query())
+		if !ok {
+			http.Error(w, "unknown room", http.StatusNotFound)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		g.SpectateStream(eventStreamWriter{r.Context(), s.streamsDone, w, flusher}, game.JSONCodec, game.NoCompression)
+	}
+}
+
+func (s *Server) HandleGetSit(mainGame *MainGame) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		g := mainGame.Get()
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_, err := io.WriteString(w, string(g.ToJson()))
 		if err != nil {
-			fmt.Println("Error writing to client: ", err)
+			utils.LogError("Error writing to client", "err", err)
+		}
+	}
+}
+
+func (s *Server) HandleGetState(mainGame *MainGame) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g := mainGame.Get()
+		snapshot, ok := g.RequestSnapshot(time.Second)
+		if !ok {
+			http.Error(w, "game is not responding", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(snapshot)
+		if err != nil {
+			utils.LogError("Error writing to client", "err", err)
+		}
+	}
+}
+
+func (s *Server) HandleGetLeaderboard(leaderboard *game.Leaderboard) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 {
+			limit = 10
+		}
+		entries, ok := leaderboard.RequestTop(limit, time.Second)
+		if !ok {
+			http.Error(w, "leaderboard is not responding", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(entries)
+		if err != nil {
+			utils.LogError("Error writing to client", "err", err)
+		}
+	}
+}
+
+func (s *Server) HandleGetMetrics(mainGame *MainGame) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		games, ok := s.requestMetrics(mainGame, time.Second)
+		if !ok {
+			http.Error(w, "game is not responding", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(game.AggregateMetrics(games))
+		if err != nil {
+			utils.LogError("Error writing to client", "err", err)
+		}
+	}
+}
+
+// INFO Only the main game not answering fails the check, a wedged room is counted and left to its own health check
+func (s *Server) HandleGetHealth(mainGame *MainGame) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		main, ok := mainGame.Get().RequestHealth(time.Second)
+		if !ok {
+			http.Error(w, "game is not responding", http.StatusServiceUnavailable)
+			return
+		}
+		_, rooms := s.rooms.games()
+		healths := requestAll(rooms, func(_ int, g *game.Game) (game.GameHealth, bool) { return g.RequestHealth(time.Second) })
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(game.AggregateHealth(main, healths, len(rooms)-len(healths)))
+		if err != nil {
+			utils.LogError("Error writing to client", "err", err)
+		}
+	}
+}
+
+// INFO Metrics of the main game followed by every private room that answered, labeled main and by room name
+func (s *Server) requestMetrics(mainGame *MainGame, timeout time.Duration) ([]game.GameMetrics, bool) {
+	main, ok := mainGame.Get().RequestMetrics(timeout)
+	if !ok {
+		return nil, false
+	}
+	main.Room = "main"
+	names, rooms := s.rooms.games()
+	metrics := requestAll(rooms, func(index int, g *game.Game) (game.GameMetrics, bool) {
+		gameMetrics, ok := g.RequestMetrics(timeout)
+		gameMetrics.Room = names[index]
+		return gameMetrics, ok
+	})
+	return append([]game.GameMetrics{main}, metrics...), true
+}
+
+// INFO Serves /admin/{action} where action is end or reset, only for requests carrying the configured admin token
+func (s *Server) HandleAdminAction(mainGame *MainGame, adminToken string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g := mainGame.Get()
+		token := r.Header.Get("X-Admin-Token")
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ok := false
+		switch strings.TrimPrefix(r.URL.Path, "/admin/") {
+		case "end":
+			ok = g.ForceGameOver("ended by an admin", time.Second)
+		case "reset":
+			ok = g.RequestReset(time.Second)
+		default:
+			http.Error(w, "unknown action", http.StatusNotFound)
+			return
+		}
+		if !ok {
+			http.Error(w, "game is not responding", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// INFO Checks a map posted by an editor and answers with every issue found, symmetric=true also requires mirrored quarters
+func (s *Server) HandleValidateMap() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMapBytes))
+		if err != nil {
+			http.Error(w, "map is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		symmetric := r.URL.Query().Get("symmetric") == "true"
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(game.ValidateMap(data, utils.GridSize, symmetric))
+		if err != nil {
+			utils.LogError("Error writing to client", "err", err)
+		}
+	}
+}
+
+func (s *Server) HandleGetPrometheus(mainGame *MainGame) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		games, ok := s.requestMetrics(mainGame, time.Second)
+		if !ok {
+			http.Error(w, "game is not responding", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.WriteHeader(http.StatusOK)
+		err := game.AggregateMetrics(games).WritePrometheus(w)
+		if err != nil {
+			utils.LogError("Error writing to client", "err", err)
 		}
 	}
 }
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lguibr/pongo/game"
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestServer_HandleGetMetrics_Rooms(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := New()
+	defer server.rooms.cancel()
+	mainGame := NewMainGame(ctx, utils.DefaultConfig(), game.NewLeaderboard())
+	_, name, _ := server.rooms.Create(utils.DefaultConfig(), game.NewLeaderboard())
+
+	recorder := httptest.NewRecorder()
+	server.HandleGetMetrics(mainGame)(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected the metrics, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	metrics := game.ServerMetrics{}
+	if err := json.NewDecoder(recorder.Body).Decode(&metrics); err != nil {
+		t.Fatalf("Expected the server metrics, got %v", err)
+	}
+	if metrics.Rooms != 2 || len(metrics.Games) != 2 {
+		t.Fatalf("Expected the main game and the room, got %+v", metrics)
+	}
+	if metrics.Games[0].Room != "main" || metrics.Games[1].Room != name {
+		t.Errorf("Expected the games labeled main and %q, got %q and %q", name, metrics.Games[0].Room, metrics.Games[1].Room)
+	}
+	if metrics.MaxPlayers != 2*utils.DefaultConfig().PlayerCount {
+		t.Errorf("Expected the slots of both games, got %d", metrics.MaxPlayers)
+	}
+
+	recorder = httptest.NewRecorder()
+	server.HandleGetPrometheus(mainGame)(recorder, httptest.NewRequest(http.MethodGet, "/metrics/prometheus", nil))
+	for _, expected := range []string{"pongo_active_rooms 2", `pongo_physics_ticks_total{room="main"}`, `pongo_physics_ticks_total{room="` + name + `"}`} {
+		if !strings.Contains(recorder.Body.String(), expected) {
+			t.Errorf("Expected %q in the exposition, got:\n%s", expected, recorder.Body.String())
+		}
+	}
+}
+
+func TestServer_HandleGetHealth_Rooms(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := New()
+	defer server.rooms.cancel()
+	mainGame := NewMainGame(ctx, utils.DefaultConfig(), game.NewLeaderboard())
+	server.rooms.Create(utils.DefaultConfig(), game.NewLeaderboard())
+
+	recorder := httptest.NewRecorder()
+	server.HandleGetHealth(mainGame)(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected the health, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	health := game.ServerHealth{}
+	if err := json.NewDecoder(recorder.Body).Decode(&health); err != nil {
+		t.Fatalf("Expected the server health, got %v", err)
+	}
+	if !health.Ready || health.Rooms != 2 || health.UnresponsiveRooms != 0 {
+		t.Errorf("Expected a ready server with both games answering, got %+v", health)
+	}
+}
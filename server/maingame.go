@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lguibr/pongo/game"
+	"github.com/lguibr/pongo/utils"
+)
+
+// INFO Starts a game with the loops every game runs until ctx is done, a game that stops answering health checks is handed to onWedged
+func runGame(ctx context.Context, config utils.Config, leaderboard *game.Leaderboard, onWedged func()) *game.Game {
+	g := game.StartGameWithConfig(config)
+	g.SetLeaderboard(leaderboard)
+	go g.ReadGameChannel()
+	go g.WatchHealth(ctx, config.HealthCheckInterval, config.HealthCheckTimeout, config.HealthCheckFailures, onWedged)
+	go g.TickBalls(ctx)
+	go g.RubberBand(ctx)
+	go g.ScoreMultiplierEvents(ctx)
+	go g.WatchIdlePlayers(ctx)
+	return g
+}
+
+// INFO The game everyone plays unless they join a private room, a wedged one is replaced by a fresh game with the same config
+type MainGame struct {
+	mutex       sync.Mutex
+	game        *game.Game
+	gameCtx     context.Context
+	stop        context.CancelFunc
+	ctx         context.Context
+	config      utils.Config
+	leaderboard *game.Leaderboard
+}
+
+func NewMainGame(ctx context.Context, config utils.Config, leaderboard *game.Leaderboard) *MainGame {
+	mainGame := &MainGame{ctx: ctx, config: config, leaderboard: leaderboard}
+	mainGame.mutex.Lock()
+	defer mainGame.mutex.Unlock()
+	mainGame.start()
+	return mainGame
+}
+
+// INFO The game new requests go to, it changes once the previous one got wedged
+func (mainGame *MainGame) Get() *game.Game {
+	mainGame.mutex.Lock()
+	defer mainGame.mutex.Unlock()
+	return mainGame.game
+}
+
+// INFO Called with the mutex held
+func (mainGame *MainGame) start() {
+	ctx, stop := context.WithCancel(mainGame.ctx)
+	mainGame.game = runGame(ctx, mainGame.config, mainGame.leaderboard, func() { mainGame.replace(ctx) })
+	mainGame.gameCtx, mainGame.stop = ctx, stop
+}
+
+// INFO Stops the loops of the wedged game started with ctx and starts a fresh one, its routine can't be stopped so it is left behind
+func (mainGame *MainGame) replace(ctx context.Context) {
+	mainGame.mutex.Lock()
+	defer mainGame.mutex.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	mainGame.stop()
+	mainGame.start()
+	utils.LogWarn("Replaced the wedged main game")
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lguibr/pongo/game"
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestMainGame_Replace(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mainGame := NewMainGame(ctx, utils.DefaultConfig(), game.NewLeaderboard())
+	wedged, wedgedCtx := mainGame.Get(), mainGame.gameCtx
+
+	mainGame.replace(wedgedCtx)
+	replacement := mainGame.Get()
+	if replacement == wedged {
+		t.Fatalf("Expected the wedged game to be replaced")
+	}
+	if wedgedCtx.Err() == nil {
+		t.Errorf("Expected the loops of the wedged game to be stopped")
+	}
+	if mainGame.gameCtx.Err() != nil {
+		t.Errorf("Expected the replacement to run its loops")
+	}
+
+	//INFO The wedged game's watch only ever reports once, a late report must not replace the new game
+	mainGame.replace(wedgedCtx)
+	if mainGame.Get() != replacement {
+		t.Errorf("Expected a stale report to leave the replacement alone")
+	}
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/websocket"
+)
+
+// INFO Origins in the allow-list are accepted as is, "*" accepts any origin, everything else falls back to the default websocket check
+func CheckOrigin(allowedOrigins []string) func(config *websocket.Config, req *http.Request) error {
+	return func(config *websocket.Config, req *http.Request) (err error) {
+		origin := req.Header.Get("Origin")
+		for _, allowedOrigin := range allowedOrigins {
+			if allowedOrigin != "*" && allowedOrigin != origin {
+				continue
+			}
+			if origin == "" {
+				return nil
+			}
+			config.Origin, err = url.ParseRequestURI(origin)
+			return err
+		}
+
+		config.Origin, err = websocket.Origin(config, req)
+		if err == nil && config.Origin == nil {
+			return fmt.Errorf("null origin")
+		}
+		return err
+	}
+}
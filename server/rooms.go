@@ -0,0 +1,392 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lguibr/pongo/game"
+	"github.com/lguibr/pongo/utils"
+)
+
+// INFO Only settings that shape a match can be picked for a private room, everything operational stays with the server config
+type RoomOverrides struct {
+	PlayerCount          *int           `json:"playerCount"`
+	MaxGameDuration      *time.Duration `json:"maxGameDuration"`
+	SteelBrickRatio      *float64       `json:"steelBrickRatio"`
+	ExplosiveBrickChance *float64       `json:"explosiveBrickChance"`
+	CenterObstacle       *string        `json:"centerObstacle"`
+	EndlessMode          *bool          `json:"endlessMode"`
+	CoopMode             *bool          `json:"coopMode"`
+}
+
+func (overrides RoomOverrides) Apply(config utils.Config) utils.Config {
+	if overrides.PlayerCount != nil {
+		config.PlayerCount = *overrides.PlayerCount
+	}
+	if overrides.MaxGameDuration != nil {
+		config.MaxGameDuration = *overrides.MaxGameDuration
+	}
+	if overrides.SteelBrickRatio != nil {
+		config.SteelBrickRatio = *overrides.SteelBrickRatio
+	}
+	if overrides.ExplosiveBrickChance != nil {
+		config.ExplosiveBrickChance = *overrides.ExplosiveBrickChance
+	}
+	if overrides.CenterObstacle != nil {
+		config.CenterObstacle = *overrides.CenterObstacle
+	}
+	if overrides.EndlessMode != nil {
+		config.EndlessMode = *overrides.EndlessMode
+	}
+	if overrides.CoopMode != nil {
+		config.CoopMode = *overrides.CoopMode
+	}
+	return config
+}
+
+type CreatedRoom struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	JoinURL string `json:"joinUrl"`
+}
+
+type RoomListEntry struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	//INFO Missing when the room didn't answer in time
+	*game.GameSummary
+}
+
+type room struct {
+	name string
+	game *game.Game
+	stop context.CancelFunc
+}
+
+// INFO Private rooms run next to the main game until the server stops or they get wedged or idle, each with its own game loop
+type Rooms struct {
+	mutex  sync.Mutex
+	rooms  map[string]room
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewRooms() *Rooms {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Rooms{rooms: make(map[string]room), ctx: ctx, cancel: cancel}
+}
+
+// INFO Starts a room unless config.MaxRooms are already open
+func (rooms *Rooms) Create(config utils.Config, leaderboard *game.Leaderboard) (id, name string, ok bool) {
+	rooms.mutex.Lock()
+	defer rooms.mutex.Unlock()
+	if config.MaxRooms > 0 && len(rooms.rooms) >= config.MaxRooms {
+		return "", "", false
+	}
+	id = newRoomId()
+	name = newRoomName(rooms.nameTaken)
+	ctx, stop := context.WithCancel(rooms.ctx)
+	g := runGame(ctx, config, leaderboard, func() { rooms.remove(id, "wedged") })
+	rooms.rooms[id] = room{name: name, game: g, stop: stop}
+	go rooms.closeWhenIdle(ctx, id, g, config.RoomIdleTimeout)
+	return id, name, true
+}
+
+// INFO Drops a room so nobody joins it anymore, its routine can't be stopped so only its loops end
+func (rooms *Rooms) remove(id, reason string) {
+	rooms.mutex.Lock()
+	defer rooms.mutex.Unlock()
+	room, ok := rooms.rooms[id]
+	if !ok {
+		return
+	}
+	room.stop()
+	delete(rooms.rooms, id)
+	utils.LogInfo("Closed private room", "room", id, "name", room.name, "reason", reason)
+}
+
+// INFO Closes the room once nobody played or watched in it for idleTimeout, a new room gets as long for its first players to join
+func (rooms *Rooms) closeWhenIdle(ctx context.Context, id string, g *game.Game, idleTimeout time.Duration) {
+	if idleTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(idleTimeout / 4)
+	defer ticker.Stop()
+	lastUsed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		//INFO A room that doesn't answer is left to its health check
+		summary, ok := g.RequestSummary(time.Second)
+		if !ok {
+			continue
+		}
+		if len(summary.Players) > 0 || summary.Spectators > 0 {
+			lastUsed = time.Now()
+			continue
+		}
+		if time.Since(lastUsed) >= idleTimeout {
+			rooms.remove(id, "idle")
+			return
+		}
+	}
+}
+
+// INFO Finds a room by its id or by its name, names are unique so either one can go in a join link
+func (rooms *Rooms) Get(key string) (*game.Game, bool) {
+	rooms.mutex.Lock()
+	defer rooms.mutex.Unlock()
+	if room, ok := rooms.rooms[key]; ok {
+		return room.game, true
+	}
+	for _, room := range rooms.rooms {
+		if room.name == key {
+			return room.game, true
+		}
+	}
+	return nil, false
+}
+
+// INFO The main game unless ?room= names a private room by its id or name, false when no room goes by it
+func (s *Server) requestedGame(mainGame *MainGame, query url.Values) (*game.Game, bool) {
+	roomId := query.Get("room")
+	if roomId == "" {
+		return mainGame.Get(), true
+	}
+	return s.rooms.Get(roomId)
+}
+
+// INFO Rooms are asked for their summary outside the lock and in parallel, a busy room only leaves its own entry bare
+func (rooms *Rooms) List(timeout time.Duration) []RoomListEntry {
+	rooms.mutex.Lock()
+	entries := make([]RoomListEntry, 0, len(rooms.rooms))
+	games := make([]*game.Game, 0, len(rooms.rooms))
+	for id, room := range rooms.rooms {
+		entries = append(entries, RoomListEntry{Id: id, Name: room.name})
+		games = append(games, room.game)
+	}
+	rooms.mutex.Unlock()
+
+	var wait sync.WaitGroup
+	for index, g := range games {
+		wait.Add(1)
+		go func(index int, g *game.Game) {
+			defer wait.Done()
+			if summary, ok := g.RequestSummary(timeout); ok {
+				entries[index].GameSummary = &summary
+			}
+		}(index, g)
+	}
+	wait.Wait()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// INFO Snapshot of the open rooms sorted by name, so they can all be asked something outside the lock
+func (rooms *Rooms) games() (names []string, games []*game.Game) {
+	rooms.mutex.Lock()
+	defer rooms.mutex.Unlock()
+	names = make([]string, 0, len(rooms.rooms))
+	byName := make(map[string]*game.Game, len(rooms.rooms))
+	for _, room := range rooms.rooms {
+		names = append(names, room.name)
+		byName[room.name] = room.game
+	}
+	sort.Strings(names)
+	games = make([]*game.Game, len(names))
+	for index, name := range names {
+		games[index] = byName[name]
+	}
+	return names, games
+}
+
+// INFO Asks every game in parallel and keeps the answers in order, games that don't answer are left out
+func requestAll[T any](games []*game.Game, request func(index int, g *game.Game) (T, bool)) []T {
+	answers := make([]T, len(games))
+	answered := make([]bool, len(games))
+	var wait sync.WaitGroup
+	for index, g := range games {
+		wait.Add(1)
+		go func(index int, g *game.Game) {
+			defer wait.Done()
+			answers[index], answered[index] = request(index, g)
+		}(index, g)
+	}
+	wait.Wait()
+	kept := answers[:0]
+	for index, answer := range answers {
+		if answered[index] {
+			kept = append(kept, answer)
+		}
+	}
+	return kept
+}
+
+// INFO Called with the mutex held
+func (rooms *Rooms) nameTaken(name string) bool {
+	for _, room := range rooms.rooms {
+		if room.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// INFO Ends every private room like the main game, rooms not acknowledging before ctx expires are left behind
+func (rooms *Rooms) Shutdown(ctx context.Context) {
+	rooms.cancel()
+	rooms.mutex.Lock()
+	games := make([]*game.Game, 0, len(rooms.rooms))
+	for _, room := range rooms.rooms {
+		games = append(games, room.game)
+	}
+	rooms.mutex.Unlock()
+
+	var wait sync.WaitGroup
+	for _, g := range games {
+		wait.Add(1)
+		go func(g *game.Game) {
+			defer wait.Done()
+			if !g.Shutdown(ctx) {
+				utils.LogError("Room did not acknowledge the shutdown in time")
+			}
+		}(g)
+	}
+	wait.Wait()
+}
+
+var roomAdjectives = []string{
+	"amber", "bold", "brisk", "calm", "clever", "cosmic", "crimson", "daring", "eager", "fuzzy",
+	"gentle", "golden", "happy", "jolly", "lucky", "mighty", "nimble", "quiet", "rapid", "silver",
+	"sleepy", "snappy", "sunny", "swift", "tiny", "vivid", "wild", "witty", "zany", "zesty",
+}
+
+var roomNouns = []string{
+	"badger", "comet", "falcon", "ferret", "gecko", "hedgehog", "heron", "koala", "lemur", "lynx",
+	"meteor", "narwhal", "otter", "panda", "pebble", "penguin", "pixel", "puffin", "quasar", "raccoon",
+	"rocket", "salmon", "sparrow", "squid", "tiger", "toucan", "walrus", "whale", "wombat", "yak",
+}
+
+// INFO Picks an adjective-noun pair nobody uses yet, a number is added once the pairs run out
+func newRoomName(taken func(string) bool) string {
+	for attempt := 0; ; attempt++ {
+		name := roomAdjectives[randomIndex(len(roomAdjectives))] + "-" + roomNouns[randomIndex(len(roomNouns))]
+		if attempt >= len(roomAdjectives)*len(roomNouns) {
+			name += "-" + strconv.Itoa(attempt)
+		}
+		if !taken(name) {
+			return name
+		}
+	}
+}
+
+func randomIndex(n int) int {
+	index, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(index.Int64())
+}
+
+func newRoomId() string {
+	buffer := make([]byte, 8)
+	_, err := rand.Read(buffer)
+	if err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buffer)
+}
+
+// INFO Room settings are a handful of fields, anything far bigger is not one
+const maxRoomSettingsBytes = 4 << 10
+
+// INFO Creates a private room from the server config with the posted overrides and answers with a link players can share
+func (s *Server) HandleCreateRoom(base utils.Config, leaderboard *game.Leaderboard) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		overrides := RoomOverrides{}
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRoomSettingsBytes))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&overrides)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "room settings are too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, "invalid room settings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		config := overrides.Apply(base)
+		err = config.Validate()
+		if err != nil {
+			http.Error(w, "invalid room settings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id, name, ok := s.rooms.Create(config, leaderboard)
+		if !ok {
+			http.Error(w, "too many rooms", http.StatusServiceUnavailable)
+			return
+		}
+		scheme := "ws"
+		if r.TLS != nil {
+			scheme = "wss"
+		}
+		utils.LogInfo("Created private room", "room", id, "name", name)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		err = json.NewEncoder(w).Encode(CreatedRoom{Id: id, Name: name, JoinURL: scheme + "://" + r.Host + "/subscribe?room=" + name})
+		if err != nil {
+			utils.LogError("Error writing to client", "err", err)
+		}
+	}
+}
+
+func (s *Server) HandleListRooms() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(s.rooms.List(time.Second))
+		if err != nil {
+			utils.LogError("Error writing to client", "err", err)
+		}
+	}
+}
+
+// INFO Serves the recent event history of the main game, or of the private room named by ?room= with its id or name
+func (s *Server) HandleRoomEvents(mainGame *MainGame) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g, ok := s.requestedGame(mainGame, r.URL.Query())
+		if !ok {
+			http.Error(w, "unknown room", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode(g.Events())
+		if err != nil {
+			utils.LogError("Error writing to client", "err", err)
+		}
+	}
+}
+
+func (s *Server) ShutdownRooms(ctx context.Context) {
+	s.rooms.Shutdown(ctx)
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/game"
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestRoomOverrides_Apply(t *testing.T) {
+	base := utils.DefaultConfig()
+	if config := (RoomOverrides{}).Apply(base); config.PlayerCount != base.PlayerCount || config.CoopMode != base.CoopMode || config.MaxGameDuration != base.MaxGameDuration {
+		t.Errorf("Expected no overrides to keep the base config")
+	}
+
+	playerCount, duration, steel, explosive, obstacle, endless, coop := 2, time.Minute, 0.5, 0.25, "ring", true, true
+	config := RoomOverrides{
+		PlayerCount:          &playerCount,
+		MaxGameDuration:      &duration,
+		SteelBrickRatio:      &steel,
+		ExplosiveBrickChance: &explosive,
+		CenterObstacle:       &obstacle,
+		EndlessMode:          &endless,
+		CoopMode:             &coop,
+	}.Apply(base)
+	if config.PlayerCount != 2 || config.MaxGameDuration != time.Minute || config.SteelBrickRatio != 0.5 || config.ExplosiveBrickChance != 0.25 ||
+		config.CenterObstacle != "ring" || !config.EndlessMode || !config.CoopMode {
+		t.Errorf("Expected every override to be applied, got %+v", config)
+	}
+	if config.MaxConnections != base.MaxConnections || config.AdminToken != base.AdminToken {
+		t.Errorf("Expected the operational settings to stay with the base config")
+	}
+}
+
+func TestRooms_Create_MaxRooms(t *testing.T) {
+	rooms := NewRooms()
+	defer rooms.cancel()
+	config := utils.DefaultConfig()
+	config.MaxRooms = 2
+
+	first, _, ok := rooms.Create(config, game.NewLeaderboard())
+	if !ok {
+		t.Fatalf("Expected the first room to be created")
+	}
+	if _, _, ok := rooms.Create(config, game.NewLeaderboard()); !ok {
+		t.Fatalf("Expected the second room to be created")
+	}
+	if _, _, ok := rooms.Create(config, game.NewLeaderboard()); ok {
+		t.Errorf("Expected no room beyond maxRooms")
+	}
+
+	rooms.remove(first, "test")
+	if _, _, ok := rooms.Create(config, game.NewLeaderboard()); !ok {
+		t.Errorf("Expected a closed room to free its place")
+	}
+}
+
+func TestRooms_Remove(t *testing.T) {
+	rooms := NewRooms()
+	defer rooms.cancel()
+	id, name, _ := rooms.Create(utils.DefaultConfig(), game.NewLeaderboard())
+	if _, ok := rooms.Get(id); !ok {
+		t.Fatalf("Expected the room to be created")
+	}
+
+	rooms.remove(id, "wedged")
+	if _, ok := rooms.Get(id); ok {
+		t.Errorf("Expected the room to be dropped by id")
+	}
+	if _, ok := rooms.Get(name); ok {
+		t.Errorf("Expected the room to be dropped by name")
+	}
+	//INFO Removing a room that is already gone does nothing
+	rooms.remove(id, "wedged")
+}
+
+func TestRooms_CloseWhenIdle(t *testing.T) {
+	rooms := NewRooms()
+	defer rooms.cancel()
+	config := utils.DefaultConfig()
+	config.RoomIdleTimeout = 40 * time.Millisecond
+	id, _, _ := rooms.Create(config, game.NewLeaderboard())
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := rooms.Get(id); !ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the empty room to be closed once idle")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestServer_HandleCreateRoom(t *testing.T) {
+	server := New()
+	defer server.rooms.cancel()
+	config := utils.DefaultConfig()
+	config.MaxRooms = 1
+	handler := server.HandleCreateRoom(config, game.NewLeaderboard())
+	post := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(http.MethodPost, "http://pongo.test/rooms", strings.NewReader(body)))
+		return recorder
+	}
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/rooms", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected GET to be refused, got %d", recorder.Code)
+	}
+	testCases := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"not json", `{`, http.StatusBadRequest},
+		{"unknown setting", `{"adminToken":"mine"}`, http.StatusBadRequest},
+		{"invalid setting", `{"playerCount":3}`, http.StatusBadRequest},
+		{"negative duration", `{"maxGameDuration":-1}`, http.StatusBadRequest},
+		{"too large", `{"centerObstacle":"` + strings.Repeat("x", maxRoomSettingsBytes) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, testCase := range testCases {
+		if recorder := post(testCase.body); recorder.Code != testCase.expected {
+			t.Errorf("Expected %d for %s, got %d: %s", testCase.expected, testCase.name, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	recorder = post(`{"playerCount":2,"coopMode":true}`)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Expected the room to be created, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	created := CreatedRoom{}
+	if err := json.NewDecoder(recorder.Body).Decode(&created); err != nil {
+		t.Fatalf("Expected the created room, got %v", err)
+	}
+	if created.Id == "" || created.Name == "" || created.JoinURL != "ws://pongo.test/subscribe?room="+created.Name {
+		t.Errorf("Unexpected created room %+v", created)
+	}
+	if _, ok := server.rooms.Get(created.Id); !ok {
+		t.Errorf("Expected the created room to be registered")
+	}
+
+	if recorder := post(`{}`); recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 once maxRooms are open, got %d", recorder.Code)
+	}
+}
+
+func TestNewRoomName_Unique(t *testing.T) {
+	names := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		name := newRoomName(func(name string) bool { return names[name] })
+		if names[name] {
+			t.Fatalf("Expected a name nobody uses, got %q again", name)
+		}
+		if parts := strings.Split(name, "-"); len(parts) != 2 {
+			t.Errorf("Expected an adjective-noun pair while pairs are left, got %q", name)
+		}
+		names[name] = true
+	}
+}
+
+func TestNewRoomName_Fallback(t *testing.T) {
+	//INFO Every plain pair is taken, so only a numbered name can be picked
+	name := newRoomName(func(name string) bool { return strings.Count(name, "-") == 1 })
+	parts := strings.Split(name, "-")
+	if len(parts) != 3 {
+		t.Fatalf("Expected a numbered name once the pairs ran out, got %q", name)
+	}
+	if _, err := strconv.Atoi(parts[2]); err != nil {
+		t.Errorf("Expected a number after the pair, got %q", name)
+	}
+}
+
+func TestRooms_Get_ByName(t *testing.T) {
+	rooms := NewRooms()
+	defer rooms.cancel()
+	id, name, _ := rooms.Create(utils.DefaultConfig(), game.NewLeaderboard())
+	rooms.Create(utils.DefaultConfig(), game.NewLeaderboard())
+
+	byId, ok := rooms.Get(id)
+	if !ok {
+		t.Fatalf("Expected the room by its id")
+	}
+	byName, ok := rooms.Get(name)
+	if !ok || byName != byId {
+		t.Errorf("Expected the name %q to find the same room as its id", name)
+	}
+	if _, ok := rooms.Get("missing-room"); ok {
+		t.Errorf("Expected no room for an unknown name")
+	}
+}
+
+func TestServer_HandleListRooms(t *testing.T) {
+	server := New()
+	defer server.rooms.cancel()
+	id, name, _ := server.rooms.Create(utils.DefaultConfig(), game.NewLeaderboard())
+
+	recorder := httptest.NewRecorder()
+	server.HandleListRooms()(recorder, httptest.NewRequest(http.MethodGet, "/rooms/", nil))
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected a json list, got %d %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	entries := []map[string]interface{}{}
+	if err := json.NewDecoder(recorder.Body).Decode(&entries); err != nil {
+		t.Fatalf("Expected a list of rooms, got %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected the one room, got %+v", entries)
+	}
+	entry := entries[0]
+	if entry["id"] != id || entry["name"] != name {
+		t.Errorf("Expected the room's id %q and name %q, got %+v", id, name, entry)
+	}
+	//INFO The summary is flattened next to the id and name
+	for _, key := range []string{"players", "maxPlayers", "spectators", "inProgress", "joinable"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("Expected %q in the room entry, got %+v", key, entry)
+		}
+	}
+}
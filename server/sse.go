@@ -0,0 +1,42 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var errStreamClosed = errors.New("stream closed")
+
+// INFO Turns every write into a server sent event and flushes it right away
+type eventStreamWriter struct {
+	ctx     context.Context
+	done    chan struct{}
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (writer eventStreamWriter) Write(data []byte) (int, error) {
+	select {
+	case <-writer.ctx.Done():
+		return 0, errStreamClosed
+	case <-writer.done:
+		return 0, errStreamClosed
+	default:
+	}
+	//INFO Event data can't span lines, JSON frames never contain raw newlines but be safe anyway
+	_, err := fmt.Fprintf(writer.w, "data: %s\n\n", bytes.ReplaceAll(data, []byte("\n"), []byte("\ndata: ")))
+	if err != nil {
+		return 0, err
+	}
+	writer.flusher.Flush()
+	//INFO Callers check the count against what they passed in, not against the framed event
+	return len(data), nil
+}
+
+// INFO Ends every open stream on their next write, the http server waits for streaming handlers while shutting down
+func (s *Server) CloseStreams() {
+	s.closeStreams.Do(func() { close(s.streamsDone) })
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lguibr/pongo/game"
+	"github.com/lguibr/pongo/utils"
+)
+
+func TestEventStreamWriter_Write(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := eventStreamWriter{context.Background(), make(chan struct{}), recorder, recorder}
+
+	payload := []byte("{\"a\":1}\n{\"b\":2}")
+	written, err := writer.Write(payload)
+	if err != nil {
+		t.Fatalf("Expected the event to be written, got %v", err)
+	}
+	if written != len(payload) {
+		t.Errorf("Expected the payload length %d to be reported, got %d", len(payload), written)
+	}
+	if expected := "data: {\"a\":1}\ndata: {\"b\":2}\n\n"; recorder.Body.String() != expected {
+		t.Errorf("Expected every line of the payload in its own data field %q, got %q", expected, recorder.Body.String())
+	}
+	if !recorder.Flushed {
+		t.Errorf("Expected the event to be flushed")
+	}
+}
+
+func TestEventStreamWriter_Write_Closed(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	done := make(chan struct{})
+	writer := eventStreamWriter{context.Background(), done, recorder, recorder}
+	close(done)
+
+	written, err := writer.Write([]byte("{}"))
+	if err != errStreamClosed || written != 0 || recorder.Body.Len() != 0 {
+		t.Errorf("Expected nothing written to a closed stream, got %d %v %q", written, err, recorder.Body.String())
+	}
+}
+
+func TestServer_HandleStream_Room(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := New()
+	defer server.rooms.cancel()
+	mainGame := NewMainGame(ctx, utils.DefaultConfig(), game.NewLeaderboard())
+	_, name, _ := server.rooms.Create(utils.DefaultConfig(), game.NewLeaderboard())
+	room, _ := server.rooms.Get(name)
+	handler := server.HandleStream(mainGame)
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/stream?room=missing", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown room, got %d", recorder.Code)
+	}
+
+	streamCtx, stopStream := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream?room="+name, nil).WithContext(streamCtx))
+	}()
+	spectators := func(g *game.Game) int {
+		summary, ok := g.RequestSummary(time.Second)
+		if !ok {
+			t.Fatalf("Expected the game to answer")
+		}
+		return summary.Spectators
+	}
+	deadline := time.Now().Add(time.Second)
+	for spectators(room) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the stream to be counted as a spectator of the room")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if spectators(mainGame.Get()) != 0 {
+		t.Errorf("Expected the main game to have no spectators")
+	}
+
+	stopStream()
+	<-done
+	if spectators(room) != 0 {
+		t.Errorf("Expected the spectator to be unregistered once the stream ended")
+	}
+}
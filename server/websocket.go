@@ -1,24 +1,92 @@
 package server
 
 import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
 	"golang.org/x/net/websocket"
 )
 
 type Server struct {
-	connections map[*websocket.Conn]bool
+	mutex sync.Mutex
+	//INFO Whether each open connection holds a slot of the connection limit
+	connections  map[*websocket.Conn]bool
+	slots        chan struct{}
+	streamsDone  chan struct{}
+	closeStreams sync.Once
+	rooms        *Rooms
 }
 
 func New() *Server {
-	return &Server{connections: make(map[*websocket.Conn]bool)}
+	return &Server{connections: make(map[*websocket.Conn]bool), streamsDone: make(chan struct{}), rooms: NewRooms()}
+}
+
+type slotKey struct{}
+
+// INFO A slot taken for an upgrade request, the connection claims it once the handshake went through
+type connectionSlot struct {
+	claimed int32
+}
+
+// INFO Turns requests away with 503 once maxConnections websockets and event streams are open or opening, zero never turns them away
+func (s *Server) LimitConnections(maxConnections int, next http.Handler) http.Handler {
+	if maxConnections <= 0 {
+		return next
+	}
+	//INFO Every limited route shares the same slots
+	if s.slots == nil {
+		s.slots = make(chan struct{}, maxConnections)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			http.Error(w, "too many connections", http.StatusServiceUnavailable)
+			return
+		}
+		slot := &connectionSlot{}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), slotKey{}, slot)))
+		//INFO A failed handshake or an ended event stream never opens a connection, so nobody else gives the slot back
+		if atomic.CompareAndSwapInt32(&slot.claimed, 0, 1) {
+			<-s.slots
+		}
+	})
 }
 
 func (s *Server) OpenConnection(ws *websocket.Conn) {
-	s.connections[ws] = true
+	holdsSlot := false
+	if slot, ok := ws.Request().Context().Value(slotKey{}).(*connectionSlot); ok {
+		holdsSlot = atomic.CompareAndSwapInt32(&slot.claimed, 0, 1)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.connections[ws] = holdsSlot
 }
 
 func (s *Server) CloseConnection(ws *websocket.Conn) {
 	ws.Close()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	holdsSlot, open := s.connections[ws]
 	delete(s.connections, ws) // remove the connection from the map
+	if open && holdsSlot {
+		<-s.slots
+	}
+}
+
+func (s *Server) CloseConnections() {
+	s.mutex.Lock()
+	connections := make([]*websocket.Conn, 0, len(s.connections))
+	for ws := range s.connections {
+		connections = append(connections, ws)
+	}
+	s.mutex.Unlock()
+
+	for _, ws := range connections {
+		s.CloseConnection(ws)
+	}
 }
 
 func (s *Server) KeepConnection(ws *websocket.Conn) {
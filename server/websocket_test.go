@@ -0,0 +1,106 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestServer_LimitConnections(t *testing.T) {
+	server := New()
+	httpServer := httptest.NewServer(server.LimitConnections(1, websocket.Server{Handler: func(ws *websocket.Conn) {
+		server.OpenConnection(ws)
+		io.Copy(io.Discard, ws)
+		server.CloseConnection(ws)
+	}}))
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+	//INFO Slots are given back once the server notices, so a free slot may take a moment to show up
+	dialEventually := func() *websocket.Conn {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			ws, err := websocket.Dial(url, "", "http://localhost/")
+			if err == nil {
+				return ws
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the slot to be given back, got %v", err)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	get := func() int {
+		t.Helper()
+		response, err := http.Get(httpServer.URL)
+		if err != nil {
+			t.Fatalf("Expected a response, got %v", err)
+		}
+		response.Body.Close()
+		return response.StatusCode
+	}
+
+	first := dialEventually()
+	if _, err := websocket.Dial(url, "", "http://localhost/"); err == nil {
+		t.Errorf("Expected a second upgrade to be refused")
+	}
+	if status := get(); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while the only slot is taken, got %d", status)
+	}
+
+	first.Close()
+	second := dialEventually()
+	second.Close()
+
+	//INFO A plain request fails the handshake and never opens a connection
+	deadline := time.Now().Add(time.Second)
+	for status := get(); status != http.StatusBadRequest; status = get() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the handshake to fail once the slot is free, got %d", status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	third, err := websocket.Dial(url, "", "http://localhost/")
+	if err != nil {
+		t.Fatalf("Expected the failed handshake to give its slot back, got %v", err)
+	}
+	third.Close()
+}
+
+func TestServer_LimitConnections_Shared(t *testing.T) {
+	server := New()
+	release := make(chan struct{})
+	streaming := server.LimitConnections(1, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+	other := server.LimitConnections(1, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		streaming.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream", nil))
+	}()
+	deadline := time.Now().Add(time.Second)
+	for len(server.slots) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the stream to take the slot")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	recorder := httptest.NewRecorder()
+	other.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/subscribe", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the open stream to count against the other route, got %d", recorder.Code)
+	}
+
+	close(release)
+	<-done
+	recorder = httptest.NewRecorder()
+	other.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/subscribe", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected the ended stream to give its slot back, got %d", recorder.Code)
+	}
+}
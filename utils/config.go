@@ -0,0 +1,351 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	ReconnectGracePeriod     time.Duration      `json:"reconnectGracePeriod"`     //INFO Zero frees the slot immediately on disconnect
+	BroadcastPositionEpsilon int                `json:"broadcastPositionEpsilon"` //INFO Position/velocity changes up to this value don't trigger a new frame
+	MaxGameDuration          time.Duration      `json:"maxGameDuration"`          //INFO Zero lets a game run until all bricks are destroyed
+	AllowedOrigins           []string           `json:"allowedOrigins"`           //INFO Exact origins accepted by /subscribe, "*" accepts any
+	PingInterval             time.Duration      `json:"pingInterval"`             //INFO Zero disables the server pings
+	ServerTimeInterval       time.Duration      `json:"serverTimeInterval"`       //INFO How often clients get the server time again after the one sent on connect, zero only sends it on connect
+	MaxInputLagCompensation  time.Duration      `json:"maxInputLagCompensation"`  //INFO Longest lag a direction change is replayed for, measured from the ServerTime messages each client echoes back, at most once per window, zero disables it
+	PongTimeout              time.Duration      `json:"pongTimeout"`              //INFO Clients silent for this long are disconnected, zero waits forever
+	PowerUpShrinkRatio       float64            `json:"powerUpShrinkRatio"`       //INFO Fraction of the paddle length an opponent keeps while shrunk
+	PowerUpShrinkDuration    time.Duration      `json:"powerUpShrinkDuration"`
+	PowerUpMultiballCount    int                `json:"powerUpMultiballCount"`
+	ExplosiveBrickChance     float64            `json:"explosiveBrickChance"`    //INFO Chance of each brick being explosive when the grid is filled
+	SteelBrickRatio          float64            `json:"steelBrickRatio"`         //INFO Fraction of generated bricks turned into unbreakable steel
+	Gravity                  [2]float64         `json:"gravity"`                 //INFO Velocity added to every ball each tick, fractions accumulate across ticks
+	MaxBallVelocity          int                `json:"maxBallVelocity"`         //INFO Speed gravity can accelerate a ball up to
+	MaxBallRadius            int                `json:"maxBallRadius"`           //INFO Radius mass power-ups can grow a ball to, zero lets it grow without bound
+	MaxQueueLength           int                `json:"maxQueueLength"`          //INFO Players beyond this many waiting for a slot are rejected, zero never rejects
+	MaxConnections           int                `json:"maxConnections"`          //INFO Websockets and event streams open at once across players, spectators and rooms, requests beyond it get a 503, zero never refuses
+	PaddleHitCooldownTicks   int                `json:"paddleHitCooldownTicks"`  //INFO Ticks a ball ignores paddles after hitting one
+	AFKTimeout               time.Duration      `json:"afkTimeout"`              //INFO Time without moves or shots after which a player is flagged afk and their paddle stopped, zero never flags
+	MinDamageSpeed           int                `json:"minDamageSpeed"`          //INFO Balls slower than this only damage bricks on a matching share of hits, zero always damages
+	EndlessMode              bool               `json:"endlessMode"`             //INFO Refill the grid with a new wave instead of ending the game once all bricks are gone
+	EndlessWaveLifeIncrease  int                `json:"endlessWaveLifeIncrease"` //INFO Extra brick life added on every wave after the first
+	ScoreboardInterval       time.Duration      `json:"scoreboardInterval"`      //INFO How often changed scores are sent as a single scoreboard message
+	PaddleDashFactor         float64            `json:"paddleDashFactor"`        //INFO Paddle and ball speed multiplier while dashing, 1 or less disables dashes
+	PaddleDashDuration       time.Duration      `json:"paddleDashDuration"`
+	PaddleDashCooldown       time.Duration      `json:"paddleDashCooldown"`   //INFO Time between the start of two dashes
+	RandomSeed               int64              `json:"randomSeed"`           //INFO Seeds grids, balls and power-ups for reproducible games, zero picks one from the clock
+	BallSpinFactor           float64            `json:"ballSpinFactor"`       //INFO Spin given to a ball per unit of paddle speed at contact, zero disables curves
+	BallSpinDecay            float64            `json:"ballSpinDecay"`        //INFO Fraction of the spin a ball keeps every tick
+	MaxInputsPerSecond       int                `json:"maxInputsPerSecond"`   //INFO Inputs a player can send per second, faster ones are coalesced into the latest, zero disables the limit
+	PaddleAcceleration       float64            `json:"paddleAcceleration"`   //INFO Paddle speed gained or lost per tick, zero starts and stops instantly
+	PaddleEdgeAngleBoost     float64            `json:"paddleEdgeAngleBoost"` //INFO Share of the ball speed turned sideways by a hit on the very edge of a paddle, easing off quadratically toward the center, zero keeps plain reflections
+	PaddleInputBuffer        int                `json:"paddleInputBuffer"`    //INFO Direction changes a paddle queues and applies one per tick so quick taps give small nudges, zero applies the latest immediately
+	PowerUpSlowRatio         float64            `json:"powerUpSlowRatio"`     //INFO Fraction of their speed the breaker's balls keep while slowed
+	PowerUpSlowDuration      time.Duration      `json:"powerUpSlowDuration"`  //INFO How long the breaker's balls stay slowed
+	MaxOwnedBalls            int                `json:"maxOwnedBalls"`        //INFO Balls a player can own at once, power-up balls beyond it spawn ownerless, zero disables the cap
+	HealthCheckInterval      time.Duration      `json:"healthCheckInterval"`  //INFO How often the game is checked for being stuck without players, zero disables the check
+	HealthCheckTimeout       time.Duration      `json:"healthCheckTimeout"`   //INFO A health check not answered within this is missed
+	HealthCheckFailures      int                `json:"healthCheckFailures"`  //INFO Health checks a game may miss in a row before it is considered wedged and replaced
+	PowerUpLaserCharges      int                `json:"powerUpLaserCharges"`  //INFO Laser shots granted by the laser power-up
+	LaserSpeed               int                `json:"laserSpeed"`           //INFO Distance a laser travels per tick, at most a cell so it can't skip bricks
+	DynamicPaddleSize        bool               `json:"dynamicPaddleSize"`    //INFO Players behind on score get longer paddles and the leaders shorter ones
+	MinPaddleLength          int                `json:"minPaddleLength"`
+	MaxPaddleLength          int                `json:"maxPaddleLength"`
+	AdminToken               string             `json:"adminToken"`               //INFO Token expected in the X-Admin-Token header of admin requests, empty disables them
+	BallSpawnGracePeriod     time.Duration      `json:"ballSpawnGracePeriod"`     //INFO Time a newly spawned ball ignores paddles
+	PowerUpWeights           map[string]float64 `json:"powerUpWeights"`           //INFO Relative odds of each power-up by name, missing or zero weights never trigger
+	PermanentBallMaxLifetime time.Duration      `json:"permanentBallMaxLifetime"` //INFO Permanent balls nobody connected owns are removed after this long, zero keeps them forever
+	LogLevel                 string             `json:"logLevel"`                 //INFO One of debug, info, warn or error, messages below it are not logged
+	ScoreOnlyDirectHits      bool               `json:"scoreOnlyDirectHits"`      //INFO Owners only score on the first wall their ball reaches after leaving their paddle
+	CenterObstacle           string             `json:"centerObstacle"`           //INFO Steel shape drawn in the middle of every generated grid, one of cross, diamond or ring, empty for none
+	CornerWedges             bool               `json:"cornerWedges"`             //INFO Corners between two occupied paddle slots bounce balls back instead of letting them slip through
+	TransferBallsOnLeave     bool               `json:"transferBallsOnLeave"`     //INFO Balls of a player leaving go to the connected player owning the fewest instead of being removed
+	AbsoluteSpeedCapRatio    float64            `json:"absoluteSpeedCapRatio"`    //INFO No ball ever moves faster than maxBallVelocity times this, whatever power-ups or paddles did to it, zero disables the cap
+	GoalWidthRatio           float64            `json:"goalWidthRatio"`           //INFO Central fraction of each wall that concedes, balls hitting the rest just bounce, zero never concedes
+	PowerUpMagnetDuration    time.Duration      `json:"powerUpMagnetDuration"`    //INFO How long the magnet pulls the breaker's balls toward their paddle
+	PowerUpGhostDuration     time.Duration      `json:"powerUpGhostDuration"`     //INFO How long a ghost ball passes through paddles, it still bounces off walls and bricks
+	PowerUpMagnetStrength    float64            `json:"powerUpMagnetStrength"`    //INFO Velocity added per tick toward the paddle to magnetized balls on their owner's half
+	TickBudgetWarnRatio      float64            `json:"tickBudgetWarnRatio"`      //INFO Fraction of a tick period the rolling average physics tick may take before the game is degraded, zero disables the check
+	ThrottleWhenDegraded     bool               `json:"throttleWhenDegraded"`     //INFO Degraded games broadcast every other frame until their ticks are back within budget
+	ScoreMultiplierInterval  time.Duration      `json:"scoreMultiplierInterval"`  //INFO How often a score multiplier window opens for everyone, zero disables them
+	ScoreMultiplierValue     int                `json:"scoreMultiplierValue"`     //INFO Every score change is multiplied by this while a window is open
+	ScoreMultiplierDuration  time.Duration      `json:"scoreMultiplierDuration"`  //INFO How long each score multiplier window stays open
+	SuddenDeath              bool               `json:"suddenDeath"`              //INFO A game tied when maxGameDuration runs out goes on until the tie is broken
+	WriteTimeout             time.Duration      `json:"writeTimeout"`             //INFO Time a client has to accept each frame, zero waits forever
+	StuckBallTicks           int                `json:"stuckBallTicks"`           //INFO Ticks a ball may stay within half a cell before it is nudged free, zero never nudges
+	CoopMode                 bool               `json:"coopMode"`                 //INFO Players share one team score from bricks, walls never score and the game over reports the clear time
+	PhysicsSubsteps          int                `json:"physicsSubsteps"`          //INFO Times each ball moves and collides per tick with a share of its velocity, more keeps fast balls from tunneling
+	MinPlayersToStart        int                `json:"minPlayersToStart"`        //INFO Balls stay out of play and the game timer stopped until this many players are connected
+	StartCountdownSeconds    int                `json:"startCountdownSeconds"`    //INFO Seconds counted down once enough players are connected before the balls are put in play
+	MapFile                  string             `json:"mapFile"`                  //INFO Saved map every room and wave starts from instead of a generated grid, empty generates
+	InitialBallAimJitter     float64            `json:"initialBallAimJitter"`     //INFO Radians a launched ball may deviate from heading straight at the canvas center, zero always aims at the center
+	PlayerCount              int                `json:"playerCount"`              //INFO 2 for head-to-head on the right and left walls, 4 for all walls
+	MaxBallsPerRoom          int                `json:"maxBallsPerRoom"`          //INFO Spawn-ball power-ups are skipped at this count, zero disables the cap
+	MaxRooms                 int                `json:"maxRooms"`                 //INFO Private rooms open at once, creating more gets a 503, zero never refuses
+	RoomIdleTimeout          time.Duration      `json:"roomIdleTimeout"`          //INFO Private rooms nobody played or watched in for this long are closed, zero keeps them until the server stops
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ReconnectGracePeriod:     10 * time.Second,
+		BroadcastPositionEpsilon: 0,
+		MaxGameDuration:          0,
+		AllowedOrigins:           ParseList(os.Getenv("PONGO_ALLOWED_ORIGINS")),
+		PingInterval:             2 * time.Second,
+		ServerTimeInterval:       10 * time.Second,
+		MaxInputLagCompensation:  0,
+		PongTimeout:              6 * time.Second,
+		PowerUpShrinkRatio:       0.6,
+		PowerUpShrinkDuration:    5 * time.Second,
+		PowerUpMultiballCount:    3,
+		ExplosiveBrickChance:     0,
+		SteelBrickRatio:          0,
+		Gravity:                  [2]float64{0, 0},
+		MaxBallVelocity:          MaxVelocity * 3,
+		MaxBallRadius:            CellSize / 2,
+		MaxQueueLength:           16,
+		MaxConnections:           1024,
+		PaddleHitCooldownTicks:   3,
+		AFKTimeout:               0,
+		MinDamageSpeed:           0,
+		EndlessMode:              false,
+		EndlessWaveLifeIncrease:  1,
+		ScoreboardInterval:       500 * time.Millisecond,
+		PaddleDashFactor:         2,
+		PaddleDashDuration:       300 * time.Millisecond,
+		PaddleDashCooldown:       2 * time.Second,
+		RandomSeed:               0,
+		BallSpinFactor:           0,
+		BallSpinDecay:            0.95,
+		MaxInputsPerSecond:       0,
+		PaddleAcceleration:       0,
+		PaddleInputBuffer:        0,
+		PaddleEdgeAngleBoost:     0,
+		PowerUpSlowRatio:         0.5,
+		PowerUpSlowDuration:      3 * time.Second,
+		MaxOwnedBalls:            0,
+		HealthCheckInterval:      30 * time.Second,
+		HealthCheckTimeout:       5 * time.Second,
+		HealthCheckFailures:      3,
+		PowerUpLaserCharges:      3,
+		LaserSpeed:               CellSize / 4,
+		DynamicPaddleSize:        false,
+		MinPaddleLength:          PaddleLength * 3 / 4,
+		MaxPaddleLength:          PaddleLength * 3 / 2,
+		AdminToken:               os.Getenv("PONGO_ADMIN_TOKEN"),
+		BallSpawnGracePeriod:     250 * time.Millisecond,
+		PowerUpWeights:           DefaultPowerUpWeights(),
+		PermanentBallMaxLifetime: 0,
+		LogLevel:                 logLevelFromEnv(),
+		ScoreOnlyDirectHits:      false,
+		CenterObstacle:           "",
+		CornerWedges:             false,
+		TransferBallsOnLeave:     false,
+		AbsoluteSpeedCapRatio:    1.2,
+		GoalWidthRatio:           1,
+		PowerUpMagnetDuration:    5 * time.Second,
+		PowerUpGhostDuration:     3 * time.Second,
+		PowerUpMagnetStrength:    0.4,
+		TickBudgetWarnRatio:      0.5,
+		ThrottleWhenDegraded:     true,
+		ScoreMultiplierInterval:  0,
+		ScoreMultiplierValue:     2,
+		ScoreMultiplierDuration:  10 * time.Second,
+		SuddenDeath:              false,
+		WriteTimeout:             2 * time.Second,
+		StuckBallTicks:           50,
+		CoopMode:                 false,
+		PhysicsSubsteps:          1,
+		MinPlayersToStart:        1,
+		StartCountdownSeconds:    0,
+		MapFile:                  "",
+		InitialBallAimJitter:     math.Pi / 4,
+		PlayerCount:              4,
+		MaxBallsPerRoom:          16,
+		MaxRooms:                 32,
+		RoomIdleTimeout:          10 * time.Minute,
+	}
+}
+
+// INFO Reads a JSON config file on top of the defaults, so fields missing from the file keep their default value
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read config file: %w", err)
+	}
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return config, config.Validate()
+}
+
+// INFO Checks the invariants the game relies on, so a broken config fails on start instead of misbehaving mid game
+func (config Config) Validate() error {
+	if CanvasSize%GridSize != 0 {
+		return fmt.Errorf("canvas size %d must be divisible by grid size %d", CanvasSize, GridSize)
+	}
+	if GridSize%2 != 0 {
+		return fmt.Errorf("grid size %d must be even", GridSize)
+	}
+	if config.PlayerCount != 2 && config.PlayerCount != 4 {
+		return fmt.Errorf("playerCount %d must be 2 or 4", config.PlayerCount)
+	}
+	if config.MaxBallVelocity < MaxVelocity {
+		return fmt.Errorf("maxBallVelocity %d must be at least the spawn velocity %d", config.MaxBallVelocity, MaxVelocity)
+	}
+	if config.ScoreboardInterval <= 0 {
+		return fmt.Errorf("scoreboardInterval %v must be positive", config.ScoreboardInterval)
+	}
+	if config.PingInterval > 0 && config.PongTimeout > 0 && config.PongTimeout <= config.PingInterval {
+		return fmt.Errorf("pongTimeout %v must be longer than pingInterval %v", config.PongTimeout, config.PingInterval)
+	}
+	if config.HealthCheckInterval > 0 && config.HealthCheckTimeout <= 0 {
+		return fmt.Errorf("healthCheckTimeout %v must be positive while health checks are enabled", config.HealthCheckTimeout)
+	}
+	if config.HealthCheckInterval > 0 && config.HealthCheckFailures <= 0 {
+		return fmt.Errorf("healthCheckFailures %d must be positive while health checks are enabled", config.HealthCheckFailures)
+	}
+	if config.LaserSpeed <= 0 || config.LaserSpeed > CellSize {
+		return fmt.Errorf("laserSpeed %d must be between 1 and the cell size %d", config.LaserSpeed, CellSize)
+	}
+	if config.DynamicPaddleSize && (config.MinPaddleLength <= 0 || config.MinPaddleLength > PaddleLength || config.MaxPaddleLength < PaddleLength || config.MaxPaddleLength > CanvasSize) {
+		return fmt.Errorf("paddle lengths must satisfy 0 < minPaddleLength %d <= %d <= maxPaddleLength %d <= %d", config.MinPaddleLength, PaddleLength, config.MaxPaddleLength, CanvasSize)
+	}
+	_, err := ParseLogLevel(config.LogLevel)
+	if err != nil {
+		return err
+	}
+	if config.CenterObstacle != "" {
+		known := false
+		for _, obstacle := range CenterObstacles {
+			known = known || config.CenterObstacle == obstacle
+		}
+		if !known {
+			return fmt.Errorf("centerObstacle %q must be one of %v", config.CenterObstacle, CenterObstacles)
+		}
+	}
+	for name, weight := range config.PowerUpWeights {
+		known := false
+		for _, powerUpName := range PowerUpNames {
+			known = known || name == powerUpName
+		}
+		if !known {
+			return fmt.Errorf("powerUpWeights has unknown power-up %q", name)
+		}
+		if weight < 0 {
+			return fmt.Errorf("powerUpWeights %s %v must not be negative", name, weight)
+		}
+	}
+	if config.PhysicsSubsteps < 1 {
+		return fmt.Errorf("physicsSubsteps %d must be at least 1", config.PhysicsSubsteps)
+	}
+	if config.MinPlayersToStart < 0 || config.MinPlayersToStart > config.PlayerCount {
+		return fmt.Errorf("minPlayersToStart %d must be between 0 and playerCount %d", config.MinPlayersToStart, config.PlayerCount)
+	}
+	if config.AbsoluteSpeedCapRatio != 0 && config.AbsoluteSpeedCapRatio < 1 {
+		return fmt.Errorf("absoluteSpeedCapRatio %v must be zero or at least 1", config.AbsoluteSpeedCapRatio)
+	}
+	if config.InitialBallAimJitter < 0 || config.InitialBallAimJitter > math.Pi/2 {
+		return fmt.Errorf("initialBallAimJitter %v must be between 0 and pi/2", config.InitialBallAimJitter)
+	}
+	if config.MaxBallRadius != 0 && config.MaxBallRadius < BallSize {
+		return fmt.Errorf("maxBallRadius %d must be zero or at least the ball size %d", config.MaxBallRadius, BallSize)
+	}
+	if config.MaxInputLagCompensation < 0 {
+		return fmt.Errorf("maxInputLagCompensation %v must not be negative", config.MaxInputLagCompensation)
+	}
+	if config.MaxGameDuration < 0 {
+		return fmt.Errorf("maxGameDuration %v must not be negative", config.MaxGameDuration)
+	}
+	if config.RoomIdleTimeout < 0 {
+		return fmt.Errorf("roomIdleTimeout %v must not be negative", config.RoomIdleTimeout)
+	}
+	if config.PaddleEdgeAngleBoost < 0 {
+		return fmt.Errorf("paddleEdgeAngleBoost %v must not be negative", config.PaddleEdgeAngleBoost)
+	}
+	if config.PowerUpMagnetStrength < 0 {
+		return fmt.Errorf("powerUpMagnetStrength %v must not be negative", config.PowerUpMagnetStrength)
+	}
+	//INFO Slowed balls are restored by dividing by the ratio, so it can't be zero
+	if config.PowerUpSlowRatio == 0 {
+		return fmt.Errorf("powerUpSlowRatio must not be zero")
+	}
+	ratios := []struct {
+		name  string
+		value float64
+	}{
+		{"powerUpShrinkRatio", config.PowerUpShrinkRatio},
+		{"powerUpSlowRatio", config.PowerUpSlowRatio},
+		{"explosiveBrickChance", config.ExplosiveBrickChance},
+		{"steelBrickRatio", config.SteelBrickRatio},
+		{"ballSpinDecay", config.BallSpinDecay},
+		{"tickBudgetWarnRatio", config.TickBudgetWarnRatio},
+		{"goalWidthRatio", config.GoalWidthRatio},
+	}
+	for _, ratio := range ratios {
+		if ratio.value < 0 || ratio.value > 1 {
+			return fmt.Errorf("%s %v must be between 0 and 1", ratio.name, ratio.value)
+		}
+	}
+	counts := []struct {
+		name  string
+		value int
+	}{
+		{"broadcastPositionEpsilon", config.BroadcastPositionEpsilon},
+		{"powerUpMultiballCount", config.PowerUpMultiballCount},
+		{"maxQueueLength", config.MaxQueueLength},
+		{"maxConnections", config.MaxConnections},
+		{"paddleHitCooldownTicks", config.PaddleHitCooldownTicks},
+		{"minDamageSpeed", config.MinDamageSpeed},
+		{"maxInputsPerSecond", config.MaxInputsPerSecond},
+		{"paddleInputBuffer", config.PaddleInputBuffer},
+		{"maxBallsPerRoom", config.MaxBallsPerRoom},
+		{"maxOwnedBalls", config.MaxOwnedBalls},
+		{"powerUpLaserCharges", config.PowerUpLaserCharges},
+		{"startCountdownSeconds", config.StartCountdownSeconds},
+		{"scoreMultiplierValue", config.ScoreMultiplierValue},
+		{"stuckBallTicks", config.StuckBallTicks},
+		{"maxRooms", config.MaxRooms},
+	}
+	for _, count := range counts {
+		if count.value < 0 {
+			return fmt.Errorf("%s %d must not be negative", count.name, count.value)
+		}
+	}
+	return nil
+}
+
+func logLevelFromEnv() string {
+	level := os.Getenv("PONGO_LOG_LEVEL")
+	if level == "" {
+		return "info"
+	}
+	return level
+}
+
+// INFO Every power-up equally likely except ghost balls, which are stronger and so four times rarer
+func DefaultPowerUpWeights() map[string]float64 {
+	weights := map[string]float64{}
+	for _, name := range PowerUpNames {
+		weights[name] = 1
+	}
+	weights["ghost"] = 0.25
+	return weights
+}
+
+func ParseList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseList(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected []string
+	}{
+		{"", []string{}},
+		{"*", []string{"*"}},
+		{"https://a.com, https://b.com", []string{"https://a.com", "https://b.com"}},
+		{" ,https://a.com,, ", []string{"https://a.com"}},
+	}
+	for _, tc := range testCases {
+		result := ParseList(tc.value)
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("ParseList(%q) = %v, want %v", tc.value, result, tc.expected)
+		}
+	}
+}
+
+func TestDefaultConfig_AllowedOrigins(t *testing.T) {
+	t.Setenv("PONGO_ALLOWED_ORIGINS", "https://myfrontend.com")
+	config := DefaultConfig()
+	if !reflect.DeepEqual(config.AllowedOrigins, []string{"https://myfrontend.com"}) {
+		t.Errorf("Expected allowed origins from the environment, got %v", config.AllowedOrigins)
+	}
+}
+
+func TestDefaultConfig_Baseline(t *testing.T) {
+	config := DefaultConfig()
+	//INFO Gameplay options that change how a match plays stay off until a config turns them on
+	disabled := map[string]float64{
+		"explosiveBrickChance":    config.ExplosiveBrickChance,
+		"steelBrickRatio":         config.SteelBrickRatio,
+		"ballSpinFactor":          config.BallSpinFactor,
+		"paddleAcceleration":      config.PaddleAcceleration,
+		"paddleInputBuffer":       float64(config.PaddleInputBuffer),
+		"maxOwnedBalls":           float64(config.MaxOwnedBalls),
+		"maxInputsPerSecond":      float64(config.MaxInputsPerSecond),
+		"maxInputLagCompensation": float64(config.MaxInputLagCompensation),
+	}
+	for name, value := range disabled {
+		if value != 0 {
+			t.Errorf("Expected %s to be disabled by default, got %v", name, value)
+		}
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected the default config to be valid, got %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{"playerCount": 2, "endlessMode": true, "pingInterval": 1000000000}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Expected config to load, got %v", err)
+	}
+	if config.PlayerCount != 2 || !config.EndlessMode || config.PingInterval != time.Second {
+		t.Errorf("Expected fields from the file to be applied, got %+v", config)
+	}
+	if config.MaxBallsPerRoom != DefaultConfig().MaxBallsPerRoom {
+		t.Errorf("Expected missing fields to keep their defaults, got MaxBallsPerRoom %d", config.MaxBallsPerRoom)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+	}{
+		{"invalid json", `{"playerCount": `},
+		{"player count", `{"playerCount": 3}`},
+		{"max ball velocity", `{"maxBallVelocity": 1}`},
+	}
+	for _, tc := range testCases {
+		path := filepath.Join(t.TempDir(), "config.json")
+		err := os.WriteFile(path, []byte(tc.contents), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = LoadConfig(path)
+		if err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	if err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(config *Config)
+	}{
+		{"player count", func(config *Config) { config.PlayerCount = 3 }},
+		{"max ball velocity", func(config *Config) { config.MaxBallVelocity = MaxVelocity - 1 }},
+		{"scoreboard interval", func(config *Config) { config.ScoreboardInterval = 0 }},
+		{"pong timeout", func(config *Config) { config.PongTimeout = config.PingInterval }},
+		{"health check timeout", func(config *Config) { config.HealthCheckTimeout = 0 }},
+		{"health check failures", func(config *Config) { config.HealthCheckFailures = 0 }},
+		{"laser speed", func(config *Config) { config.LaserSpeed = CellSize + 1 }},
+		{"laser charges", func(config *Config) { config.PowerUpLaserCharges = -1 }},
+		{"min paddle length", func(config *Config) { config.DynamicPaddleSize, config.MinPaddleLength = true, PaddleLength+1 }},
+		{"max paddle length", func(config *Config) { config.DynamicPaddleSize, config.MaxPaddleLength = true, PaddleLength-1 }},
+		{"unknown power-up weight", func(config *Config) { config.PowerUpWeights["teleport"] = 1 }},
+		{"negative power-up weight", func(config *Config) { config.PowerUpWeights["phasing"] = -1 }},
+		{"log level", func(config *Config) { config.LogLevel = "verbose" }},
+		{"center obstacle", func(config *Config) { config.CenterObstacle = "spiral" }},
+		{"shrink ratio", func(config *Config) { config.PowerUpShrinkRatio = 1.5 }},
+		{"slow ratio", func(config *Config) { config.PowerUpSlowRatio = -0.5 }},
+		{"zero slow ratio", func(config *Config) { config.PowerUpSlowRatio = 0 }},
+		{"explosive chance", func(config *Config) { config.ExplosiveBrickChance = 2 }},
+		{"steel ratio", func(config *Config) { config.SteelBrickRatio = -1 }},
+		{"spin decay", func(config *Config) { config.BallSpinDecay = 1.1 }},
+		{"lag compensation", func(config *Config) { config.MaxInputLagCompensation = -time.Millisecond }},
+		{"stuck ball ticks", func(config *Config) { config.StuckBallTicks = -1 }},
+		{"substeps", func(config *Config) { config.PhysicsSubsteps = 0 }},
+		{"min players", func(config *Config) { config.MinPlayersToStart = 5 }},
+		{"speed cap", func(config *Config) { config.AbsoluteSpeedCapRatio = 0.5 }},
+		{"goal width", func(config *Config) { config.GoalWidthRatio = 1.2 }},
+		{"magnet strength", func(config *Config) { config.PowerUpMagnetStrength = -1 }},
+		{"tick budget ratio", func(config *Config) { config.TickBudgetWarnRatio = 1.5 }},
+		{"position epsilon", func(config *Config) { config.BroadcastPositionEpsilon = -1 }},
+		{"multiball count", func(config *Config) { config.PowerUpMultiballCount = -1 }},
+		{"queue length", func(config *Config) { config.MaxQueueLength = -1 }},
+		{"max connections", func(config *Config) { config.MaxConnections = -1 }},
+		{"hit cooldown", func(config *Config) { config.PaddleHitCooldownTicks = -1 }},
+		{"min damage speed", func(config *Config) { config.MinDamageSpeed = -1 }},
+		{"inputs per second", func(config *Config) { config.MaxInputsPerSecond = -1 }},
+		{"input buffer", func(config *Config) { config.PaddleInputBuffer = -1 }},
+		{"edge angle boost", func(config *Config) { config.PaddleEdgeAngleBoost = -1 }},
+		{"max ball radius", func(config *Config) { config.MaxBallRadius = 1 }},
+		{"balls per room", func(config *Config) { config.MaxBallsPerRoom = -1 }},
+		{"max rooms", func(config *Config) { config.MaxRooms = -1 }},
+		{"max game duration", func(config *Config) { config.MaxGameDuration = -time.Minute }},
+		{"room idle timeout", func(config *Config) { config.RoomIdleTimeout = -time.Minute }},
+		{"aim jitter", func(config *Config) { config.InitialBallAimJitter = math.Pi }},
+	}
+
+	err := DefaultConfig().Validate()
+	if err != nil {
+		t.Fatalf("Expected the default config to be valid, got %v", err)
+	}
+	for _, tc := range testCases {
+		config := DefaultConfig()
+		tc.modify(&config)
+		err := config.Validate()
+		if err == nil {
+			t.Errorf("%s: expected a validation error", tc.name)
+		}
+	}
+}
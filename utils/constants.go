@@ -7,6 +7,9 @@ const (
 
 	InitialScore = 100
 
+	GameOverRestartDelay = 5 * time.Second
+	MaxPlayerNameLength  = 16
+
 	CanvasSize = 576 //INFO Must be divisible by GridSize
 	GridSize   = 12  //INFO Must be divisible by 2
 
@@ -31,18 +34,29 @@ const (
 	brick CellType = iota
 	block
 	empty
+	explosive
+	steel
 )
 
 type cellTypes struct {
-	Brick CellType
-	Block CellType
-	Empty CellType
+	Brick     CellType
+	Block     CellType
+	Empty     CellType
+	Explosive CellType
+	Steel     CellType
 }
 
 var Cells = cellTypes{
-	Brick: brick,
-	Block: block,
-	Empty: empty,
+	Brick:     brick,
+	Block:     block,
+	Empty:     empty,
+	Explosive: explosive,
+	Steel:     steel,
+}
+
+// INFO Explosive bricks are bricks too, they just take their neighbors with them, steel never breaks so it doesn't count
+func (cellType CellType) IsBrick() bool {
+	return cellType == brick || cellType == explosive
 }
 
 func (cellType CellType) String() string {
@@ -53,7 +67,28 @@ func (cellType CellType) String() string {
 		return "Block"
 	case empty:
 		return "Empty"
+	case explosive:
+		return "Explosive"
+	case steel:
+		return "Steel"
 	default:
 		return "Unknown"
 	}
 }
+
+var CenterObstacles = []string{"cross", "diamond", "ring"}
+
+// INFO Names of the power-ups in the order the game numbers them, used as keys of the power-up weights
+var PowerUpNames = []string{
+	"spawnBall",
+	"increaseMass",
+	"increaseVelocity",
+	"phasing",
+	"stickyPaddle",
+	"shrinkOpponentPaddle",
+	"multiball",
+	"slowMotion",
+	"laser",
+	"magnet",
+	"ghost",
+}
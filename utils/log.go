@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+type LogLevel int32
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var logLevelNames = map[LogLevel]string{
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+}
+
+var currentLogLevel = int32(LevelInfo)
+
+func ParseLogLevel(level string) (LogLevel, error) {
+	for logLevel, name := range logLevelNames {
+		if strings.EqualFold(level, name) {
+			return logLevel, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", level)
+}
+
+func SetLogLevel(level LogLevel) {
+	atomic.StoreInt32(&currentLogLevel, int32(level))
+}
+
+func LogEnabled(level LogLevel) bool {
+	return level >= LogLevel(atomic.LoadInt32(&currentLogLevel))
+}
+
+// INFO Formats a log line as level=... msg="..." followed by the key value pairs, a trailing key without value is dropped
+func FormatLog(level LogLevel, message string, keyValues ...interface{}) string {
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "level=%s msg=%q", logLevelNames[level], message)
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		fmt.Fprintf(builder, " %v=%q", keyValues[i], fmt.Sprint(keyValues[i+1]))
+	}
+	return builder.String()
+}
+
+func logAt(level LogLevel, message string, keyValues ...interface{}) {
+	if !LogEnabled(level) {
+		return
+	}
+	log.Println(FormatLog(level, message, keyValues...))
+}
+
+func LogDebug(message string, keyValues ...interface{}) { logAt(LevelDebug, message, keyValues...) }
+func LogInfo(message string, keyValues ...interface{})  { logAt(LevelInfo, message, keyValues...) }
+func LogWarn(message string, keyValues ...interface{})  { logAt(LevelWarn, message, keyValues...) }
+func LogError(message string, keyValues ...interface{}) { logAt(LevelError, message, keyValues...) }
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	testCases := []struct {
+		level    string
+		expected LogLevel
+		valid    bool
+	}{
+		{"debug", LevelDebug, true},
+		{"INFO", LevelInfo, true},
+		{"Warn", LevelWarn, true},
+		{"error", LevelError, true},
+		{"verbose", LevelInfo, false},
+	}
+	for _, tc := range testCases {
+		level, err := ParseLogLevel(tc.level)
+		if level != tc.expected || (err == nil) != tc.valid {
+			t.Errorf("ParseLogLevel(%q) = %v, %v, want %v with valid %v", tc.level, level, err, tc.expected, tc.valid)
+		}
+	}
+}
+
+func TestFormatLog(t *testing.T) {
+	line := FormatLog(LevelWarn, "Error reading", "player", 2, "err", "EOF", "dangling")
+	expected := `level=WARN msg="Error reading" player="2" err="EOF"`
+	if line != expected {
+		t.Errorf("FormatLog() = %s, want %s", line, expected)
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	log.SetOutput(buffer)
+	defer log.SetOutput(os.Stderr)
+	defer SetLogLevel(LevelInfo)
+
+	SetLogLevel(LevelError)
+	LogInfo("informational spam")
+	LogError("something broke")
+
+	output := buffer.String()
+	if strings.Contains(output, "informational spam") {
+		t.Errorf("Expected info logs to be suppressed at the error level, got %s", output)
+	}
+	if !strings.Contains(output, "something broke") {
+		t.Errorf("Expected error logs to be kept at the error level, got %s", output)
+	}
+}
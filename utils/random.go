@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// INFO rand.Rand isn't safe for concurrent use, the game shares one between its routines through this source
+type lockedSource struct {
+	mutex  sync.Mutex
+	source rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.source.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.source.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.source.Seed(seed)
+}
+
+// INFO A zero seed picks one from the clock so games differ unless a seed is configured
+func NewRandom(seed int64) *rand.Rand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(&lockedSource{source: rand.NewSource(seed).(rand.Source64)})
+}
@@ -7,7 +7,6 @@ import (
 	"math/rand"
 	"os"
 	"testing"
-	"time"
 )
 
 // DEV Matrix
@@ -46,21 +45,19 @@ func RotateVector(index int, x int, y int, canvasWidth int, canvasHeight int) (i
 }
 
 // DEV Vector
-func NewPositiveRandomVector(vectorMaxLen int) [2]int {
+func NewPositiveRandomVector(random *rand.Rand, vectorMaxLen int) [2]int {
 	maxCoordinateSize := int(math.Max(float64(vectorMaxLen)/(2*math.Sqrt(2)), 1.0))
-	x := rand.Intn(maxCoordinateSize)
-	rand.Seed(time.Now().UnixNano())
-	y := rand.Intn(maxCoordinateSize)
+	x := random.Intn(maxCoordinateSize)
+	y := random.Intn(maxCoordinateSize)
 
 	return [2]int{x, y}
 }
 
 // DEV Vector
-func NewRandomVector(vectorMaxLen int) [2]int {
+func NewRandomVector(random *rand.Rand, vectorMaxLen int) [2]int {
 	maxCoordinateSize := int((math.Max(float64(vectorMaxLen)/2*math.Sqrt(2), 1.0)))
-	x := rand.Intn(maxCoordinateSize)*2 - maxCoordinateSize
-	rand.Seed(time.Now().UnixNano())
-	y := rand.Intn(maxCoordinateSize)*2 - maxCoordinateSize
+	x := random.Intn(maxCoordinateSize)*2 - maxCoordinateSize
+	y := random.Intn(maxCoordinateSize)*2 - maxCoordinateSize
 	return [2]int{x, y}
 }
 
@@ -127,14 +124,14 @@ func SwapVectorCoordinates(vector [2]int) [2]int {
 }
 
 // DEV Vector
-func NewRandomPositiveVectors(numberOfVectors, maxVectorSize int) [][2]int {
+func NewRandomPositiveVectors(random *rand.Rand, numberOfVectors, maxVectorSize int) [][2]int {
 	seedVectors := make([][2]int, numberOfVectors)
 	for index := range seedVectors {
-		currentLength := rand.Intn(maxVectorSize)
+		currentLength := random.Intn(maxVectorSize)
 		if currentLength == 0 || currentLength > maxVectorSize {
 			currentLength = maxVectorSize
 		}
-		seedVectors[index] = NewPositiveRandomVector(currentLength)
+		seedVectors[index] = NewPositiveRandomVector(random, currentLength)
 	}
 	return seedVectors
 }
@@ -148,31 +145,45 @@ func Distance(x1, y1, x2, y2 int) float64 {
 }
 
 // DEV Number
-func RandomNumber(amplitude int) int {
-	return rand.Intn(amplitude*2) - amplitude
+func RandomNumber(random *rand.Rand, amplitude int) int {
+	return random.Intn(amplitude*2) - amplitude
 }
 
-var randomNumberN func(amplitude int) int
-
-func RandomNumberN(amplitude int) int {
-	randomNumberN = func(amplitude int) int {
-		value := rand.Intn(amplitude*2) - amplitude
-		if value == 0 {
-			value = RandomNumberN(amplitude)
-		}
-		return value
+func RandomNumberN(random *rand.Rand, amplitude int) int {
+	value := random.Intn(amplitude*2) - amplitude
+	if value == 0 {
+		return RandomNumberN(random, amplitude)
 	}
-	return randomNumberN(amplitude)
+	return value
 }
 
 // DEV Number
+// INFO The most negative int has no positive counterpart, it saturates to the largest int instead of staying negative
 func Abs(x int) int {
+	if x == math.MinInt {
+		return math.MaxInt
+	}
 	if x < 0 {
 		return -x
 	}
 	return x
 }
 
+// INFO Integer min and max without a round trip through float64, which loses precision on large values
+func MinInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func MaxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 // DEV string
 func DirectionFromString(direction string) string {
 	if direction == "ArrowLeft" {
@@ -184,8 +195,8 @@ func DirectionFromString(direction string) string {
 }
 
 // DEV color
-func NewRandomColor() [3]int {
-	return [3]int{rand.Intn(255), rand.Intn(255), rand.Intn(255)}
+func NewRandomColor(random *rand.Rand) [3]int {
+	return [3]int{random.Intn(255), random.Intn(255), random.Intn(255)}
 }
 
 func AssertPanics(t *testing.T, testingFunction func(), message string) (panics bool, errorMessage string) {
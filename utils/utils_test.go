@@ -24,8 +24,9 @@ func TestDirectionFromString(t *testing.T) {
 
 func TestNewRandomColor(t *testing.T) {
 	// Test that all elements of the returned array are between 0 and 255 inclusive
+	random := NewRandom(1)
 	for i := 0; i < 100; i++ {
-		color := NewRandomColor()
+		color := NewRandomColor(random)
 		for i := range color {
 			if color[i] < 0 || color[i] > 255 {
 				t.Errorf("NewRandomColor() returned an invalid color value: %d", color[i])
@@ -144,7 +145,7 @@ func TestTransformMatrix(t *testing.T) {
 
 func TestNewPositiveRandomVector(t *testing.T) {
 	size := 10
-	vector := NewPositiveRandomVector(size)
+	vector := NewPositiveRandomVector(NewRandom(1), size)
 	if vector[0] < 0 || vector[1] < 0 {
 		t.Errorf("NewPositiveRandomVector(%d) = %v, want positive values", size, vector)
 	}
@@ -153,8 +154,9 @@ func TestNewPositiveRandomVector(t *testing.T) {
 func TestNewRandomVector(t *testing.T) {
 	size := 10
 	// Call the function multiple times and check if the returned vector is within bounds
+	random := NewRandom(1)
 	for i := 0; i < 100; i++ {
-		vector := NewRandomVector(size)
+		vector := NewRandomVector(random, size)
 		if math.Abs(float64(vector[0])) > float64(size) || math.Abs(float64(vector[1])) > float64(size) {
 			t.Errorf("Expected vector to be within bounds, got %v", vector)
 		}
@@ -194,6 +196,7 @@ func TestAbs(t *testing.T) {
 		{1, 1, "Positive value"},
 		{-1, 1, "Negative value"},
 		{0, 0, "Zero value"},
+		{math.MinInt, math.MaxInt, "Most negative value"},
 	}
 	for _, tc := range testCases {
 		result := Abs(tc.x)
@@ -203,6 +206,26 @@ func TestAbs(t *testing.T) {
 	}
 }
 
+func TestMinMaxInt(t *testing.T) {
+	testCases := []struct {
+		a, b     int
+		min, max int
+	}{
+		{1, 2, 1, 2},
+		{-3, -7, -7, -3},
+		{math.MaxInt, math.MaxInt - 1, math.MaxInt - 1, math.MaxInt},
+		{math.MinInt, 0, math.MinInt, 0},
+	}
+	for _, tc := range testCases {
+		if got := MinInt(tc.a, tc.b); got != tc.min {
+			t.Errorf("MinInt(%d, %d) = %d, want %d", tc.a, tc.b, got, tc.min)
+		}
+		if got := MaxInt(tc.a, tc.b); got != tc.max {
+			t.Errorf("MaxInt(%d, %d) = %d, want %d", tc.a, tc.b, got, tc.max)
+		}
+	}
+}
+
 func TestSubtractVectors(t *testing.T) {
 	testCases := []struct {
 		vectorA  [2]int
@@ -389,13 +412,13 @@ func TestNewRandomPositiveVectors(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		if tc.panics {
-			panics, err := AssertPanics(t, func() { NewRandomPositiveVectors(tc.n, tc.size) }, "")
+			panics, err := AssertPanics(t, func() { NewRandomPositiveVectors(NewRandom(1), tc.n, tc.size) }, "")
 			if !panics {
 				t.Errorf("Expected panic for %s, got %v", tc.name, err)
 			}
 		} else {
 
-			result := NewRandomPositiveVectors(tc.n, tc.size)
+			result := NewRandomPositiveVectors(NewRandom(1), tc.n, tc.size)
 			if len(result) != tc.n {
 				t.Errorf("NewRandomPositiveVectors(%d, %d) = %v, want %d vectors", tc.n, tc.size, result, tc.n)
 			}
@@ -447,7 +470,7 @@ func TestRandomNumber(t *testing.T) {
 	}
 
 	for _, test := range testCases {
-		result := RandomNumber(test.amplitude)
+		result := RandomNumber(NewRandom(1), test.amplitude)
 		if result < test.expectedMin || result > test.expectedMax {
 			t.Errorf("Expected random number between %d and %d for amplitude %d, got %d", test.expectedMin, test.expectedMax, test.amplitude, result)
 		}
@@ -467,10 +490,11 @@ func TestRandomNumberN(t *testing.T) {
 	}
 
 	// Iterate over test cases
+	random := NewRandom(1)
 	for _, test := range testCases {
 		for i := 0; i < 100; i++ {
 			// Call the function and save the result
-			result := RandomNumberN(test.amplitude)
+			result := RandomNumberN(random, test.amplitude)
 
 			// Check that the result is within the expected range
 			if result < test.min || result > test.max {
@@ -505,3 +529,12 @@ func TestAssertPanics(t *testing.T) {
 		}
 	})
 }
+
+func TestNewRandom(t *testing.T) {
+	first, second := NewRandom(42), NewRandom(42)
+	for i := 0; i < 10; i++ {
+		if first.Int63() != second.Int63() {
+			t.Fatalf("Expected generators with the same seed to produce the same sequence")
+		}
+	}
+}